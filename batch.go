@@ -0,0 +1,178 @@
+package isql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+func NewSliceRowSource(rows [][]interface{}) RowSource {
+	return &sliceRowSource{
+		rows: rows,
+		idx:  -1,
+	}
+}
+
+type RowSource interface {
+	Next() bool
+	Values() []interface{}
+	Err() error
+}
+
+// BatchInsert inserts every row from rows into table using multi row INSERT ... VALUES statements,
+// each chunk is sized to stay within the dialects bind parameter and row limits. It returns the total rows affected.
+func BatchInsert(ctx context.Context, db DBCore, d Dialect, table string, columns []string, rows RowSource) (int64, error) {
+	if len(columns) == 0 {
+		return 0, errors.New("isql: batch insert requires at least one column")
+	}
+	chunkSize := d.MaxParams() / len(columns)
+	if chunkSize == 0 {
+		return 0, fmt.Errorf("isql: batch insert of %d columns exceeds %s parameter limit of %d", len(columns), d.Name(), d.MaxParams())
+	}
+	if maxRows := d.MaxRows(); maxRows > 0 && chunkSize > maxRows {
+		chunkSize = maxRows
+	}
+	b := &batchInserter{
+		ctx:     ctx,
+		db:      db,
+		d:       d,
+		prefix:  batchInsertPrefix(d, table, columns),
+		columns: len(columns),
+		args:    make([]interface{}, 0, chunkSize*len(columns)),
+	}
+	for rows.Next() {
+		values := rows.Values()
+		if len(values) != len(columns) {
+			return b.total, fmt.Errorf("isql: batch insert row %d has %d values, expected %d", b.rowCount+b.pending, len(values), len(columns))
+		}
+		b.args = append(b.args, values...)
+		b.pending++
+		if b.pending == chunkSize {
+			if err := b.flush(); err != nil {
+				return b.total, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return b.total, err
+	}
+	if err := b.flush(); err != nil {
+		return b.total, err
+	}
+	return b.total, nil
+}
+
+// BatchInsertTx is BatchInsert run inside a single transaction which is rolled back if any chunk fails.
+func BatchInsertTx(ctx context.Context, db DB, opts *sql.TxOptions, d Dialect, table string, columns []string, rows RowSource) (int64, error) {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return 0, err
+	}
+	total, err := BatchInsert(ctx, tx, d, table, columns, rows)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+type batchInserter struct {
+	ctx       context.Context
+	db        DBCore
+	d         Dialect
+	prefix    string
+	columns   int
+	args      []interface{}
+	pending   int
+	rowCount  int
+	total     int64
+	fullQuery string
+}
+
+func (b *batchInserter) flush() error {
+	if b.pending == 0 {
+		return nil
+	}
+	var query string
+	if b.pending == cap(b.args)/b.columns {
+		if b.fullQuery == "" {
+			b.fullQuery = b.query()
+		}
+		query = b.fullQuery
+	} else {
+		query = b.query()
+	}
+	res, err := b.db.ExecContext(b.ctx, query, b.args...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	b.total += affected
+	b.rowCount += b.pending
+	b.pending = 0
+	b.args = b.args[:0]
+	return nil
+}
+
+func (b *batchInserter) query() string {
+	buf := bytes.NewBufferString(b.prefix)
+	n := 0
+	for r := 0; r < b.pending; r++ {
+		if r > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('(')
+		for c := 0; c < b.columns; c++ {
+			if c > 0 {
+				buf.WriteByte(',')
+			}
+			n++
+			buf.WriteString(b.d.Placeholder(n))
+		}
+		buf.WriteByte(')')
+	}
+	return buf.String()
+}
+
+func batchInsertPrefix(d Dialect, table string, columns []string) string {
+	buf := bytes.NewBufferString("INSERT INTO ")
+	buf.WriteString(d.QuoteIdent(table))
+	buf.WriteString(" (")
+	for i, column := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(d.QuoteIdent(column))
+	}
+	buf.WriteString(") VALUES ")
+	return buf.String()
+}
+
+type sliceRowSource struct {
+	rows [][]interface{}
+	idx  int
+}
+
+func (s *sliceRowSource) Next() bool {
+	if s.idx+1 >= len(s.rows) {
+		return false
+	}
+	s.idx++
+	return true
+}
+
+func (s *sliceRowSource) Values() []interface{} {
+	return s.rows[s.idx]
+}
+
+func (s *sliceRowSource) Err() error {
+	return nil
+}
@@ -0,0 +1,86 @@
+package isql_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/0xor1/isql"
+)
+
+// execRecorder is a DBCore which records the args of each ExecContext and reports every row as affected.
+type execRecorder struct {
+	isql.DBCore
+	queries []string
+	args    [][]interface{}
+}
+
+func (r *execRecorder) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	r.queries = append(r.queries, query)
+	r.args = append(r.args, args)
+	return isql.NewResult(0, int64(strings.Count(query, "("))-1), nil
+}
+
+func TestBatchInsertChunking(t *testing.T) {
+	tests := []struct {
+		name    string
+		d       isql.Dialect
+		columns int
+		rows    int
+		chunks  []int
+	}{
+		{"postgres one column", isql.Postgres, 1, 70000, []int{65535, 4465}},
+		{"sqlite param limit", isql.SQLite, 2, 1000, []int{499, 499, 2}},
+		{"sqlserver row limit", isql.SQLServer, 1, 2500, []int{1000, 1000, 500}},
+		{"sqlserver row limit two columns", isql.SQLServer, 2, 1001, []int{1000, 1}},
+		{"sqlserver param limit", isql.SQLServer, 3, 1400, []int{699, 699, 2}},
+		{"exact chunk", isql.SQLServer, 1, 1000, []int{1000}},
+		{"no rows", isql.MySQL, 2, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns := make([]string, tt.columns)
+			for i := range columns {
+				columns[i] = "c" + string(rune('a'+i))
+			}
+			rows := make([][]interface{}, tt.rows)
+			for i := range rows {
+				rows[i] = make([]interface{}, tt.columns)
+				for j := range rows[i] {
+					rows[i][j] = i
+				}
+			}
+			db := &execRecorder{}
+			n, err := isql.BatchInsert(context.Background(), db, tt.d, "t", columns, isql.NewSliceRowSource(rows))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(tt.rows) {
+				t.Fatalf("inserted %d rows, expected %d", n, tt.rows)
+			}
+			if len(db.args) != len(tt.chunks) {
+				t.Fatalf("ran %d statements, expected %d", len(db.args), len(tt.chunks))
+			}
+			for i, args := range db.args {
+				if len(args) > tt.d.MaxParams() {
+					t.Fatalf("statement %d has %d params, limit is %d", i, len(args), tt.d.MaxParams())
+				}
+				if len(args) != tt.chunks[i]*tt.columns {
+					t.Fatalf("statement %d has %d rows, expected %d", i, len(args)/tt.columns, tt.chunks[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBatchInsertQuery(t *testing.T) {
+	db := &execRecorder{}
+	_, err := isql.BatchInsert(context.Background(), db, isql.SQLServer, "dbo.t", []string{"a", "b"}, isql.NewSliceRowSource([][]interface{}{{1, 2}, {3, 4}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "INSERT INTO [dbo].[t] ([a],[b]) VALUES (@p1,@p2),(@p3,@p4)"; db.queries[0] != expected {
+		t.Fatalf("query %q, expected %q", db.queries[0], expected)
+	}
+}
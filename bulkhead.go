@@ -0,0 +1,352 @@
+package isql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrLoadShed matches every *ShedError with errors.Is.
+var ErrLoadShed = errors.New("isql: load shed")
+
+// ShedError is returned when a bulkhead rejects a query rather than run it.
+type ShedError struct {
+	// Bulkhead is the name of the bulkhead which shed the query.
+	Bulkhead string
+	// Class is the query class whose limit was reached, empty if it was the overall limit.
+	Class string
+	// Reason is one of "queue full", "preempted" or "wait expired".
+	Reason string
+	// Err is the context error when Reason is "wait expired".
+	Err error
+}
+
+func (e *ShedError) Error() string {
+	msg := "isql: load shed by bulkhead " + strconv.Quote(e.Bulkhead)
+	if e.Class != "" {
+		msg += " class " + strconv.Quote(e.Class)
+	}
+	msg += ": " + e.Reason
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ShedError) Is(target error) bool {
+	return target == ErrLoadShed
+}
+
+func (e *ShedError) Unwrap() error {
+	return e.Err
+}
+
+// Priority orders queries waiting in a bulkhead queue, higher priorities are admitted first and, when the queue
+// is full, preempt the lowest priority waiter.
+type Priority int
+
+const (
+	PriorityLow    Priority = -10
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 10
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx carrying p, queries run without one have PriorityNormal.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+type BulkheadConfig struct {
+	// Name identifies the bulkhead in ShedErrors.
+	Name string
+	// MaxConcurrent caps the queries running at once, zero means no cap.
+	MaxConcurrent int
+	// MaxQueue caps the queries waiting for a slot, beyond it queries are shed, zero means queries are shed as soon
+	// as no slot is free.
+	MaxQueue int
+	// MaxWait caps how long a query waits for a slot in addition to any deadline on its context, zero means no cap.
+	MaxWait time.Duration
+	// Classify names the class of a query, e.g. "report", only used if ClassLimits is not empty.
+	Classify func(query string) string
+	// ClassLimits caps the queries of each class running at once, classes not present are only subject to
+	// MaxConcurrent. Each class has its own queue of up to MaxQueue waiters.
+	ClassLimits map[string]int
+}
+
+type BulkheadStats struct {
+	InFlight int64
+	Queued   int64
+	Shed     int64
+}
+
+type Bulkhead interface {
+	DBCore
+	Stats() BulkheadStats
+}
+
+// NewBulkhead returns a DBCore which limits the concurrency of queries on db. A slot is held until ExecContext
+// returns, the Rows from QueryContext are closed or the Row from QueryRowContext is scanned.
+func NewBulkhead(db DBCore, cfg BulkheadConfig) Bulkhead {
+	b := &bulkhead{
+		db:      db,
+		cfg:     cfg,
+		classes: make(map[string]*prioritySemaphore, len(cfg.ClassLimits)),
+	}
+	if cfg.MaxConcurrent > 0 {
+		b.member = newPrioritySemaphore(cfg.MaxConcurrent, cfg.MaxQueue)
+	}
+	for class, limit := range cfg.ClassLimits {
+		b.classes[class] = newPrioritySemaphore(limit, cfg.MaxQueue)
+	}
+	return b
+}
+
+// NewBulkheadReplicaSet regroups rs with each of its members wrapped in its own bulkhead named "primary" or
+// "slave:<index>".
+func NewBulkheadReplicaSet(rs ReplicaSet, cfg BulkheadConfig) ReplicaSet {
+	primaryCfg := cfg
+	primaryCfg.Name = "primary"
+	slaves := make([]DBCore, 0, len(rs.Slaves()))
+	for idx, slave := range rs.Slaves() {
+		slaveCfg := cfg
+		slaveCfg.Name = "slave:" + strconv.Itoa(idx)
+		slaves = append(slaves, NewBulkhead(slave, slaveCfg))
+	}
+	return NewReplicaSetFromMembers(NewBulkhead(rs.Primary(), primaryCfg), slaves...)
+}
+
+type bulkhead struct {
+	db       DBCore
+	cfg      BulkheadConfig
+	member   *prioritySemaphore
+	classes  map[string]*prioritySemaphore
+	inFlight int64
+	queued   int64
+	shed     int64
+}
+
+func (b *bulkhead) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	release, err := b.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return b.db.ExecContext(ctx, query, args...)
+}
+
+func (b *bulkhead) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	release, err := b.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := b.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &bulkheadRows{
+		Rows:    rows,
+		release: release,
+	}, nil
+}
+
+func (b *bulkhead) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	release, err := b.acquire(ctx, query)
+	if err != nil {
+		return &errRow{
+			err: err,
+		}
+	}
+	return &bulkheadRow{
+		row:     b.db.QueryRowContext(ctx, query, args...),
+		release: release,
+	}
+}
+
+func (b *bulkhead) Stats() BulkheadStats {
+	return BulkheadStats{
+		InFlight: atomic.LoadInt64(&b.inFlight),
+		Queued:   atomic.LoadInt64(&b.queued),
+		Shed:     atomic.LoadInt64(&b.shed),
+	}
+}
+
+// acquire takes a class slot then a member slot, the returned func releases both and is safe to call repeatedly.
+func (b *bulkhead) acquire(ctx context.Context, query string) (func(), error) {
+	if b.cfg.MaxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.cfg.MaxWait)
+		defer cancel()
+	}
+	p := PriorityFromContext(ctx)
+	var class string
+	var classSem *prioritySemaphore
+	if len(b.classes) > 0 && b.cfg.Classify != nil {
+		class = b.cfg.Classify(query)
+		classSem = b.classes[class]
+	}
+	if classSem != nil {
+		if err := b.wait(ctx, classSem, p, class); err != nil {
+			return nil, err
+		}
+	}
+	if b.member != nil {
+		if err := b.wait(ctx, b.member, p, ""); err != nil {
+			if classSem != nil {
+				classSem.release()
+			}
+			return nil, err
+		}
+	}
+	atomic.AddInt64(&b.inFlight, 1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			atomic.AddInt64(&b.inFlight, -1)
+			if b.member != nil {
+				b.member.release()
+			}
+			if classSem != nil {
+				classSem.release()
+			}
+		})
+	}, nil
+}
+
+func (b *bulkhead) wait(ctx context.Context, sem *prioritySemaphore, p Priority, class string) error {
+	atomic.AddInt64(&b.queued, 1)
+	reason, err := sem.acquire(ctx, p)
+	atomic.AddInt64(&b.queued, -1)
+	if reason == "" {
+		return nil
+	}
+	atomic.AddInt64(&b.shed, 1)
+	return &ShedError{
+		Bulkhead: b.cfg.Name,
+		Class:    class,
+		Reason:   reason,
+		Err:      err,
+	}
+}
+
+type bulkheadRows struct {
+	Rows
+	release func()
+}
+
+func (r *bulkheadRows) Close() error {
+	err := r.Rows.Close()
+	r.release()
+	return err
+}
+
+type bulkheadRow struct {
+	row     Row
+	release func()
+}
+
+func (r *bulkheadRow) Scan(dest ...interface{}) error {
+	defer r.release()
+	return r.row.Scan(dest...)
+}
+
+func newPrioritySemaphore(limit, maxQueue int) *prioritySemaphore {
+	return &prioritySemaphore{
+		limit:    limit,
+		maxQueue: maxQueue,
+	}
+}
+
+// prioritySemaphore admits waiters in priority order, FIFO within a priority.
+type prioritySemaphore struct {
+	mtx      sync.Mutex
+	limit    int
+	maxQueue int
+	inUse    int
+	waiters  []*semWaiter
+}
+
+type semWaiter struct {
+	priority Priority
+	// ready receives "" when admitted or the reason it was shed.
+	ready chan string
+}
+
+// acquire returns an empty reason once admitted, otherwise the reason it was shed and the context error if any.
+func (s *prioritySemaphore) acquire(ctx context.Context, p Priority) (string, error) {
+	s.mtx.Lock()
+	if s.inUse < s.limit && len(s.waiters) == 0 {
+		s.inUse++
+		s.mtx.Unlock()
+		return "", nil
+	}
+	if len(s.waiters) >= s.maxQueue {
+		last := len(s.waiters) - 1
+		if last < 0 || s.waiters[last].priority >= p {
+			s.mtx.Unlock()
+			return "queue full", nil
+		}
+		s.waiters[last].ready <- "preempted"
+		s.waiters = s.waiters[:last]
+	}
+	w := &semWaiter{
+		priority: p,
+		ready:    make(chan string, 1),
+	}
+	idx := len(s.waiters)
+	for idx > 0 && s.waiters[idx-1].priority < p {
+		idx--
+	}
+	s.waiters = append(s.waiters, nil)
+	copy(s.waiters[idx+1:], s.waiters[idx:])
+	s.waiters[idx] = w
+	s.mtx.Unlock()
+
+	select {
+	case reason := <-w.ready:
+		return reason, nil
+	case <-ctx.Done():
+		s.mtx.Lock()
+		for i, waiter := range s.waiters {
+			if waiter == w {
+				s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+				s.mtx.Unlock()
+				return "wait expired", ctx.Err()
+			}
+		}
+		s.mtx.Unlock()
+		// admitted or preempted concurrently
+		reason := <-w.ready
+		if reason != "" {
+			return reason, nil
+		}
+		s.release()
+		return "wait expired", ctx.Err()
+	}
+}
+
+func (s *prioritySemaphore) release() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if len(s.waiters) > 0 {
+		// hand the slot straight to the next waiter
+		w := s.waiters[0]
+		s.waiters = s.waiters[1:]
+		w.ready <- ""
+		return
+	}
+	s.inUse--
+}
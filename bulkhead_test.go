@@ -0,0 +1,212 @@
+package isql_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+// orderDB records the queries run against it in the order they ran.
+type orderDB struct {
+	mtx     sync.Mutex
+	queries []string
+}
+
+func (o *orderDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.queries = append(o.queries, query)
+	return isql.NewResult(0, 1), nil
+}
+
+func (o *orderDB) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	return isql.NewDataRows(isql.ResultSet{Columns: []string{"a"}, Rows: [][]interface{}{{1}}})
+}
+
+func (o *orderDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	return isql.NewDataRow(isql.ResultSet{Columns: []string{"a"}, Rows: [][]interface{}{{1}}})
+}
+
+// waitQueued waits until n queries are queued in b.
+func waitQueued(t *testing.T, b isql.Bulkhead, n int64) {
+	t.Helper()
+	waitStats(t, b, func(s isql.BulkheadStats) bool {
+		return s.Queued == n
+	})
+}
+
+func waitStats(t *testing.T, b isql.Bulkhead, done func(s isql.BulkheadStats) bool) {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		if done(b.Stats()) {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("stats %+v", b.Stats())
+}
+
+func TestBulkheadPriority(t *testing.T) {
+	type waiter struct {
+		query    string
+		priority isql.Priority
+	}
+	tests := []struct {
+		name     string
+		maxQueue int
+		waiters  []waiter
+		admitted []string
+		shed     map[string]string
+	}{
+		{
+			name:     "priority then fifo",
+			maxQueue: 4,
+			waiters:  []waiter{{"low", isql.PriorityLow}, {"normal1", isql.PriorityNormal}, {"high", isql.PriorityHigh}, {"normal2", isql.PriorityNormal}},
+			admitted: []string{"high", "normal1", "normal2", "low"},
+		},
+		{
+			name:     "preempt lowest",
+			maxQueue: 2,
+			waiters:  []waiter{{"low", isql.PriorityLow}, {"normal", isql.PriorityNormal}, {"high", isql.PriorityHigh}},
+			admitted: []string{"high", "normal"},
+			shed:     map[string]string{"low": "preempted"},
+		},
+		{
+			name:     "queue full",
+			maxQueue: 2,
+			waiters:  []waiter{{"normal1", isql.PriorityNormal}, {"normal2", isql.PriorityNormal}, {"normal3", isql.PriorityNormal}, {"low", isql.PriorityLow}},
+			admitted: []string{"normal1", "normal2"},
+			shed:     map[string]string{"normal3": "queue full", "low": "queue full"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &orderDB{}
+			b := isql.NewBulkhead(db, isql.BulkheadConfig{Name: "b", MaxConcurrent: 1, MaxQueue: tt.maxQueue})
+			ctx := context.Background()
+			held, err := b.QueryContext(ctx, "SELECT a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var mtx sync.Mutex
+			shed := map[string]string{}
+			var wg sync.WaitGroup
+			for i, w := range tt.waiters {
+				wg.Add(1)
+				go func(w waiter) {
+					defer wg.Done()
+					_, err := b.ExecContext(isql.WithPriority(ctx, w.priority), w.query)
+					var shedErr *isql.ShedError
+					if errors.As(err, &shedErr) {
+						mtx.Lock()
+						shed[w.query] = shedErr.Reason
+						mtx.Unlock()
+					}
+				}(w)
+				// queue the waiters in order, each one is either queued or shed
+				waitStats(t, b, func(s isql.BulkheadStats) bool {
+					return s.Queued+s.Shed == int64(i+1)
+				})
+				time.Sleep(time.Millisecond)
+			}
+			held.Close()
+			wg.Wait()
+			if !reflect.DeepEqual(db.queries, tt.admitted) {
+				t.Errorf("admitted %v, expected %v", db.queries, tt.admitted)
+			}
+			if len(shed) != len(tt.shed) || (len(shed) > 0 && !reflect.DeepEqual(shed, tt.shed)) {
+				t.Errorf("shed %v, expected %v", shed, tt.shed)
+			}
+			if s := b.Stats(); s.InFlight != 0 || s.Queued != 0 || s.Shed != int64(len(tt.shed)) {
+				t.Errorf("stats %+v", s)
+			}
+		})
+	}
+}
+
+func TestBulkheadCancel(t *testing.T) {
+	db := &orderDB{}
+	b := isql.NewBulkhead(db, isql.BulkheadConfig{Name: "b", MaxConcurrent: 1, MaxQueue: 2, MaxWait: time.Hour})
+	ctx := context.Background()
+	held, err := b.QueryContext(ctx, "SELECT a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelCtx, cancel := context.WithCancel(ctx)
+	errs := make(chan error)
+	go func() {
+		_, err := b.ExecContext(cancelCtx, "cancelled")
+		errs <- err
+	}()
+	waitQueued(t, b, 1)
+	cancel()
+	err = <-errs
+	var shedErr *isql.ShedError
+	if !errors.As(err, &shedErr) || shedErr.Reason != "wait expired" || !errors.Is(err, context.Canceled) || !errors.Is(err, isql.ErrLoadShed) {
+		t.Fatalf("error %v, expected the cancelled wait to be shed", err)
+	}
+	if b.Stats().Queued != 0 {
+		t.Fatalf("stats %+v, expected the cancelled waiter to leave the queue", b.Stats())
+	}
+
+	short := isql.NewBulkhead(db, isql.BulkheadConfig{Name: "short", MaxConcurrent: 1, MaxQueue: 1, MaxWait: 5 * time.Millisecond})
+	row := short.QueryRowContext(ctx, "SELECT a")
+	if _, err := short.ExecContext(ctx, "expired"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, expected MaxWait to expire", err)
+	}
+	var a int
+	if err := row.Scan(&a); err != nil {
+		t.Fatal(err)
+	}
+
+	// the cancelled waiter must not have taken the slot, the next waiter is admitted when it is released
+	go func() {
+		_, err := b.ExecContext(ctx, "next")
+		errs <- err
+	}()
+	waitQueued(t, b, 1)
+	held.Close()
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := short.ExecContext(ctx, "after"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(db.queries, []string{"next", "after"}) {
+		t.Fatalf("queries %v", db.queries)
+	}
+	if s := b.Stats(); s.InFlight != 0 || s.Shed != 1 {
+		t.Fatalf("stats %+v", s)
+	}
+}
+
+func TestBulkheadClassLimits(t *testing.T) {
+	db := &orderDB{}
+	b := isql.NewBulkhead(db, isql.BulkheadConfig{
+		Name:          "b",
+		MaxConcurrent: 2,
+		Classify: func(query string) string {
+			return query[:6]
+		},
+		ClassLimits: map[string]int{"report": 1},
+	})
+	ctx := context.Background()
+	report, err := b.QueryContext(ctx, "report 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer report.Close()
+	var shedErr *isql.ShedError
+	if _, err := b.QueryContext(ctx, "report 2"); !errors.As(err, &shedErr) || shedErr.Class != "report" || shedErr.Reason != "queue full" {
+		t.Fatalf("error %v, expected the report class to be full", err)
+	}
+	if _, err := b.ExecContext(ctx, "update 1"); err != nil {
+		t.Fatalf("error %v, expected other classes to use the remaining slot", err)
+	}
+}
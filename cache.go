@@ -0,0 +1,387 @@
+package isql
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheStore holds materialized query results for a Cache, implementations must be safe for concurrent use and
+// must not modify stored result sets.
+type CacheStore interface {
+	Get(key string) ([]ResultSet, bool)
+	Set(key string, sets []ResultSet, ttl time.Duration, tags []string)
+	InvalidateTags(tags ...string)
+}
+
+type CacheConfig struct {
+	// Store defaults to NewMemoryCacheStore(1000, 0).
+	Store CacheStore
+	// TTL is the default time to live of cached results, default 1m.
+	TTL time.Duration
+	// Tables declares the tables whose results are invalidated automatically, a read whose query mentions one of
+	// them is tagged "table:<name>" and an ExecContext whose query mentions it invalidates that tag.
+	Tables []string
+	// Cacheable reports whether the results of query may be cached, by default every read is.
+	Cacheable func(query string) bool
+}
+
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+	// Shared counts misses which waited for an identical concurrent read instead of running their own.
+	Shared int64
+}
+
+type Cache interface {
+	DBCore
+	InvalidateTags(tags ...string)
+	InvalidateTables(tables ...string)
+	Stats() CacheStats
+}
+
+type cacheTagsKey struct{}
+
+type cacheTTLKey struct{}
+
+// WithCacheTags returns a copy of ctx which tags reads cached with it, so they can be invalidated together.
+func WithCacheTags(ctx context.Context, tags ...string) context.Context {
+	if existing, ok := ctx.Value(cacheTagsKey{}).([]string); ok {
+		tags = append(append([]string(nil), existing...), tags...)
+	}
+	return context.WithValue(ctx, cacheTagsKey{}, tags)
+}
+
+// WithCacheTTL returns a copy of ctx which overrides the time to live of reads cached with it, a ttl of zero or
+// less bypasses the cache.
+func WithCacheTTL(ctx context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, cacheTTLKey{}, ttl)
+}
+
+// NewCache returns a DBCore which serves repeated reads of db from materialized copies of their results, keyed by
+// query and the driver value of each arg, a read with an arg which has no driver value bypasses the cache.
+// Concurrent identical reads which miss share one execution. ExecContext is never cached.
+func NewCache(db DBCore, cfg CacheConfig) Cache {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryCacheStore(1000, 0)
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = time.Minute
+	}
+	tables := make(map[string]string, len(cfg.Tables))
+	for _, table := range cfg.Tables {
+		tables[strings.ToLower(table)] = table
+	}
+	return &cache{
+		db:     db,
+		cfg:    cfg,
+		tables: tables,
+		gens:   map[string]uint64{},
+	}
+}
+
+type cache struct {
+	db      DBCore
+	cfg     CacheConfig
+	tables  map[string]string
+	flights flightGroup
+	gensMtx sync.Mutex
+	gens    map[string]uint64
+	hits    int64
+	misses  int64
+	shared  int64
+}
+
+func (c *cache) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	res, err := c.db.ExecContext(ctx, query, args...)
+	// invalidate even on error as the statement may have been partially applied
+	if tags := c.tableTags(query); len(tags) > 0 {
+		c.InvalidateTags(tags...)
+	}
+	return res, err
+}
+
+func (c *cache) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	sets, ok, err := c.read(ctx, query, args)
+	if !ok {
+		return c.db.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewDataRows(sets...)
+}
+
+func (c *cache) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	sets, ok, err := c.read(ctx, query, args)
+	if !ok {
+		return c.db.QueryRowContext(ctx, query, args...)
+	}
+	if err != nil {
+		return &errRow{
+			err: err,
+		}
+	}
+	if len(sets) == 0 {
+		return NewDataRow(ResultSet{})
+	}
+	return NewDataRow(sets[0])
+}
+
+func (c *cache) InvalidateTags(tags ...string) {
+	c.gensMtx.Lock()
+	for _, tag := range tags {
+		c.gens[tag]++
+	}
+	c.gensMtx.Unlock()
+	c.cfg.Store.InvalidateTags(tags...)
+}
+
+func (c *cache) InvalidateTables(tables ...string) {
+	tags := make([]string, 0, len(tables))
+	for _, table := range tables {
+		tags = append(tags, "table:"+table)
+	}
+	c.InvalidateTags(tags...)
+}
+
+func (c *cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:   atomic.LoadInt64(&c.hits),
+		Misses: atomic.LoadInt64(&c.misses),
+		Shared: atomic.LoadInt64(&c.shared),
+	}
+}
+
+// read returns ok false if the query should bypass the cache, because it is not cacheable or an arg has no driver
+// value to key it by.
+func (c *cache) read(ctx context.Context, query string, args []interface{}) ([]ResultSet, bool, error) {
+	ttl := c.cfg.TTL
+	if override, ok := ctx.Value(cacheTTLKey{}).(time.Duration); ok {
+		ttl = override
+	}
+	if ttl <= 0 || (c.cfg.Cacheable != nil && !c.cfg.Cacheable(query)) {
+		return nil, false, nil
+	}
+	key, ok := queryKey(query, args)
+	if !ok {
+		return nil, false, nil
+	}
+	if sets, ok := c.cfg.Store.Get(key); ok {
+		atomic.AddInt64(&c.hits, 1)
+		return sets, true, nil
+	}
+	atomic.AddInt64(&c.misses, 1)
+	tags := c.tableTags(query)
+	if ctxTags, ok := ctx.Value(cacheTagsKey{}).([]string); ok {
+		tags = append(tags, ctxTags...)
+	}
+	sets, shared, err := c.flights.do(ctx, key, func(ctx context.Context) ([]ResultSet, error) {
+		gens := c.snapshot(tags)
+		rows, err := c.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		sets, err := ReadResultSets(rows)
+		if err != nil {
+			return nil, err
+		}
+		for _, set := range sets {
+			if set.Err != nil {
+				return sets, nil
+			}
+		}
+		// do not store results which may predate an invalidation made while they were being read
+		if c.unchanged(tags, gens) {
+			c.cfg.Store.Set(key, sets, ttl, tags)
+		}
+		return sets, nil
+	})
+	if shared {
+		atomic.AddInt64(&c.shared, 1)
+	}
+	return sets, true, err
+}
+
+func (c *cache) snapshot(tags []string) []uint64 {
+	c.gensMtx.Lock()
+	defer c.gensMtx.Unlock()
+	gens := make([]uint64, 0, len(tags))
+	for _, tag := range tags {
+		gens = append(gens, c.gens[tag])
+	}
+	return gens
+}
+
+func (c *cache) unchanged(tags []string, gens []uint64) bool {
+	c.gensMtx.Lock()
+	defer c.gensMtx.Unlock()
+	for i, tag := range tags {
+		if c.gens[tag] != gens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// tableTags returns the tags of the declared tables mentioned in query.
+func (c *cache) tableTags(query string) []string {
+	if len(c.tables) == 0 {
+		return nil
+	}
+	var tags []string
+	seen := map[string]bool{}
+	for _, ident := range identifiers(query) {
+		ident = strings.ToLower(ident)
+		if i := strings.LastIndexByte(ident, '.'); i >= 0 {
+			ident = ident[i+1:]
+		}
+		if table, ok := c.tables[ident]; ok && !seen[table] {
+			seen[table] = true
+			tags = append(tags, "table:"+table)
+		}
+	}
+	return tags
+}
+
+// identifiers splits query into runs of identifier characters, quotes are treated as separators so quoted
+// identifiers are included.
+func identifiers(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !(r == '_' || r == '.' || r == '$' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r > 127)
+	})
+}
+
+// NewMemoryCacheStore returns an in memory LRU CacheStore holding at most maxEntries results and at most roughly
+// maxBytes of values, either bound is ignored if it is zero.
+func NewMemoryCacheStore(maxEntries int, maxBytes int64) CacheStore {
+	return &memoryCacheStore{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		now:        time.Now,
+		lru:        list.New(),
+		entries:    map[string]*list.Element{},
+		tags:       map[string]map[string]struct{}{},
+	}
+}
+
+type memoryCacheStore struct {
+	mtx        sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	now        func() time.Time
+	lru        *list.List
+	entries    map[string]*list.Element
+	tags       map[string]map[string]struct{}
+}
+
+type memoryCacheEntry struct {
+	key     string
+	sets    []ResultSet
+	expires time.Time
+	tags    []string
+	size    int64
+}
+
+func (s *memoryCacheStore) Get(key string) ([]ResultSet, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*memoryCacheEntry)
+	if !s.now().Before(e.expires) {
+		s.remove(el)
+		return nil, false
+	}
+	s.lru.MoveToFront(el)
+	return e.sets, true
+}
+
+func (s *memoryCacheStore) Set(key string, sets []ResultSet, ttl time.Duration, tags []string) {
+	e := &memoryCacheEntry{
+		key:     key,
+		sets:    sets,
+		expires: s.now().Add(ttl),
+		tags:    tags,
+		size:    resultSetsSize(sets),
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.maxBytes > 0 && e.size > s.maxBytes {
+		return
+	}
+	if el, ok := s.entries[key]; ok {
+		s.remove(el)
+	}
+	s.entries[key] = s.lru.PushFront(e)
+	s.bytes += e.size
+	for _, tag := range tags {
+		keys, ok := s.tags[tag]
+		if !ok {
+			keys = map[string]struct{}{}
+			s.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	for s.lru.Len() > 0 && ((s.maxEntries > 0 && s.lru.Len() > s.maxEntries) || (s.maxBytes > 0 && s.bytes > s.maxBytes)) {
+		s.remove(s.lru.Back())
+	}
+}
+
+func (s *memoryCacheStore) InvalidateTags(tags ...string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			if el, ok := s.entries[key]; ok {
+				s.remove(el)
+			}
+		}
+		delete(s.tags, tag)
+	}
+}
+
+// remove must be called with mtx held.
+func (s *memoryCacheStore) remove(el *list.Element) {
+	e := s.lru.Remove(el).(*memoryCacheEntry)
+	delete(s.entries, e.key)
+	s.bytes -= e.size
+	for _, tag := range e.tags {
+		if keys, ok := s.tags[tag]; ok {
+			delete(keys, e.key)
+			if len(keys) == 0 {
+				delete(s.tags, tag)
+			}
+		}
+	}
+}
+
+// resultSetsSize roughly estimates the memory held by sets.
+func resultSetsSize(sets []ResultSet) int64 {
+	var size int64
+	for _, set := range sets {
+		for _, column := range set.Columns {
+			size += int64(len(column)) + 16
+		}
+		for _, row := range set.Rows {
+			for _, v := range row {
+				size += 16
+				switch v := v.(type) {
+				case string:
+					size += int64(len(v))
+				case []byte:
+					size += int64(len(v))
+				}
+			}
+		}
+	}
+	return size
+}
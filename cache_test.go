@@ -0,0 +1,216 @@
+package isql_test
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+// readDB counts the reads run against it, returning the count as the only value, and if block is set each read
+// signals started then waits for block to be closed.
+type readDB struct {
+	reads   int64
+	started chan struct{}
+	block   chan struct{}
+}
+
+func (r *readDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return isql.NewResult(0, 1), nil
+}
+
+func (r *readDB) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	n := atomic.AddInt64(&r.reads, 1)
+	if r.block != nil {
+		r.started <- struct{}{}
+		<-r.block
+	}
+	return isql.NewDataRows(isql.ResultSet{Columns: []string{"n"}, Rows: [][]interface{}{{n}}})
+}
+
+func (r *readDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	return isql.NewDataRow(isql.ResultSet{Columns: []string{"n"}, Rows: [][]interface{}{{atomic.AddInt64(&r.reads, 1)}}})
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		// between runs between two identical reads, reads is the number of reads run by both
+		between func(c isql.Cache)
+		query   string
+		ctx     context.Context
+		reads   int64
+	}{
+		{"hit", func(c isql.Cache) {}, "SELECT n FROM users", ctx, 1},
+		{"exec on table", func(c isql.Cache) { c.ExecContext(ctx, `UPDATE "Users" SET n = 1`) }, "SELECT n FROM public.users", ctx, 2},
+		{"exec on other table", func(c isql.Cache) { c.ExecContext(ctx, "UPDATE orders SET n = 1") }, "SELECT n FROM users", ctx, 1},
+		{"invalidate table", func(c isql.Cache) { c.InvalidateTables("users") }, "SELECT n FROM users", ctx, 2},
+		{"invalidate tag", func(c isql.Cache) { c.InvalidateTags("t") }, "SELECT n", isql.WithCacheTags(ctx, "t"), 2},
+		{"invalidate other tag", func(c isql.Cache) { c.InvalidateTags("u") }, "SELECT n", isql.WithCacheTags(ctx, "t"), 1},
+		{"bypass", func(c isql.Cache) {}, "SELECT n", isql.WithCacheTTL(ctx, 0), 2},
+	}
+	for _, tt := range tests {
+		db := &readDB{}
+		c := isql.NewCache(db, isql.CacheConfig{Tables: []string{"users", "orders"}})
+		var first, second int64
+		if err := c.QueryRowContext(tt.ctx, tt.query, 1).Scan(&first); err != nil {
+			t.Fatal(err)
+		}
+		tt.between(c)
+		if err := c.QueryRowContext(tt.ctx, tt.query, 1).Scan(&second); err != nil {
+			t.Fatal(err)
+		}
+		if db.reads != tt.reads || second != tt.reads {
+			t.Errorf("%s: %d reads returning %d, expected %d", tt.name, db.reads, second, tt.reads)
+		}
+	}
+}
+
+func TestCacheKeys(t *testing.T) {
+	db := &readDB{}
+	c := isql.NewCache(db, isql.CacheConfig{})
+	ctx := context.Background()
+	for _, args := range [][]interface{}{{1}, {"1"}, {1}, {int64(1)}, {1, 2}, nil} {
+		rows, err := c.QueryContext(ctx, "SELECT n WHERE id = ?", args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+	}
+	// 1 and int64(1) have the same driver value so share an entry
+	if db.reads != 4 {
+		t.Fatalf("%d reads, expected args of different driver values or count to be cached separately", db.reads)
+	}
+	if s := c.Stats(); s.Hits != 2 || s.Misses != 4 {
+		t.Fatalf("stats %+v", s)
+	}
+}
+
+func TestCachePointerArgs(t *testing.T) {
+	db := &readDB{}
+	c := isql.NewCache(db, isql.CacheConfig{})
+	ctx := context.Background()
+	id := 1
+	var first, second, third int64
+	if err := c.QueryRowContext(ctx, "SELECT n WHERE id = ?", &id).Scan(&first); err != nil {
+		t.Fatal(err)
+	}
+	id = 2
+	if err := c.QueryRowContext(ctx, "SELECT n WHERE id = ?", &id).Scan(&second); err != nil {
+		t.Fatal(err)
+	}
+	other := 2
+	if err := c.QueryRowContext(ctx, "SELECT n WHERE id = ?", &other).Scan(&third); err != nil {
+		t.Fatal(err)
+	}
+	if db.reads != 2 || second != 2 || third != 2 {
+		t.Fatalf("%d reads returning %d and %d, expected a pointer arg to be keyed by the value it points to", db.reads, second, third)
+	}
+	// a struct has no driver value so the read can't be keyed and bypasses the cache
+	for i := 0; i < 2; i++ {
+		if err := c.QueryRowContext(ctx, "SELECT n WHERE id = ?", struct{ id int }{1}).Scan(&first); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := c.Stats(); db.reads != 4 || s.Hits != 1 || s.Misses != 2 {
+		t.Fatalf("%d reads with stats %+v, expected reads with an unkeyable arg to bypass the cache", db.reads, s)
+	}
+}
+
+func TestCacheInvalidatedDuringRead(t *testing.T) {
+	db := &readDB{started: make(chan struct{}, 1), block: make(chan struct{})}
+	c := isql.NewCache(db, isql.CacheConfig{Tables: []string{"users"}})
+	ctx := context.Background()
+	done := make(chan int64)
+	go func() {
+		var n int64
+		c.QueryRowContext(ctx, "SELECT n FROM users").Scan(&n)
+		done <- n
+	}()
+	<-db.started
+	// the read in flight may have missed this write so its result must not be stored
+	c.ExecContext(ctx, "UPDATE users SET n = 1")
+	close(db.block)
+	if n := <-done; n != 1 {
+		t.Fatalf("read %d", n)
+	}
+	db.block = nil
+	var n int64
+	if err := c.QueryRowContext(ctx, "SELECT n FROM users").Scan(&n); err != nil || n != 2 {
+		t.Fatalf("read %d with error %v, expected the result read during the invalidation to be discarded", n, err)
+	}
+	if err := c.QueryRowContext(ctx, "SELECT n FROM users").Scan(&n); err != nil || n != 2 {
+		t.Fatalf("read %d with error %v, expected the later result to be cached", n, err)
+	}
+}
+
+func TestCacheShared(t *testing.T) {
+	db := &readDB{started: make(chan struct{}, 10), block: make(chan struct{})}
+	c := isql.NewCache(db, isql.CacheConfig{})
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	results := make([]int64, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.QueryRowContext(ctx, "SELECT n").Scan(&results[i])
+		}(i)
+	}
+	<-db.started
+	// give the other reads time to join the one in flight
+	time.Sleep(20 * time.Millisecond)
+	close(db.block)
+	wg.Wait()
+	for _, n := range results {
+		if n != 1 {
+			t.Fatalf("results %v, expected every read to share the first", results)
+		}
+	}
+	if s := c.Stats(); db.reads != 1 || s.Shared != int64(len(results)-1) {
+		t.Fatalf("%d reads with stats %+v, expected 1", db.reads, s)
+	}
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	set := []isql.ResultSet{{Columns: []string{"a"}, Rows: [][]interface{}{{"0123456789"}}}}
+	s := isql.NewMemoryCacheStore(2, 0)
+	s.Set("a", set, time.Minute, nil)
+	s.Set("b", set, time.Minute, []string{"t"})
+	s.Get("a")
+	s.Set("c", set, time.Minute, []string{"t"})
+	if _, ok := s.Get("b"); ok {
+		t.Fatal("expected the least recently used entry to be evicted")
+	}
+	s.InvalidateTags("t")
+	if _, ok := s.Get("c"); ok {
+		t.Fatal("expected the tagged entry to be invalidated")
+	}
+	if _, ok := s.Get("a"); !ok {
+		t.Fatal("expected the untagged entry to remain")
+	}
+	s.Set("d", set, time.Millisecond, nil)
+	time.Sleep(2 * time.Millisecond)
+	if _, ok := s.Get("d"); ok {
+		t.Fatal("expected the entry to expire")
+	}
+
+	s = isql.NewMemoryCacheStore(0, 60)
+	s.Set("a", set, time.Minute, nil)
+	s.Set("b", set, time.Minute, nil)
+	if _, ok := s.Get("a"); ok {
+		t.Fatal("expected the entry over the byte limit to be evicted")
+	}
+	s.Set("big", []isql.ResultSet{{Rows: [][]interface{}{{string(make([]byte, 100))}}}}, time.Minute, nil)
+	if _, ok := s.Get("big"); ok {
+		t.Fatal("expected an entry larger than the byte limit not to be stored")
+	}
+	if _, ok := s.Get("b"); !ok {
+		t.Fatal("expected the entry within the byte limit to remain")
+	}
+}
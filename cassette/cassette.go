@@ -0,0 +1,320 @@
+// Package cassette records every interaction with an isql.DB to a versioned JSON cassette file and replays
+// cassettes through the same isql interfaces with no database at all.
+package cassette
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+const Version = 1
+
+const (
+	OpBegin    = "begin"
+	OpCommit   = "commit"
+	OpRollback = "rollback"
+	OpPrepare  = "prepare"
+	OpExec     = "exec"
+	OpQuery    = "query"
+	OpQueryRow = "query_row"
+)
+
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Op         string      `json:"op"`
+	Query      string      `json:"query,omitempty"`
+	Args       []Value     `json:"args,omitempty"`
+	ResultSets []ResultSet `json:"resultSets,omitempty"`
+	Result     *Result     `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+type ResultSet struct {
+	Columns     []string     `json:"columns"`
+	ColumnTypes []ColumnType `json:"columnTypes,omitempty"`
+	Rows        [][]Value    `json:"rows"`
+	Error       string       `json:"error,omitempty"`
+	ErrorAt     int          `json:"errorAt,omitempty"`
+}
+
+type ColumnType struct {
+	DatabaseType   string `json:"databaseType,omitempty"`
+	Length         int64  `json:"length,omitempty"`
+	HasLength      bool   `json:"hasLength,omitempty"`
+	Precision      int64  `json:"precision,omitempty"`
+	Scale          int64  `json:"scale,omitempty"`
+	HasDecimalSize bool   `json:"hasDecimalSize,omitempty"`
+	Nullable       bool   `json:"nullable,omitempty"`
+	HasNullable    bool   `json:"hasNullable,omitempty"`
+}
+
+type Result struct {
+	LastInsertID      int64  `json:"lastInsertId"`
+	LastInsertIDError string `json:"lastInsertIdError,omitempty"`
+	RowsAffected      int64  `json:"rowsAffected"`
+	RowsAffectedError string `json:"rowsAffectedError,omitempty"`
+}
+
+// Value is a driver value tagged with its type, Type is one of null, int64, float64, bool, string, bytes, time
+// or other for row values of any other type a driver returned, in which case Value is only descriptive. Name is
+// set for args passed as sql.NamedArg.
+type Value struct {
+	Name  string `json:"name,omitempty"`
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+func Load(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cassette{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("cassette: invalid cassette %s: %s", path, err)
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("cassette: %s has version %d, expected %d", path, c.Version, Version)
+	}
+	return c, nil
+}
+
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// DriftError is returned in replay mode when the code under test makes a call which does not match the next
+// interaction recorded in the cassette, Expected is nil if the cassette has been fully played.
+type DriftError struct {
+	Index    int
+	Expected *Interaction
+	Actual   Interaction
+}
+
+func (e *DriftError) Error() string {
+	actual, _ := json.Marshal(e.Actual)
+	if e.Expected == nil {
+		return fmt.Sprintf("cassette: drift at interaction %d, cassette has no more interactions, got %s", e.Index, actual)
+	}
+	expected, _ := json.Marshal(e.Expected)
+	return fmt.Sprintf("cassette: drift at interaction %d, expected %s, got %s", e.Index, expected, actual)
+}
+
+func newInteraction(op, query string, args []interface{}) (Interaction, error) {
+	i := Interaction{
+		Op:    op,
+		Query: query,
+	}
+	for _, arg := range args {
+		v, err := encodeArg(arg)
+		if err != nil {
+			return i, err
+		}
+		i.Args = append(i.Args, v)
+	}
+	return i, nil
+}
+
+func (i *Interaction) matches(other *Interaction) bool {
+	if i.Op != other.Op || i.Query != other.Query || len(i.Args) != len(other.Args) {
+		return false
+	}
+	for idx := range i.Args {
+		if i.Args[idx] != other.Args[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func (i *Interaction) err() error {
+	return decodeError(i.Error)
+}
+
+func encodeError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+var sentinelErrors = []error{sql.ErrNoRows, sql.ErrTxDone, sql.ErrConnDone, driver.ErrBadConn, driver.ErrSkip}
+
+// decodeError restores well known sentinel errors so errors.Is checks behave the same during replay.
+func decodeError(msg string) error {
+	if msg == "" {
+		return nil
+	}
+	for _, err := range sentinelErrors {
+		if err.Error() == msg {
+			return err
+		}
+	}
+	return errors.New(msg)
+}
+
+func encodeResult(res sql.Result) *Result {
+	r := &Result{}
+	var err error
+	r.LastInsertID, err = res.LastInsertId()
+	r.LastInsertIDError = encodeError(err)
+	r.RowsAffected, err = res.RowsAffected()
+	r.RowsAffectedError = encodeError(err)
+	return r
+}
+
+type result struct {
+	r *Result
+}
+
+func (r *result) LastInsertId() (int64, error) {
+	return r.r.LastInsertID, decodeError(r.r.LastInsertIDError)
+}
+
+func (r *result) RowsAffected() (int64, error) {
+	return r.r.RowsAffected, decodeError(r.r.RowsAffectedError)
+}
+
+func encodeResultSets(sets []isql.ResultSet) []ResultSet {
+	res := make([]ResultSet, 0, len(sets))
+	for _, set := range sets {
+		s := ResultSet{
+			Columns: set.Columns,
+			Rows:    make([][]Value, 0, len(set.Rows)),
+			Error:   encodeError(set.Err),
+			ErrorAt: set.ErrAt,
+		}
+		for _, ct := range set.ColumnTypes {
+			s.ColumnTypes = append(s.ColumnTypes, ColumnType{
+				DatabaseType:   ct.DatabaseType,
+				Length:         ct.Length,
+				HasLength:      ct.HasLength,
+				Precision:      ct.Precision,
+				Scale:          ct.Scale,
+				HasDecimalSize: ct.HasDecimalSize,
+				Nullable:       ct.Nullable,
+				HasNullable:    ct.HasNullable,
+			})
+		}
+		for _, row := range set.Rows {
+			values := make([]Value, 0, len(row))
+			for _, v := range row {
+				values = append(values, encodeValue(v))
+			}
+			s.Rows = append(s.Rows, values)
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+func decodeResultSets(sets []ResultSet) ([]isql.ResultSet, error) {
+	res := make([]isql.ResultSet, 0, len(sets))
+	for _, set := range sets {
+		s := isql.ResultSet{
+			Columns: set.Columns,
+			Rows:    make([][]interface{}, 0, len(set.Rows)),
+			Err:     decodeError(set.Error),
+			ErrAt:   set.ErrorAt,
+		}
+		for _, ct := range set.ColumnTypes {
+			s.ColumnTypes = append(s.ColumnTypes, isql.ColumnTypeInfo{
+				DatabaseType:   ct.DatabaseType,
+				Length:         ct.Length,
+				HasLength:      ct.HasLength,
+				Precision:      ct.Precision,
+				Scale:          ct.Scale,
+				HasDecimalSize: ct.HasDecimalSize,
+				Nullable:       ct.Nullable,
+				HasNullable:    ct.HasNullable,
+			})
+		}
+		for _, row := range set.Rows {
+			values := make([]interface{}, 0, len(row))
+			for _, v := range row {
+				dv, err := v.decode()
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, dv)
+			}
+			s.Rows = append(s.Rows, values)
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+// encodeArg encodes arg as the driver value it is bound as, dereferencing pointers and calling driver.Valuer, an
+// arg which has no such value, e.g. a struct, is an error as its encoding would not match on replay.
+func encodeArg(arg interface{}) (Value, error) {
+	if named, ok := arg.(sql.NamedArg); ok {
+		v, err := encodeArg(named.Value)
+		v.Name = named.Name
+		return v, err
+	}
+	v, err := driver.DefaultParameterConverter.ConvertValue(arg)
+	if err != nil {
+		return Value{}, fmt.Errorf("cassette: can't record arg of type %T: %s", arg, err)
+	}
+	return encodeValue(v), nil
+}
+
+func encodeValue(v interface{}) Value {
+	switch v := v.(type) {
+	case nil:
+		return Value{Type: "null"}
+	case int64:
+		return Value{Type: "int64", Value: strconv.FormatInt(v, 10)}
+	case float64:
+		return Value{Type: "float64", Value: strconv.FormatFloat(v, 'g', -1, 64)}
+	case bool:
+		return Value{Type: "bool", Value: strconv.FormatBool(v)}
+	case string:
+		return Value{Type: "string", Value: v}
+	case []byte:
+		return Value{Type: "bytes", Value: base64.StdEncoding.EncodeToString(v)}
+	case time.Time:
+		return Value{Type: "time", Value: v.Format(time.RFC3339Nano)}
+	default:
+		return Value{Type: "other", Value: fmt.Sprintf("%T %v", v, v)}
+	}
+}
+
+func (v Value) decode() (interface{}, error) {
+	switch v.Type {
+	case "null":
+		return nil, nil
+	case "int64":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "float64":
+		return strconv.ParseFloat(v.Value, 64)
+	case "bool":
+		return strconv.ParseBool(v.Value)
+	case "string":
+		return v.Value, nil
+	case "bytes":
+		return base64.StdEncoding.DecodeString(v.Value)
+	case "time":
+		return time.Parse(time.RFC3339Nano, v.Value)
+	default:
+		return nil, fmt.Errorf("cassette: can not replay value of type %s: %s", v.Type, v.Value)
+	}
+}
@@ -0,0 +1,145 @@
+package cassette_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/cassette"
+	"github.com/0xor1/isql/isqltest"
+)
+
+type upper string
+
+func (u upper) Value() (driver.Value, error) {
+	return "U:" + string(u), nil
+}
+
+// exercise makes the same calls against a recording or a replaying DB and checks what they return.
+func exercise(t *testing.T, db isql.DB) {
+	t.Helper()
+	id := int64(1)
+	var name string
+	var ts time.Time
+	if err := db.QueryRow("SELECT name, ts FROM u WHERE id = $1", &id).Scan(&name, &ts); err != nil {
+		t.Fatal(err)
+	}
+	if name != "a" || !ts.Equal(time.Unix(5, 0)) {
+		t.Fatalf("scanned %q %v", name, ts)
+	}
+	var n int
+	if err := db.QueryRow("SELECT none").Scan(&n); err != sql.ErrNoRows {
+		t.Fatalf("error %v, expected sql.ErrNoRows", err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := tx.Exec("INSERT", []byte("x"), nil, 1.5, upper("v"), sql.Named("at", ts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected, _ := res.RowsAffected(); affected != 2 {
+		t.Fatalf("rows affected %d", affected)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("bad"); err == nil || err.Error() != "bad" {
+		t.Fatalf("error %v, expected bad", err)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	f := isqltest.NewFake("cassette")
+	defer f.Close()
+	f.On(isqltest.Exact("SELECT name, ts FROM u WHERE id = $1")).
+		WillReturnRows([]string{"name", "ts"}, []interface{}{"a", time.Unix(5, 0).UTC()}).
+		WithColumnTypes("TEXT", "TIMESTAMP")
+	f.On(isqltest.Exact("SELECT none")).WillReturnRows([]string{"id"})
+	f.On(isqltest.Exact("INSERT")).WillReturnResult(1, 2)
+	f.On(isqltest.Exact("bad")).WillReturnError(errors.New("bad"))
+	db, err := isql.NewOpener().Open(isqltest.DriverName, "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec := cassette.NewRecorder(db, path)
+	exercise(t, rec)
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := c.Interactions[0].Args
+	if len(args) != 1 || args[0] != (cassette.Value{Type: "int64", Value: "1"}) {
+		t.Fatalf("pointer arg recorded as %+v", args)
+	}
+	args = c.Interactions[3].Args
+	if args[3] != (cassette.Value{Type: "string", Value: "U:v"}) || args[4].Name != "at" || args[4].Type != "time" {
+		t.Fatalf("args recorded as %+v", args)
+	}
+
+	p, err := cassette.NewPlayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exercise(t, p)
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var drift *cassette.DriftError
+	if _, err := p.Exec("extra"); !errors.As(err, &drift) || drift.Expected != nil {
+		t.Fatalf("error %v, expected a drift past the end", err)
+	}
+}
+
+func TestReplayDrift(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c := &cassette.Cassette{
+		Version: cassette.Version,
+		Interactions: []cassette.Interaction{
+			{Op: cassette.OpExec, Query: "UPDATE t", Args: []cassette.Value{{Type: "int64", Value: "1"}}},
+		},
+	}
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	p, err := cassette.NewPlayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var drift *cassette.DriftError
+	if _, err := p.Exec("UPDATE t", 2); !errors.As(err, &drift) || drift.Index != 0 {
+		t.Fatalf("error %v, expected a drift at 0", err)
+	}
+	if p.Remaining() != 1 {
+		t.Fatalf("remaining %d", p.Remaining())
+	}
+}
+
+func TestRecordUnsupportedArg(t *testing.T) {
+	f := isqltest.NewFake("cassette_unsupported")
+	defer f.Close()
+	f.On(isqltest.Exact("UPDATE t")).WillReturnResult(0, 1)
+	db, err := isql.NewOpener().Open(isqltest.DriverName, "cassette_unsupported")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := cassette.NewRecorder(db, filepath.Join(t.TempDir(), "cassette.json"))
+	defer rec.Close()
+	type point struct{ X, Y *int }
+	if _, err := rec.Exec("UPDATE t", &point{}); err == nil {
+		t.Fatal("expected a struct arg to fail")
+	}
+	if len(f.Queries()) != 0 {
+		t.Fatalf("unrecordable call ran %q", f.Queries())
+	}
+}
@@ -0,0 +1,421 @@
+package cassette
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+// NewRecorder returns a DB which forwards every call to db and records it, the cassette is written to path when
+// the DB is closed or Save is called. Result sets are read fully into memory as they are recorded, concurrent
+// use is safe but the recorded order of concurrent calls is not deterministic.
+func NewRecorder(db isql.DB, path string) Recorder {
+	return &recordingDB{
+		db: db,
+		rec: &recorder{
+			path: path,
+			cassette: &Cassette{
+				Version: Version,
+			},
+		},
+	}
+}
+
+type Recorder interface {
+	isql.DB
+	Save() error
+}
+
+type recorder struct {
+	mtx      sync.Mutex
+	path     string
+	cassette *Cassette
+}
+
+func (r *recorder) add(i Interaction) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+}
+
+func (r *recorder) save() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+func (r *recorder) op(op, query string, err error) {
+	i, _ := newInteraction(op, query, nil)
+	i.Error = encodeError(err)
+	r.add(i)
+}
+
+// exec, query and queryRow encode args before calling the database so an arg which can't be recorded fails the
+// call instead of running it unrecorded.
+func (r *recorder) exec(query string, args []interface{}, run func() (sql.Result, error)) (sql.Result, error) {
+	i, err := newInteraction(OpExec, query, args)
+	if err != nil {
+		return nil, err
+	}
+	res, err := run()
+	if err != nil {
+		i.Error = encodeError(err)
+	} else {
+		i.Result = encodeResult(res)
+	}
+	r.add(i)
+	return res, err
+}
+
+func (r *recorder) query(query string, args []interface{}, run func() (isql.Rows, error)) (isql.Rows, error) {
+	i, err := newInteraction(OpQuery, query, args)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := run()
+	sets, err := r.read(&i, rows, err)
+	if err != nil {
+		return nil, err
+	}
+	return isql.NewDataRows(sets...)
+}
+
+func (r *recorder) queryRow(query string, args []interface{}, run func() (isql.Rows, error)) isql.Row {
+	i, err := newInteraction(OpQueryRow, query, args)
+	if err != nil {
+		return &errRow{err: err}
+	}
+	rows, err := run()
+	sets, err := r.read(&i, rows, err)
+	if err != nil {
+		return &errRow{err: err}
+	}
+	return isql.NewDataRow(sets[0])
+}
+
+func (r *recorder) read(i *Interaction, rows isql.Rows, err error) ([]isql.ResultSet, error) {
+	var sets []isql.ResultSet
+	if err == nil {
+		sets, err = isql.ReadResultSets(rows)
+	}
+	if err != nil {
+		i.Error = encodeError(err)
+		r.add(*i)
+		return nil, err
+	}
+	i.ResultSets = encodeResultSets(sets)
+	r.add(*i)
+	return sets, nil
+}
+
+type recordingDB struct {
+	db  isql.DB
+	rec *recorder
+}
+
+func (d *recordingDB) Save() error {
+	return d.rec.save()
+}
+
+func (d *recordingDB) Begin() (isql.Tx, error) {
+	return d.BeginTx(context.Background(), nil)
+}
+
+func (d *recordingDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (isql.Tx, error) {
+	tx, err := d.db.BeginTx(ctx, opts)
+	d.rec.op(OpBegin, "", err)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingTx{
+		tx:  tx,
+		rec: d.rec,
+	}, nil
+}
+
+func (d *recordingDB) Close() error {
+	err := d.db.Close()
+	if saveErr := d.rec.save(); saveErr != nil {
+		return saveErr
+	}
+	return err
+}
+
+// Conn records calls on the returned Conn with those on the DB, taking and closing it are not recorded.
+func (d *recordingDB) Conn(ctx context.Context) (isql.PinnedConn, error) {
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingPinnedConn{
+		conn: conn,
+		rec:  d.rec,
+	}, nil
+}
+
+func (d *recordingDB) Driver() driver.Driver {
+	return d.db.Driver()
+}
+
+func (d *recordingDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.ExecContext(context.Background(), query, args...)
+}
+
+func (d *recordingDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return d.rec.exec(query, args, func() (sql.Result, error) {
+		return d.db.ExecContext(ctx, query, args...)
+	})
+}
+
+func (d *recordingDB) Ping() error {
+	return d.db.Ping()
+}
+
+func (d *recordingDB) PingContext(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
+func (d *recordingDB) Prepare(query string) (isql.Stmt, error) {
+	return d.PrepareContext(context.Background(), query)
+}
+
+func (d *recordingDB) PrepareContext(ctx context.Context, query string) (isql.Stmt, error) {
+	stmt, err := d.db.PrepareContext(ctx, query)
+	d.rec.op(OpPrepare, query, err)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingStmt{
+		stmt:  stmt,
+		query: query,
+		rec:   d.rec,
+	}, nil
+}
+
+func (d *recordingDB) Query(query string, args ...interface{}) (isql.Rows, error) {
+	return d.QueryContext(context.Background(), query, args...)
+}
+
+func (d *recordingDB) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	return d.rec.query(query, args, func() (isql.Rows, error) {
+		return d.db.QueryContext(ctx, query, args...)
+	})
+}
+
+func (d *recordingDB) QueryRow(query string, args ...interface{}) isql.Row {
+	return d.QueryRowContext(context.Background(), query, args...)
+}
+
+func (d *recordingDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	return d.rec.queryRow(query, args, func() (isql.Rows, error) {
+		return d.db.QueryContext(ctx, query, args...)
+	})
+}
+
+func (d *recordingDB) SetConnMaxIdleTime(dur time.Duration) {
+	d.db.SetConnMaxIdleTime(dur)
+}
+
+func (d *recordingDB) SetConnMaxLifetime(dur time.Duration) {
+	d.db.SetConnMaxLifetime(dur)
+}
+
+func (d *recordingDB) SetMaxIdleConns(n int) {
+	d.db.SetMaxIdleConns(n)
+}
+
+func (d *recordingDB) SetMaxOpenConns(n int) {
+	d.db.SetMaxOpenConns(n)
+}
+
+func (d *recordingDB) Stats() sql.DBStats {
+	return d.db.Stats()
+}
+
+func (d *recordingDB) StmtCacheStats() isql.StmtCacheStats {
+	return d.db.StmtCacheStats()
+}
+
+type recordingPinnedConn struct {
+	conn isql.PinnedConn
+	rec  *recorder
+}
+
+func (c *recordingPinnedConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (isql.Tx, error) {
+	tx, err := c.conn.BeginTx(ctx, opts)
+	c.rec.op(OpBegin, "", err)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingTx{
+		tx:  tx,
+		rec: c.rec,
+	}, nil
+}
+
+func (c *recordingPinnedConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *recordingPinnedConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.rec.exec(query, args, func() (sql.Result, error) {
+		return c.conn.ExecContext(ctx, query, args...)
+	})
+}
+
+func (c *recordingPinnedConn) PingContext(ctx context.Context) error {
+	return c.conn.PingContext(ctx)
+}
+
+func (c *recordingPinnedConn) PrepareContext(ctx context.Context, query string) (isql.Stmt, error) {
+	stmt, err := c.conn.PrepareContext(ctx, query)
+	c.rec.op(OpPrepare, query, err)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingStmt{
+		stmt:  stmt,
+		query: query,
+		rec:   c.rec,
+	}, nil
+}
+
+func (c *recordingPinnedConn) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	return c.rec.query(query, args, func() (isql.Rows, error) {
+		return c.conn.QueryContext(ctx, query, args...)
+	})
+}
+
+func (c *recordingPinnedConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	return c.rec.queryRow(query, args, func() (isql.Rows, error) {
+		return c.conn.QueryContext(ctx, query, args...)
+	})
+}
+
+type recordingTx struct {
+	tx  isql.Tx
+	rec *recorder
+}
+
+func (t *recordingTx) Commit() error {
+	err := t.tx.Commit()
+	t.rec.op(OpCommit, "", err)
+	return err
+}
+
+func (t *recordingTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.ExecContext(context.Background(), query, args...)
+}
+
+func (t *recordingTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.rec.exec(query, args, func() (sql.Result, error) {
+		return t.tx.ExecContext(ctx, query, args...)
+	})
+}
+
+func (t *recordingTx) Prepare(query string) (isql.Stmt, error) {
+	return t.PrepareContext(context.Background(), query)
+}
+
+func (t *recordingTx) PrepareContext(ctx context.Context, query string) (isql.Stmt, error) {
+	stmt, err := t.tx.PrepareContext(ctx, query)
+	t.rec.op(OpPrepare, query, err)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingStmt{
+		stmt:  stmt,
+		query: query,
+		rec:   t.rec,
+	}, nil
+}
+
+func (t *recordingTx) Query(query string, args ...interface{}) (isql.Rows, error) {
+	return t.QueryContext(context.Background(), query, args...)
+}
+
+func (t *recordingTx) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	return t.rec.query(query, args, func() (isql.Rows, error) {
+		return t.tx.QueryContext(ctx, query, args...)
+	})
+}
+
+func (t *recordingTx) QueryRow(query string, args ...interface{}) isql.Row {
+	return t.QueryRowContext(context.Background(), query, args...)
+}
+
+func (t *recordingTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	return t.rec.queryRow(query, args, func() (isql.Rows, error) {
+		return t.tx.QueryContext(ctx, query, args...)
+	})
+}
+
+func (t *recordingTx) Rollback() error {
+	err := t.tx.Rollback()
+	t.rec.op(OpRollback, "", err)
+	return err
+}
+
+// Stmt records calls on the returned Stmt with an empty query as the text of a *sql.Stmt is not accessible.
+func (t *recordingTx) Stmt(stmt *sql.Stmt) isql.Stmt {
+	return t.StmtContext(context.Background(), stmt)
+}
+
+func (t *recordingTx) StmtContext(ctx context.Context, stmt *sql.Stmt) isql.Stmt {
+	return &recordingStmt{
+		stmt: t.tx.StmtContext(ctx, stmt),
+		rec:  t.rec,
+	}
+}
+
+type recordingStmt struct {
+	stmt  isql.Stmt
+	query string
+	rec   *recorder
+}
+
+func (s *recordingStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *recordingStmt) Exec(args ...interface{}) (sql.Result, error) {
+	return s.ExecContext(context.Background(), args...)
+}
+
+func (s *recordingStmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	return s.rec.exec(s.query, args, func() (sql.Result, error) {
+		return s.stmt.ExecContext(ctx, args...)
+	})
+}
+
+func (s *recordingStmt) Query(args ...interface{}) (isql.Rows, error) {
+	return s.QueryContext(context.Background(), args...)
+}
+
+func (s *recordingStmt) QueryContext(ctx context.Context, args ...interface{}) (isql.Rows, error) {
+	return s.rec.query(s.query, args, func() (isql.Rows, error) {
+		return s.stmt.QueryContext(ctx, args...)
+	})
+}
+
+func (s *recordingStmt) QueryRow(args ...interface{}) isql.Row {
+	return s.QueryRowContext(context.Background(), args...)
+}
+
+func (s *recordingStmt) QueryRowContext(ctx context.Context, args ...interface{}) isql.Row {
+	return s.rec.queryRow(s.query, args, func() (isql.Rows, error) {
+		return s.stmt.QueryContext(ctx, args...)
+	})
+}
+
+type errRow struct {
+	err error
+}
+
+func (r *errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
@@ -0,0 +1,368 @@
+package cassette
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+// NewPlayer loads the cassette at path and returns a DB which serves its interactions in order with no database,
+// any call which does not match the next recorded interaction fails with a *DriftError.
+func NewPlayer(path string) (Player, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return &replayDB{
+		p: &player{
+			cassette: c,
+		},
+	}, nil
+}
+
+type Player interface {
+	isql.DB
+	// Remaining returns the number of recorded interactions which have not been played yet.
+	Remaining() int
+}
+
+type player struct {
+	mtx      sync.Mutex
+	cassette *Cassette
+	next     int
+}
+
+func (p *player) play(actual Interaction) (*Interaction, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.next >= len(p.cassette.Interactions) {
+		return nil, &DriftError{
+			Index:  p.next,
+			Actual: actual,
+		}
+	}
+	expected := &p.cassette.Interactions[p.next]
+	if !expected.matches(&actual) {
+		return nil, &DriftError{
+			Index:    p.next,
+			Expected: expected,
+			Actual:   actual,
+		}
+	}
+	p.next++
+	return expected, nil
+}
+
+func (p *player) remaining() int {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return len(p.cassette.Interactions) - p.next
+}
+
+func (p *player) op(op, query string) error {
+	actual, _ := newInteraction(op, query, nil)
+	i, err := p.play(actual)
+	if err != nil {
+		return err
+	}
+	return i.err()
+}
+
+func (p *player) exec(query string, args []interface{}) (sql.Result, error) {
+	actual, err := newInteraction(OpExec, query, args)
+	if err != nil {
+		return nil, err
+	}
+	i, err := p.play(actual)
+	if err != nil {
+		return nil, err
+	}
+	if i.Error != "" {
+		return nil, i.err()
+	}
+	if i.Result == nil {
+		return &result{r: &Result{}}, nil
+	}
+	return &result{r: i.Result}, nil
+}
+
+func (p *player) query(query string, args []interface{}) (isql.Rows, error) {
+	sets, err := p.resultSets(OpQuery, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return isql.NewDataRows(sets...)
+}
+
+func (p *player) queryRow(query string, args []interface{}) isql.Row {
+	sets, err := p.resultSets(OpQueryRow, query, args)
+	if err != nil {
+		return &errRow{err: err}
+	}
+	if len(sets) == 0 {
+		return isql.NewDataRow(isql.ResultSet{})
+	}
+	return isql.NewDataRow(sets[0])
+}
+
+func (p *player) resultSets(op, query string, args []interface{}) ([]isql.ResultSet, error) {
+	actual, err := newInteraction(op, query, args)
+	if err != nil {
+		return nil, err
+	}
+	i, err := p.play(actual)
+	if err != nil {
+		return nil, err
+	}
+	if i.Error != "" {
+		return nil, i.err()
+	}
+	return decodeResultSets(i.ResultSets)
+}
+
+type replayDB struct {
+	p *player
+}
+
+func (d *replayDB) Remaining() int {
+	return d.p.remaining()
+}
+
+func (d *replayDB) Begin() (isql.Tx, error) {
+	return d.BeginTx(context.Background(), nil)
+}
+
+func (d *replayDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (isql.Tx, error) {
+	if err := d.p.op(OpBegin, ""); err != nil {
+		return nil, err
+	}
+	return &replayTx{
+		p: d.p,
+	}, nil
+}
+
+// Close returns an error if any recorded interactions were not played.
+func (d *replayDB) Close() error {
+	if n := d.p.remaining(); n > 0 {
+		return fmt.Errorf("cassette: %d recorded interactions were not played", n)
+	}
+	return nil
+}
+
+// Conn returns a Conn which plays the same cassette, taking and closing it are not recorded.
+func (d *replayDB) Conn(ctx context.Context) (isql.PinnedConn, error) {
+	return &replayPinnedConn{
+		p: d.p,
+	}, nil
+}
+
+func (d *replayDB) Driver() driver.Driver {
+	return nil
+}
+
+func (d *replayDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.ExecContext(context.Background(), query, args...)
+}
+
+func (d *replayDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return d.p.exec(query, args)
+}
+
+func (d *replayDB) Ping() error {
+	return nil
+}
+
+func (d *replayDB) PingContext(ctx context.Context) error {
+	return nil
+}
+
+func (d *replayDB) Prepare(query string) (isql.Stmt, error) {
+	return d.PrepareContext(context.Background(), query)
+}
+
+func (d *replayDB) PrepareContext(ctx context.Context, query string) (isql.Stmt, error) {
+	if err := d.p.op(OpPrepare, query); err != nil {
+		return nil, err
+	}
+	return &replayStmt{
+		p:     d.p,
+		query: query,
+	}, nil
+}
+
+func (d *replayDB) Query(query string, args ...interface{}) (isql.Rows, error) {
+	return d.QueryContext(context.Background(), query, args...)
+}
+
+func (d *replayDB) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	return d.p.query(query, args)
+}
+
+func (d *replayDB) QueryRow(query string, args ...interface{}) isql.Row {
+	return d.QueryRowContext(context.Background(), query, args...)
+}
+
+func (d *replayDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	return d.p.queryRow(query, args)
+}
+
+func (d *replayDB) SetConnMaxIdleTime(dur time.Duration) {
+}
+
+func (d *replayDB) SetConnMaxLifetime(dur time.Duration) {
+}
+
+func (d *replayDB) SetMaxIdleConns(n int) {
+}
+
+func (d *replayDB) SetMaxOpenConns(n int) {
+}
+
+func (d *replayDB) Stats() sql.DBStats {
+	return sql.DBStats{}
+}
+
+func (d *replayDB) StmtCacheStats() isql.StmtCacheStats {
+	return isql.StmtCacheStats{}
+}
+
+type replayPinnedConn struct {
+	p *player
+}
+
+func (c *replayPinnedConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (isql.Tx, error) {
+	if err := c.p.op(OpBegin, ""); err != nil {
+		return nil, err
+	}
+	return &replayTx{
+		p: c.p,
+	}, nil
+}
+
+func (c *replayPinnedConn) Close() error {
+	return nil
+}
+
+func (c *replayPinnedConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.p.exec(query, args)
+}
+
+func (c *replayPinnedConn) PingContext(ctx context.Context) error {
+	return nil
+}
+
+func (c *replayPinnedConn) PrepareContext(ctx context.Context, query string) (isql.Stmt, error) {
+	if err := c.p.op(OpPrepare, query); err != nil {
+		return nil, err
+	}
+	return &replayStmt{
+		p:     c.p,
+		query: query,
+	}, nil
+}
+
+func (c *replayPinnedConn) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	return c.p.query(query, args)
+}
+
+func (c *replayPinnedConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	return c.p.queryRow(query, args)
+}
+
+type replayTx struct {
+	p *player
+}
+
+func (t *replayTx) Commit() error {
+	return t.p.op(OpCommit, "")
+}
+
+func (t *replayTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.ExecContext(context.Background(), query, args...)
+}
+
+func (t *replayTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.p.exec(query, args)
+}
+
+func (t *replayTx) Prepare(query string) (isql.Stmt, error) {
+	return t.PrepareContext(context.Background(), query)
+}
+
+func (t *replayTx) PrepareContext(ctx context.Context, query string) (isql.Stmt, error) {
+	if err := t.p.op(OpPrepare, query); err != nil {
+		return nil, err
+	}
+	return &replayStmt{
+		p:     t.p,
+		query: query,
+	}, nil
+}
+
+func (t *replayTx) Query(query string, args ...interface{}) (isql.Rows, error) {
+	return t.QueryContext(context.Background(), query, args...)
+}
+
+func (t *replayTx) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	return t.p.query(query, args)
+}
+
+func (t *replayTx) QueryRow(query string, args ...interface{}) isql.Row {
+	return t.QueryRowContext(context.Background(), query, args...)
+}
+
+func (t *replayTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	return t.p.queryRow(query, args)
+}
+
+func (t *replayTx) Rollback() error {
+	return t.p.op(OpRollback, "")
+}
+
+func (t *replayTx) Stmt(stmt *sql.Stmt) isql.Stmt {
+	return t.StmtContext(context.Background(), stmt)
+}
+
+func (t *replayTx) StmtContext(ctx context.Context, stmt *sql.Stmt) isql.Stmt {
+	return &replayStmt{
+		p: t.p,
+	}
+}
+
+type replayStmt struct {
+	p     *player
+	query string
+}
+
+func (s *replayStmt) Close() error {
+	return nil
+}
+
+func (s *replayStmt) Exec(args ...interface{}) (sql.Result, error) {
+	return s.ExecContext(context.Background(), args...)
+}
+
+func (s *replayStmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	return s.p.exec(s.query, args)
+}
+
+func (s *replayStmt) Query(args ...interface{}) (isql.Rows, error) {
+	return s.QueryContext(context.Background(), args...)
+}
+
+func (s *replayStmt) QueryContext(ctx context.Context, args ...interface{}) (isql.Rows, error) {
+	return s.p.query(s.query, args)
+}
+
+func (s *replayStmt) QueryRow(args ...interface{}) isql.Row {
+	return s.QueryRowContext(context.Background(), args...)
+}
+
+func (s *replayStmt) QueryRowContext(ctx context.Context, args ...interface{}) isql.Row {
+	return s.p.queryRow(s.query, args)
+}
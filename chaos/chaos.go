@@ -0,0 +1,203 @@
+// Package chaos wraps isql types to inject latency, connection errors, deadline errors, partial Rows failures and
+// failed commits, so retry and failover handling can be proven to work.
+package chaos
+
+import (
+	"context"
+	"database/sql/driver"
+	"math/rand"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+type Op uint
+
+const (
+	OpExec Op = 1 << iota
+	OpQuery
+	OpPrepare
+	OpBegin
+	OpCommit
+	OpRollback
+)
+
+// New returns an Injector which applies cfg.Rules to every call made through the isql types it wraps, all
+// wrappers created from it share a single RNG seeded with cfg.Seed so a run can be reproduced.
+func New(cfg Config) Injector {
+	return &injector{
+		shared: &shared{
+			rules: cfg.Rules,
+			rnd:   rand.New(rand.NewSource(cfg.Seed)),
+		},
+	}
+}
+
+type Config struct {
+	Seed  int64
+	Rules []Rule
+}
+
+type Rule struct {
+	// Ops restricts the rule to the given operations, zero applies it to all of them.
+	Ops Op
+	// Target restricts the rule to wrappers created by Injector.Target(Target), empty applies it to all of them,
+	// ReplicaSet members are targeted as "primary" and "slave:<index>".
+	Target string
+	// Match restricts the rule to matching queries, nil matches all queries. Begin, Commit and Rollback are
+	// matched as BEGIN, COMMIT and ROLLBACK.
+	Match func(query string) bool
+	// Probability of the fault being injected when the rule applies, 1 or more always injects it.
+	Probability float64
+	Fault       Fault
+}
+
+type Fault interface {
+	apply(ctx context.Context, f *faults) error
+}
+
+// Latency delays the call by d, or until its context is done.
+func Latency(d time.Duration) Fault {
+	return &latencyFault{
+		d: d,
+	}
+}
+
+func Error(err error) Fault {
+	return &errorFault{
+		err: err,
+	}
+}
+
+func BadConn() Fault {
+	return Error(driver.ErrBadConn)
+}
+
+func DeadlineExceeded() Fault {
+	return Error(context.DeadlineExceeded)
+}
+
+// RowsError makes Rows fail with err instead of returning a row after afterRows rows have been read, a result with
+// no more rows than that ends normally. It only applies to queries.
+func RowsError(afterRows int, err error) Fault {
+	return &rowsFault{
+		after: afterRows,
+		err:   err,
+	}
+}
+
+func Exact(query string) func(string) bool {
+	return func(q string) bool {
+		return q == query
+	}
+}
+
+func Contains(substr string) func(string) bool {
+	return func(q string) bool {
+		return strings.Contains(q, substr)
+	}
+}
+
+func Regex(pattern string) func(string) bool {
+	re := regexp.MustCompile(pattern)
+	return re.MatchString
+}
+
+type Injector interface {
+	// Target returns an Injector sharing this ones rules and RNG whose wrappers only have rules with a matching
+	// Target applied, along with untargeted rules.
+	Target(name string) Injector
+	WrapDBCore(core isql.DBCore) isql.DBCore
+	WrapDB(db isql.DB) isql.DB
+	WrapTx(tx isql.Tx) isql.Tx
+	WrapStmt(stmt isql.Stmt, query string) isql.Stmt
+	// WrapReplicaSet regroups rs with each of its members wrapped and targeted as "primary" or "slave:<index>".
+	WrapReplicaSet(rs isql.ReplicaSet) isql.ReplicaSet
+}
+
+type shared struct {
+	rules []Rule
+	mtx   sync.Mutex
+	rnd   *rand.Rand
+}
+
+type injector struct {
+	*shared
+	target string
+}
+
+func (i *injector) fire(r *Rule) bool {
+	if r.Probability >= 1 {
+		return true
+	}
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	return i.rnd.Float64() < r.Probability
+}
+
+// inject applies every rule matching the call, it returns the error to fail the call with, if any, and the faults
+// to apply to the calls Rows.
+func (i *injector) inject(ctx context.Context, op Op, query string) (*faults, error) {
+	f := &faults{}
+	for idx := range i.rules {
+		r := &i.rules[idx]
+		if r.Ops != 0 && r.Ops&op == 0 {
+			continue
+		}
+		if r.Target != "" && r.Target != i.target {
+			continue
+		}
+		if r.Match != nil && !r.Match(query) {
+			continue
+		}
+		if r.Fault == nil || !i.fire(r) {
+			continue
+		}
+		if err := r.Fault.apply(ctx, f); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+type faults struct {
+	rows *rowsFault
+}
+
+type latencyFault struct {
+	d time.Duration
+}
+
+func (l *latencyFault) apply(ctx context.Context, f *faults) error {
+	t := time.NewTimer(l.d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type errorFault struct {
+	err error
+}
+
+func (e *errorFault) apply(ctx context.Context, f *faults) error {
+	return e.err
+}
+
+type rowsFault struct {
+	after int
+	err   error
+}
+
+func (r *rowsFault) apply(ctx context.Context, f *faults) error {
+	if f.rows == nil {
+		f.rows = r
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package chaos_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/chaos"
+	"github.com/0xor1/isql/isqltest"
+)
+
+func open(t *testing.T, name string) isql.DB {
+	t.Helper()
+	f := isqltest.NewFake(name)
+	t.Cleanup(f.Close)
+	for n := 0; n <= 3; n++ {
+		rows := make([][]interface{}, n)
+		for i := range rows {
+			rows[i] = []interface{}{i}
+		}
+		f.On(isqltest.Exact("SELECT "+strconv.Itoa(n))).WillReturnRows([]string{"a"}, rows...)
+	}
+	f.On(isqltest.Regex(`^INSERT`)).WillReturnResult(0, 1)
+	db, err := isql.NewOpener().Open(isqltest.DriverName, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db
+}
+
+func TestRowsError(t *testing.T) {
+	mid := errors.New("mid")
+	tests := []struct {
+		rows, after int
+		read        int
+		err         error
+	}{
+		{3, 2, 2, mid},
+		{2, 2, 2, nil},
+		{1, 2, 1, nil},
+		{0, 0, 0, nil},
+		{1, 0, 0, mid},
+	}
+	db := open(t, "chaos_rows")
+	for _, tt := range tests {
+		inj := chaos.New(chaos.Config{Rules: []chaos.Rule{
+			{Ops: chaos.OpQuery, Probability: 1, Fault: chaos.RowsError(tt.after, mid)},
+		}})
+		rows, err := inj.WrapDB(db).Query("SELECT " + strconv.Itoa(tt.rows))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for rows.Next() {
+			n++
+		}
+		rows.Close()
+		if n != tt.read || rows.Err() != tt.err {
+			t.Errorf("%d rows failing after %d: read %d with error %v, expected %d and %v", tt.rows, tt.after, n, rows.Err(), tt.read, tt.err)
+		}
+	}
+}
+
+func TestFaults(t *testing.T) {
+	db := open(t, "chaos_faults")
+	commitErr := errors.New("commit failed")
+	inj := chaos.New(chaos.Config{Seed: 1, Rules: []chaos.Rule{
+		{Ops: chaos.OpCommit, Probability: 1, Fault: chaos.Error(commitErr)},
+		{Ops: chaos.OpExec, Probability: 0.5, Fault: chaos.BadConn()},
+		{Target: "slave:0", Probability: 1, Fault: chaos.Latency(time.Minute)},
+	}})
+	cdb := inj.WrapDB(db)
+	tx, err := cdb.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != commitErr {
+		t.Fatalf("commit error %v, expected %v", err, commitErr)
+	}
+	bad := 0
+	for i := 0; i < 200; i++ {
+		if _, err := cdb.Exec("INSERT"); err == driver.ErrBadConn {
+			bad++
+		}
+	}
+	if bad < 60 || bad > 140 {
+		t.Fatalf("%d of 200 execs failed with probability 0.5", bad)
+	}
+	rs := inj.WrapReplicaSet(isql.NewReplicaSetFromMembers(db, db))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := rs.QueryContext(ctx, "SELECT 1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, expected the slave latency to hit the deadline", err)
+	}
+	if _, err := rs.ExecContext(context.Background(), "INSERT"); err != nil && err != driver.ErrBadConn {
+		t.Fatalf("primary error %v", err)
+	}
+}
+
+func TestSeedReproducible(t *testing.T) {
+	db := open(t, "chaos_seed")
+	run := func() []bool {
+		inj := chaos.New(chaos.Config{Seed: 42, Rules: []chaos.Rule{
+			{Ops: chaos.OpExec, Probability: 0.3, Fault: chaos.BadConn()},
+		}})
+		cdb := inj.WrapDB(db)
+		res := make([]bool, 50)
+		for i := range res {
+			_, err := cdb.Exec("INSERT")
+			res[i] = err != nil
+		}
+		return res
+	}
+	a, b := run(), run()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("runs with the same seed differ at exec %d", i)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package chaos
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strconv"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+func (i *injector) Target(name string) Injector {
+	return &injector{
+		shared: i.shared,
+		target: name,
+	}
+}
+
+func (i *injector) WrapDBCore(core isql.DBCore) isql.DBCore {
+	return &coreWrapper{
+		core: core,
+		inj:  i,
+	}
+}
+
+func (i *injector) WrapDB(db isql.DB) isql.DB {
+	return &dbWrapper{
+		coreWrapper: coreWrapper{
+			core: db,
+			inj:  i,
+		},
+		db: db,
+	}
+}
+
+func (i *injector) WrapTx(tx isql.Tx) isql.Tx {
+	return &txWrapper{
+		coreWrapper: coreWrapper{
+			core: tx,
+			inj:  i,
+		},
+		tx: tx,
+	}
+}
+
+func (i *injector) WrapStmt(stmt isql.Stmt, query string) isql.Stmt {
+	return &stmtWrapper{
+		stmt:  stmt,
+		query: query,
+		inj:   i,
+	}
+}
+
+func (i *injector) WrapReplicaSet(rs isql.ReplicaSet) isql.ReplicaSet {
+	slaves := make([]isql.DBCore, 0, len(rs.Slaves()))
+	for idx, slave := range rs.Slaves() {
+		slaves = append(slaves, i.Target("slave:"+strconv.Itoa(idx)).WrapDBCore(slave))
+	}
+	return isql.NewReplicaSetFromMembers(i.Target("primary").WrapDBCore(rs.Primary()), slaves...)
+}
+
+type coreWrapper struct {
+	core isql.DBCore
+	inj  *injector
+}
+
+func (c *coreWrapper) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if _, err := c.inj.inject(ctx, OpExec, query); err != nil {
+		return nil, err
+	}
+	return c.core.ExecContext(ctx, query, args...)
+}
+
+func (c *coreWrapper) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	f, err := c.inj.inject(ctx, OpQuery, query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := c.core.QueryContext(ctx, query, args...)
+	return f.wrapRows(rows), err
+}
+
+func (c *coreWrapper) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	if err := c.inj.injectRow(ctx, query); err != nil {
+		return &errRow{err: err}
+	}
+	return c.core.QueryRowContext(ctx, query, args...)
+}
+
+type dbWrapper struct {
+	coreWrapper
+	db isql.DB
+}
+
+func (d *dbWrapper) Begin() (isql.Tx, error) {
+	return d.BeginTx(context.Background(), nil)
+}
+
+func (d *dbWrapper) BeginTx(ctx context.Context, opts *sql.TxOptions) (isql.Tx, error) {
+	if _, err := d.inj.inject(ctx, OpBegin, "BEGIN"); err != nil {
+		return nil, err
+	}
+	tx, err := d.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return d.inj.WrapTx(tx), nil
+}
+
+func (d *dbWrapper) Close() error {
+	return d.db.Close()
+}
+
+func (d *dbWrapper) Conn(ctx context.Context) (isql.PinnedConn, error) {
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pinnedConnWrapper{
+		coreWrapper: coreWrapper{
+			core: conn,
+			inj:  d.inj,
+		},
+		conn: conn,
+	}, nil
+}
+
+func (d *dbWrapper) Driver() driver.Driver {
+	return d.db.Driver()
+}
+
+func (d *dbWrapper) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.ExecContext(context.Background(), query, args...)
+}
+
+func (d *dbWrapper) Ping() error {
+	return d.db.Ping()
+}
+
+func (d *dbWrapper) PingContext(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
+func (d *dbWrapper) Prepare(query string) (isql.Stmt, error) {
+	return d.PrepareContext(context.Background(), query)
+}
+
+func (d *dbWrapper) PrepareContext(ctx context.Context, query string) (isql.Stmt, error) {
+	if _, err := d.inj.inject(ctx, OpPrepare, query); err != nil {
+		return nil, err
+	}
+	stmt, err := d.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return d.inj.WrapStmt(stmt, query), nil
+}
+
+func (d *dbWrapper) Query(query string, args ...interface{}) (isql.Rows, error) {
+	return d.QueryContext(context.Background(), query, args...)
+}
+
+func (d *dbWrapper) QueryRow(query string, args ...interface{}) isql.Row {
+	return d.QueryRowContext(context.Background(), query, args...)
+}
+
+func (d *dbWrapper) SetConnMaxIdleTime(dur time.Duration) {
+	d.db.SetConnMaxIdleTime(dur)
+}
+
+func (d *dbWrapper) SetConnMaxLifetime(dur time.Duration) {
+	d.db.SetConnMaxLifetime(dur)
+}
+
+func (d *dbWrapper) SetMaxIdleConns(n int) {
+	d.db.SetMaxIdleConns(n)
+}
+
+func (d *dbWrapper) SetMaxOpenConns(n int) {
+	d.db.SetMaxOpenConns(n)
+}
+
+func (d *dbWrapper) Stats() sql.DBStats {
+	return d.db.Stats()
+}
+
+func (d *dbWrapper) StmtCacheStats() isql.StmtCacheStats {
+	return d.db.StmtCacheStats()
+}
+
+type pinnedConnWrapper struct {
+	coreWrapper
+	conn isql.PinnedConn
+}
+
+func (c *pinnedConnWrapper) BeginTx(ctx context.Context, opts *sql.TxOptions) (isql.Tx, error) {
+	if _, err := c.inj.inject(ctx, OpBegin, "BEGIN"); err != nil {
+		return nil, err
+	}
+	tx, err := c.conn.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.inj.WrapTx(tx), nil
+}
+
+func (c *pinnedConnWrapper) Close() error {
+	return c.conn.Close()
+}
+
+func (c *pinnedConnWrapper) PingContext(ctx context.Context) error {
+	return c.conn.PingContext(ctx)
+}
+
+func (c *pinnedConnWrapper) PrepareContext(ctx context.Context, query string) (isql.Stmt, error) {
+	if _, err := c.inj.inject(ctx, OpPrepare, query); err != nil {
+		return nil, err
+	}
+	stmt, err := c.conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return c.inj.WrapStmt(stmt, query), nil
+}
+
+type txWrapper struct {
+	coreWrapper
+	tx isql.Tx
+}
+
+// Commit rolls back the underlying transaction when a fault is injected, as a real failed commit would.
+func (t *txWrapper) Commit() error {
+	if _, err := t.inj.inject(context.Background(), OpCommit, "COMMIT"); err != nil {
+		t.tx.Rollback()
+		return err
+	}
+	return t.tx.Commit()
+}
+
+func (t *txWrapper) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.ExecContext(context.Background(), query, args...)
+}
+
+func (t *txWrapper) Prepare(query string) (isql.Stmt, error) {
+	return t.PrepareContext(context.Background(), query)
+}
+
+func (t *txWrapper) PrepareContext(ctx context.Context, query string) (isql.Stmt, error) {
+	if _, err := t.inj.inject(ctx, OpPrepare, query); err != nil {
+		return nil, err
+	}
+	stmt, err := t.tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return t.inj.WrapStmt(stmt, query), nil
+}
+
+func (t *txWrapper) Query(query string, args ...interface{}) (isql.Rows, error) {
+	return t.QueryContext(context.Background(), query, args...)
+}
+
+func (t *txWrapper) QueryRow(query string, args ...interface{}) isql.Row {
+	return t.QueryRowContext(context.Background(), query, args...)
+}
+
+func (t *txWrapper) Rollback() error {
+	if _, err := t.inj.inject(context.Background(), OpRollback, "ROLLBACK"); err != nil {
+		t.tx.Rollback()
+		return err
+	}
+	return t.tx.Rollback()
+}
+
+// Stmt wraps the statement with an empty query as the text of a *sql.Stmt is not accessible.
+func (t *txWrapper) Stmt(stmt *sql.Stmt) isql.Stmt {
+	return t.inj.WrapStmt(t.tx.Stmt(stmt), "")
+}
+
+func (t *txWrapper) StmtContext(ctx context.Context, stmt *sql.Stmt) isql.Stmt {
+	return t.inj.WrapStmt(t.tx.StmtContext(ctx, stmt), "")
+}
+
+type stmtWrapper struct {
+	stmt  isql.Stmt
+	query string
+	inj   *injector
+}
+
+func (s *stmtWrapper) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *stmtWrapper) Exec(args ...interface{}) (sql.Result, error) {
+	return s.ExecContext(context.Background(), args...)
+}
+
+func (s *stmtWrapper) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	if _, err := s.inj.inject(ctx, OpExec, s.query); err != nil {
+		return nil, err
+	}
+	return s.stmt.ExecContext(ctx, args...)
+}
+
+func (s *stmtWrapper) Query(args ...interface{}) (isql.Rows, error) {
+	return s.QueryContext(context.Background(), args...)
+}
+
+func (s *stmtWrapper) QueryContext(ctx context.Context, args ...interface{}) (isql.Rows, error) {
+	f, err := s.inj.inject(ctx, OpQuery, s.query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.stmt.QueryContext(ctx, args...)
+	return f.wrapRows(rows), err
+}
+
+func (s *stmtWrapper) QueryRow(args ...interface{}) isql.Row {
+	return s.QueryRowContext(context.Background(), args...)
+}
+
+func (s *stmtWrapper) QueryRowContext(ctx context.Context, args ...interface{}) isql.Row {
+	if err := s.inj.injectRow(ctx, s.query); err != nil {
+		return &errRow{err: err}
+	}
+	return s.stmt.QueryRowContext(ctx, args...)
+}
+
+func (f *faults) wrapRows(rows isql.Rows) isql.Rows {
+	if f.rows == nil || rows == nil {
+		return rows
+	}
+	return &faultyRows{
+		Rows:      rows,
+		remaining: f.rows.after,
+		err:       f.rows.err,
+	}
+}
+
+// injectRow is inject for single row queries, a Row can only fail on its first row so the query is not run at all.
+func (i *injector) injectRow(ctx context.Context, query string) error {
+	f, err := i.inject(ctx, OpQuery, query)
+	if err != nil {
+		return err
+	}
+	if f.rows != nil && f.rows.after == 0 {
+		return f.rows.err
+	}
+	return nil
+}
+
+type faultyRows struct {
+	isql.Rows
+	remaining int
+	err       error
+	failed    bool
+}
+
+func (r *faultyRows) Next() bool {
+	if r.failed {
+		return false
+	}
+	if !r.Rows.Next() {
+		return false
+	}
+	if r.remaining == 0 {
+		// only fail if there was another row, a result with exactly the rows allowed ends cleanly
+		r.failed = true
+		return false
+	}
+	r.remaining--
+	return true
+}
+
+func (r *faultyRows) Err() error {
+	if r.failed {
+		return r.err
+	}
+	return r.Rows.Err()
+}
+
+type errRow struct {
+	err error
+}
+
+func (r *errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
@@ -0,0 +1,381 @@
+package isql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the underlying DBCore while its circuit is open.
+var ErrCircuitOpen = errors.New("isql: circuit open")
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "CircuitState(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// CircuitBreakerConfig zero values are replaced with the defaults noted on each field.
+type CircuitBreakerConfig struct {
+	// Name is passed to OnStateChange to identify the breaker.
+	Name string
+	// Window is the length of the sliding window failures are counted over, default 10s.
+	Window time.Duration
+	// Buckets is the number of buckets Window is divided into, default 10.
+	Buckets int
+	// MinRequests is the number of requests in the window below which the circuit will not open, default 20.
+	MinRequests int
+	// FailureRate is the fraction of failed requests in the window at which the circuit opens, default 0.5.
+	FailureRate float64
+	// OpenTimeout is how long the circuit stays open before allowing trial requests, default 30s.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of trial requests which must all succeed to close the circuit, default 1.
+	HalfOpenRequests int
+	// IsFailure reports whether err counts as a failure, by default every error other than sql.ErrNoRows,
+	// sql.ErrTxDone and context.Canceled does.
+	IsFailure func(err error) bool
+	// OnStateChange is called on every state change, e.g. to export metrics or log, it must not block.
+	OnStateChange func(name string, from, to CircuitState)
+}
+
+// NewCircuitBreaker returns a DBCore which fails fast with ErrCircuitOpen while db is failing. The result of a
+// QueryContext call is recorded when its Rows are exhausted or closed and that of a QueryRowContext call when its Row
+// is scanned, a half-open trial whose Rows or Row is abandoned is given up on after OpenTimeout.
+func NewCircuitBreaker(db DBCore, cfg CircuitBreakerConfig) CircuitBreaker {
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.Buckets <= 0 {
+		cfg.Buckets = 10
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 20
+	}
+	if cfg.FailureRate <= 0 {
+		cfg.FailureRate = 0.5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = 1
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = isCircuitFailure
+	}
+	bucketWidth := cfg.Window / time.Duration(cfg.Buckets)
+	if bucketWidth <= 0 {
+		bucketWidth = 1
+	}
+	return &circuitBreaker{
+		db:          db,
+		cfg:         cfg,
+		now:         time.Now,
+		bucketWidth: bucketWidth,
+		buckets:     make([]circuitBucket, cfg.Buckets),
+	}
+}
+
+type CircuitBreaker interface {
+	DBCore
+	State() CircuitState
+	// Available reports whether a request would be let through now, it is false while the circuit is open and
+	// while it is half-open with every trial request in flight.
+	Available() bool
+}
+
+// NewCircuitBreakerReplicaSet regroups rs with each of its members wrapped in a circuit breaker named "primary" or
+// "slave:<index>", queries skip slaves which are not available and fall back to the primary if none are.
+func NewCircuitBreakerReplicaSet(rs ReplicaSet, cfg CircuitBreakerConfig) ReplicaSet {
+	primaryCfg := cfg
+	primaryCfg.Name = "primary"
+	slaves := make([]DBCore, 0, len(rs.Slaves()))
+	for idx, slave := range rs.Slaves() {
+		slaveCfg := cfg
+		slaveCfg.Name = "slave:" + strconv.Itoa(idx)
+		slaves = append(slaves, NewCircuitBreaker(slave, slaveCfg))
+	}
+	return NewReplicaSetFromMembers(NewCircuitBreaker(rs.Primary(), primaryCfg), slaves...)
+}
+
+func isCircuitFailure(err error) bool {
+	return err != nil &&
+		err != sql.ErrNoRows &&
+		err != sql.ErrTxDone &&
+		!errors.Is(err, context.Canceled)
+}
+
+type circuitBucket struct {
+	epoch     int64
+	successes int
+	failures  int
+}
+
+type circuitBreaker struct {
+	db          DBCore
+	cfg         CircuitBreakerConfig
+	now         func() time.Time
+	mtx         sync.Mutex
+	state       CircuitState
+	openedAt    time.Time
+	trials      int
+	trialPassed int
+	trialAt     time.Time
+	trialGen    uint64
+	bucketWidth time.Duration
+	buckets     []circuitBucket
+}
+
+type circuitChange struct {
+	from, to CircuitState
+}
+
+func (c *circuitBreaker) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	trial, err := c.allow()
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.db.ExecContext(ctx, query, args...)
+	c.record(trial, err)
+	return res, err
+}
+
+func (c *circuitBreaker) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	trial, err := c.allow()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		c.record(trial, err)
+		return nil, err
+	}
+	return &circuitRows{
+		Rows:    rows,
+		breaker: c,
+		trial:   trial,
+	}, nil
+}
+
+func (c *circuitBreaker) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	trial, err := c.allow()
+	if err != nil {
+		return &errRow{
+			err: err,
+		}
+	}
+	return &circuitRow{
+		row:     c.db.QueryRowContext(ctx, query, args...),
+		breaker: c,
+		trial:   trial,
+	}
+}
+
+func (c *circuitBreaker) State() CircuitState {
+	c.mtx.Lock()
+	changes := c.tryHalfOpen(nil)
+	state := c.state
+	c.mtx.Unlock()
+	c.notify(changes)
+	return state
+}
+
+func (c *circuitBreaker) Available() bool {
+	c.mtx.Lock()
+	changes := c.tryHalfOpen(nil)
+	available := c.state == CircuitClosed || c.state == CircuitHalfOpen && c.trialAvailable()
+	c.mtx.Unlock()
+	c.notify(changes)
+	return available
+}
+
+// allow reports whether a request may proceed and, if it is a half-open trial whose result decides the state, the
+// generation of trials it belongs to, 0 otherwise.
+func (c *circuitBreaker) allow() (uint64, error) {
+	c.mtx.Lock()
+	changes := c.tryHalfOpen(nil)
+	trial, err := uint64(0), error(nil)
+	switch c.state {
+	case CircuitOpen:
+		err = ErrCircuitOpen
+	case CircuitHalfOpen:
+		if c.trialAvailable() {
+			c.trials++
+			c.trialAt = c.now()
+			trial = c.trialGen
+		} else {
+			err = ErrCircuitOpen
+		}
+	}
+	c.mtx.Unlock()
+	c.notify(changes)
+	return trial, err
+}
+
+func (c *circuitBreaker) record(trial uint64, err error) {
+	failed := c.cfg.IsFailure(err)
+	c.mtx.Lock()
+	var changes []circuitChange
+	if trial != 0 {
+		// the results of abandoned trials, or of trials from an earlier half-open state, are ignored
+		if c.state == CircuitHalfOpen && trial == c.trialGen {
+			c.trials--
+			if failed {
+				changes = c.open(changes)
+			} else if c.trialPassed++; c.trialPassed >= c.cfg.HalfOpenRequests {
+				changes = c.setState(changes, CircuitClosed)
+			}
+		}
+	} else if c.state == CircuitClosed {
+		b := c.bucket(c.now())
+		if failed {
+			b.failures++
+		} else {
+			b.successes++
+		}
+		if successes, failures := c.totals(c.now()); successes+failures >= c.cfg.MinRequests &&
+			float64(failures)/float64(successes+failures) >= c.cfg.FailureRate {
+			changes = c.open(changes)
+		}
+	}
+	c.mtx.Unlock()
+	c.notify(changes)
+}
+
+// the methods below must be called with mtx held.
+
+// trialAvailable reports whether another trial may start, trials still in flight after OpenTimeout are assumed to be
+// abandoned, e.g. a Row which is never scanned, and are given up on so they don't hold the circuit half-open forever.
+func (c *circuitBreaker) trialAvailable() bool {
+	if c.trials < c.cfg.HalfOpenRequests {
+		return true
+	}
+	if !c.now().Before(c.trialAt.Add(c.cfg.OpenTimeout)) {
+		c.trials = 0
+		c.trialGen++
+		return true
+	}
+	return false
+}
+
+func (c *circuitBreaker) tryHalfOpen(changes []circuitChange) []circuitChange {
+	if c.state == CircuitOpen && !c.now().Before(c.openedAt.Add(c.cfg.OpenTimeout)) {
+		return c.setState(changes, CircuitHalfOpen)
+	}
+	return changes
+}
+
+func (c *circuitBreaker) open(changes []circuitChange) []circuitChange {
+	c.openedAt = c.now()
+	return c.setState(changes, CircuitOpen)
+}
+
+func (c *circuitBreaker) setState(changes []circuitChange, to CircuitState) []circuitChange {
+	from := c.state
+	c.state = to
+	c.trials = 0
+	c.trialPassed = 0
+	c.trialGen++
+	if to == CircuitClosed {
+		for i := range c.buckets {
+			c.buckets[i] = circuitBucket{}
+		}
+	}
+	return append(changes, circuitChange{
+		from: from,
+		to:   to,
+	})
+}
+
+func (c *circuitBreaker) bucket(now time.Time) *circuitBucket {
+	epoch := now.UnixNano() / int64(c.bucketWidth)
+	b := &c.buckets[epoch%int64(len(c.buckets))]
+	if b.epoch != epoch {
+		*b = circuitBucket{
+			epoch: epoch,
+		}
+	}
+	return b
+}
+
+func (c *circuitBreaker) totals(now time.Time) (successes, failures int) {
+	epoch := now.UnixNano() / int64(c.bucketWidth)
+	for _, b := range c.buckets {
+		if b.epoch > epoch-int64(len(c.buckets)) {
+			successes += b.successes
+			failures += b.failures
+		}
+	}
+	return successes, failures
+}
+
+func (c *circuitBreaker) notify(changes []circuitChange) {
+	if c.cfg.OnStateChange == nil {
+		return
+	}
+	for _, change := range changes {
+		c.cfg.OnStateChange(c.cfg.Name, change.from, change.to)
+	}
+}
+
+type circuitRow struct {
+	row     Row
+	breaker *circuitBreaker
+	trial   uint64
+	once    sync.Once
+}
+
+func (r *circuitRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	r.once.Do(func() {
+		r.breaker.record(r.trial, err)
+	})
+	return err
+}
+
+// circuitRows records the result of its query once iteration ends, so errors reading rows count as well as errors
+// running the query.
+type circuitRows struct {
+	Rows
+	breaker *circuitBreaker
+	trial   uint64
+	once    sync.Once
+}
+
+func (r *circuitRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.done()
+	return false
+}
+
+func (r *circuitRows) Close() error {
+	err := r.Rows.Close()
+	r.done()
+	return err
+}
+
+func (r *circuitRows) done() {
+	r.once.Do(func() {
+		r.breaker.record(r.trial, r.Rows.Err())
+	})
+}
@@ -0,0 +1,203 @@
+package isql_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+// flakyDB fails every call with err, and if rowsErr is set returns Rows which fail with it after the first row.
+type flakyDB struct {
+	err     error
+	rowsErr error
+	queries int
+}
+
+func (f *flakyDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	f.queries++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return isql.NewResult(0, 1), nil
+}
+
+func (f *flakyDB) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	f.queries++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return isql.NewDataRows(isql.ResultSet{Columns: []string{"a"}, Rows: [][]interface{}{{1}, {2}}, Err: f.rowsErr, ErrAt: 1})
+}
+
+func (f *flakyDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	f.queries++
+	if f.err != nil {
+		return isql.NewDataRow(isql.ResultSet{Columns: []string{"a"}, Err: f.err})
+	}
+	return isql.NewDataRow(isql.ResultSet{Columns: []string{"a"}, Rows: [][]interface{}{{1}}})
+}
+
+func newTestBreaker(db isql.DBCore, halfOpenRequests int) (isql.CircuitBreaker, *[]string) {
+	var changes []string
+	return isql.NewCircuitBreaker(db, isql.CircuitBreakerConfig{
+		MinRequests:      2,
+		OpenTimeout:      20 * time.Millisecond,
+		HalfOpenRequests: halfOpenRequests,
+		OnStateChange: func(name string, from, to isql.CircuitState) {
+			changes = append(changes, to.String())
+		},
+	}), &changes
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	boom := errors.New("boom")
+	ctx := context.Background()
+	calls := map[string]func(cb isql.CircuitBreaker) error{
+		"exec": func(cb isql.CircuitBreaker) error {
+			_, err := cb.ExecContext(ctx, "UPDATE t")
+			return err
+		},
+		"query": func(cb isql.CircuitBreaker) error {
+			rows, err := cb.QueryContext(ctx, "SELECT a")
+			if err != nil {
+				return err
+			}
+			for rows.Next() {
+			}
+			return rows.Err()
+		},
+		"queryRow": func(cb isql.CircuitBreaker) error {
+			var a int
+			return cb.QueryRowContext(ctx, "SELECT a").Scan(&a)
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			db := &flakyDB{err: boom}
+			cb, changes := newTestBreaker(db, 2)
+			for i := 0; i < 2; i++ {
+				if err := call(cb); err != boom {
+					t.Fatalf("error %v, expected %v", err, boom)
+				}
+			}
+			if err := call(cb); err != isql.ErrCircuitOpen || db.queries != 2 {
+				t.Fatalf("error %v after %d queries, expected the open circuit to fail fast", err, db.queries)
+			}
+
+			// a failed trial reopens the circuit
+			time.Sleep(25 * time.Millisecond)
+			if cb.State() != isql.CircuitHalfOpen || !cb.Available() {
+				t.Fatalf("state %v, expected half-open", cb.State())
+			}
+			if err := call(cb); err != boom || cb.State() != isql.CircuitOpen {
+				t.Fatalf("error %v in state %v, expected the failed trial to reopen the circuit", err, cb.State())
+			}
+
+			// every trial must pass to close it
+			time.Sleep(25 * time.Millisecond)
+			db.err = nil
+			if err := call(cb); err != nil || cb.State() != isql.CircuitHalfOpen {
+				t.Fatalf("error %v in state %v, expected one passed trial to keep the circuit half-open", err, cb.State())
+			}
+			if err := call(cb); err != nil || cb.State() != isql.CircuitClosed {
+				t.Fatalf("error %v in state %v, expected two passed trials to close the circuit", err, cb.State())
+			}
+			expected := []string{"open", "half-open", "open", "half-open", "closed"}
+			if !reflect.DeepEqual(*changes, expected) {
+				t.Fatalf("changes %v, expected %v", *changes, expected)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerRowsErr(t *testing.T) {
+	boom := errors.New("boom")
+	db := &flakyDB{rowsErr: boom}
+	cb, _ := newTestBreaker(db, 1)
+	for i := 0; i < 2; i++ {
+		rows, err := cb.QueryContext(context.Background(), "SELECT a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rows.Next() {
+			t.Fatal("expected a row")
+		}
+		if rows.Next() {
+			t.Fatal("expected the second row to fail")
+		}
+		rows.Close()
+	}
+	if cb.State() != isql.CircuitOpen {
+		t.Fatalf("state %v, expected errors reading rows to open the circuit", cb.State())
+	}
+}
+
+func TestCircuitBreakerAbandonedTrial(t *testing.T) {
+	boom := errors.New("boom")
+	ctx := context.Background()
+	db := &flakyDB{err: boom}
+	cb, changes := newTestBreaker(db, 1)
+	for i := 0; i < 2; i++ {
+		cb.ExecContext(ctx, "UPDATE t")
+	}
+	time.Sleep(25 * time.Millisecond)
+	db.err = nil
+
+	// neither trial is finished, a Row which is never scanned and Rows which are never closed
+	cb.QueryRowContext(ctx, "SELECT a")
+	if cb.Available() {
+		t.Fatal("expected the in flight trial to use up the half-open capacity")
+	}
+	if _, err := cb.ExecContext(ctx, "UPDATE t"); err != isql.ErrCircuitOpen {
+		t.Fatalf("error %v, expected %v", err, isql.ErrCircuitOpen)
+	}
+	time.Sleep(25 * time.Millisecond)
+	rows, err := cb.QueryContext(ctx, "SELECT a")
+	if err != nil {
+		t.Fatalf("error %v, expected the abandoned trial to be given up on", err)
+	}
+	defer rows.Close()
+	time.Sleep(25 * time.Millisecond)
+	if _, err := cb.ExecContext(ctx, "UPDATE t"); err != nil || cb.State() != isql.CircuitClosed {
+		t.Fatalf("error %v in state %v, expected a finished trial to close the circuit", err, cb.State())
+	}
+	expected := []string{"open", "half-open", "closed"}
+	if !reflect.DeepEqual(*changes, expected) {
+		t.Fatalf("changes %v, expected %v", *changes, expected)
+	}
+}
+
+func TestCircuitBreakerReplicaSet(t *testing.T) {
+	boom := errors.New("boom")
+	ctx := context.Background()
+	primary, slave := &flakyDB{}, &flakyDB{err: boom}
+	rs := isql.NewCircuitBreakerReplicaSet(isql.NewReplicaSetFromMembers(primary, slave), isql.CircuitBreakerConfig{
+		MinRequests: 2,
+		OpenTimeout: 20 * time.Millisecond,
+	})
+	var a int
+	for i := 0; i < 2; i++ {
+		rs.QueryRowContext(ctx, "SELECT a").Scan(&a)
+	}
+	if err := rs.QueryRowContext(ctx, "SELECT a").Scan(&a); err != nil || primary.queries != 1 {
+		t.Fatalf("error %v, expected the query to fall back to the primary while the slave is open", err)
+	}
+	time.Sleep(25 * time.Millisecond)
+	slave.err = nil
+	row := rs.QueryRowContext(ctx, "SELECT a")
+	if slave.queries != 3 {
+		t.Fatalf("slave ran %d queries, expected the trial to go to the half-open slave", slave.queries)
+	}
+	if err := rs.QueryRowContext(ctx, "SELECT a").Scan(&a); err != nil || primary.queries != 2 {
+		t.Fatalf("error %v, expected the query to skip the slave whose trial is in flight", err)
+	}
+	row.Scan(&a)
+	if err := rs.QueryRowContext(ctx, "SELECT a").Scan(&a); err != nil || slave.queries != 4 {
+		t.Fatalf("error %v, expected the closed slave to be used again", err)
+	}
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/0xor1/isql"
+)
+
+type generator struct {
+	pkg string
+	d   isql.Dialect
+	// describe returns the result columns of the bound query, it is nil when there are only :exec queries.
+	describe func(q *query, bound string, args []string) ([]isql.ColumnType, error)
+	imports  map[string]bool
+}
+
+func (g *generator) generate(queries []*query) ([]byte, error) {
+	g.imports = map[string]bool{
+		"context":               true,
+		"github.com/0xor1/isql": true,
+	}
+	names := map[string]*query{}
+	body := &bytes.Buffer{}
+	for _, q := range queries {
+		if prev, ok := names[q.name]; ok {
+			return nil, fmt.Errorf("%s:%d: query %s is already declared at %s:%d", q.file, q.line, q.name, prev.file, prev.line)
+		}
+		names[q.name] = q
+		if err := g.query(body, q); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %s", q.file, q.line, q.name, err)
+		}
+	}
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	src := &bytes.Buffer{}
+	fmt.Fprintf(src, "// Code generated by isqlgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", g.pkg)
+	// standard library imports first, then the rest, as goimports groups them
+	for _, std := range []bool{true, false} {
+		for _, imp := range imports {
+			if isStd(imp) == std {
+				fmt.Fprintf(src, "\t%q\n", imp)
+			}
+		}
+		fmt.Fprintf(src, "\n")
+	}
+	fmt.Fprintf(src, ")\n")
+	src.Write(body.Bytes())
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %s", err)
+	}
+	return formatted, nil
+}
+
+func (g *generator) query(b *bytes.Buffer, q *query) error {
+	bound, args := bind(q.sql, g.d)
+	constName := lowerFirst(q.name) + "Query"
+	fmt.Fprintf(b, "\nconst %s = %s\n", constName, quote(bound))
+
+	params := make([]string, 0, len(q.params))
+	paramNames := map[string]string{}
+	declared := map[string]string{}
+	for _, p := range q.params {
+		name := paramName(p.name)
+		if name == constName {
+			return fmt.Errorf("parameter %s is named %s in Go which is also the name of the query constant", p.name, name)
+		}
+		if prev, ok := declared[name]; ok {
+			return fmt.Errorf("parameters %s and %s are both named %s in Go", prev, p.name, name)
+		}
+		declared[name] = p.name
+		paramNames[p.name] = name
+		params = append(params, name+" "+p.goType)
+		g.useType(p.goType)
+	}
+	callArgs := make([]string, 0, len(args))
+	for _, arg := range args {
+		callArgs = append(callArgs, paramNames[arg])
+	}
+	signature := "ctx context.Context, db isql.DBCore"
+	if len(params) > 0 {
+		signature += ", " + strings.Join(params, ", ")
+	}
+	argList := constName
+	if len(callArgs) > 0 {
+		argList += ", " + strings.Join(callArgs, ", ")
+	}
+
+	if q.kind == kindExec {
+		g.imports["database/sql"] = true
+		fmt.Fprintf(b, "\nfunc %s(%s) (sql.Result, error) {\n", q.name, signature)
+		fmt.Fprintf(b, "\treturn db.ExecContext(ctx, %s)\n}\n", argList)
+		return nil
+	}
+
+	columns, err := g.describe(q, bound, args)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("%s query returns no columns, use :exec", q.kind)
+	}
+	rowType := q.name + "Row"
+	fields := make([]string, len(columns))
+	seen := map[string]int{}
+	fmt.Fprintf(b, "\ntype %s struct {\n", rowType)
+	for i, c := range columns {
+		field := exportedName(c.Name())
+		if field == "" {
+			field = "Column" + strconv.Itoa(i+1)
+		}
+		if n := seen[field]; n > 0 {
+			seen[field] = n + 1
+			field += strconv.Itoa(n + 1)
+		} else {
+			seen[field] = 1
+		}
+		fields[i] = field
+		goType := g.goType(c)
+		fmt.Fprintf(b, "\t%s %s\n", field, goType)
+	}
+	fmt.Fprintf(b, "}\n")
+	dest := make([]string, len(fields))
+	for i, field := range fields {
+		dest[i] = "&r." + field
+	}
+	if q.kind == kindOne {
+		fmt.Fprintf(b, "\nfunc %s(%s) (%s, error) {\n", q.name, signature, rowType)
+		fmt.Fprintf(b, "\tvar r %s\n", rowType)
+		fmt.Fprintf(b, "\terr := db.QueryRowContext(ctx, %s).Scan(%s)\n", argList, strings.Join(dest, ", "))
+		fmt.Fprintf(b, "\treturn r, err\n}\n")
+		return nil
+	}
+	fmt.Fprintf(b, "\nfunc %s(%s) ([]%s, error) {\n", q.name, signature, rowType)
+	fmt.Fprintf(b, "\trows, err := db.QueryContext(ctx, %s)\n", argList)
+	fmt.Fprintf(b, "\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(b, "\tdefer rows.Close()\n")
+	fmt.Fprintf(b, "\tvar res []%s\n", rowType)
+	fmt.Fprintf(b, "\tfor rows.Next() {\n")
+	fmt.Fprintf(b, "\t\tvar r %s\n", rowType)
+	fmt.Fprintf(b, "\t\tif err := rows.Scan(%s); err != nil {\n\t\t\treturn nil, err\n\t\t}\n", strings.Join(dest, ", "))
+	fmt.Fprintf(b, "\t\tres = append(res, r)\n\t}\n")
+	fmt.Fprintf(b, "\treturn res, rows.Err()\n}\n")
+	return nil
+}
+
+func isStd(importPath string) bool {
+	return !strings.Contains(strings.SplitN(importPath, "/", 2)[0], ".")
+}
+
+// useType records the imports needed by a parameter type such as time.Time or []sql.NullString.
+func (g *generator) useType(goType string) {
+	if strings.Contains(goType, "time.") {
+		g.imports["time"] = true
+	}
+	if strings.Contains(goType, "sql.") {
+		g.imports["database/sql"] = true
+	}
+}
+
+var (
+	int64Type   = reflect.TypeOf(int64(0))
+	uint64Type  = reflect.TypeOf(uint64(0))
+	float64Type = reflect.TypeOf(float64(0))
+	boolType    = reflect.TypeOf(false)
+	stringType  = reflect.TypeOf("")
+	bytesType   = reflect.TypeOf([]byte(nil))
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// goType picks the field type for c from its scan type, falling back to its database type name when the driver
+// reports no useful scan type. Columns which are not known to be NOT NULL get a sql.Null* type, or *uint64 as
+// there is no sql.NullUint64.
+func (g *generator) goType(c isql.ColumnType) string {
+	t := g.baseType(c)
+	if nullable, ok := c.Nullable(); ok && !nullable {
+		return g.typeName(t)
+	}
+	null := ""
+	switch t {
+	case int64Type:
+		null = "sql.NullInt64"
+	case float64Type:
+		null = "sql.NullFloat64"
+	case boolType:
+		null = "sql.NullBool"
+	case stringType:
+		null = "sql.NullString"
+	case timeType:
+		null = "sql.NullTime"
+	case uint64Type:
+		return "*uint64"
+	default:
+		// []byte is nil for NULL, interface{} and driver specific types handle NULL themselves
+		return g.typeName(t)
+	}
+	g.imports["database/sql"] = true
+	return null
+}
+
+func (g *generator) baseType(c isql.ColumnType) reflect.Type {
+	dbType := strings.ToUpper(c.DatabaseTypeName())
+	if strings.Contains(dbType, "UNSIGNED") && strings.Contains(dbType, "BIGINT") {
+		// checked first as drivers scan nullable ones as sql.NullInt64, which overflows above math.MaxInt64
+		return uint64Type
+	}
+	if t := c.ScanType(); t != nil && t.Kind() != reflect.Interface {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			return int64Type
+		case reflect.Uint, reflect.Uint64:
+			return uint64Type
+		case reflect.Float32, reflect.Float64:
+			return float64Type
+		case reflect.Bool:
+			return boolType
+		case reflect.String:
+			return stringType
+		case reflect.Slice:
+			if t.Elem().Kind() == reflect.Uint8 {
+				return bytesType
+			}
+		}
+		if t == timeType {
+			return timeType
+		}
+		// driver specific types such as sql.NullInt64 or mysql.NullTime scan as they are
+		if t.PkgPath() != "" {
+			return t
+		}
+	}
+	switch {
+	case isql.IsIntegerType(dbType):
+		return int64Type
+	case strings.Contains(dbType, "FLOAT") || strings.Contains(dbType, "DOUBLE") || dbType == "REAL":
+		return float64Type
+	case strings.Contains(dbType, "BOOL") || dbType == "BIT":
+		return boolType
+	case strings.Contains(dbType, "CHAR") || strings.Contains(dbType, "TEXT") || dbType == "UUID" ||
+		dbType == "DECIMAL" || dbType == "NUMERIC" || dbType == "JSON" || dbType == "JSONB":
+		return stringType
+	case strings.Contains(dbType, "TIME") || dbType == "DATE":
+		return timeType
+	case strings.Contains(dbType, "BLOB") || strings.Contains(dbType, "BINARY") || dbType == "BYTEA":
+		return bytesType
+	}
+	return reflect.TypeOf((*interface{})(nil)).Elem()
+}
+
+func (g *generator) typeName(t reflect.Type) string {
+	if t.Kind() == reflect.Interface && t.NumMethod() == 0 {
+		return "interface{}"
+	}
+	if t.PkgPath() != "" {
+		g.imports[t.PkgPath()] = true
+	}
+	return t.String()
+}
+
+// commonInitialisms are upper cased in generated names, as golint expects.
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true, "GUID": true,
+	"HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "SQL": true, "SSH": true,
+	"TCP": true, "TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "URI": true, "URL": true,
+	"UTF8": true, "UUID": true, "XML": true,
+}
+
+// exportedName converts a column name such as user_id or createdAt to a Go field name, e.g. UserID, CreatedAt.
+func exportedName(name string) string {
+	var words []string
+	word := &strings.Builder{}
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			word.WriteRune(r)
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	b := &strings.Builder{}
+	for _, w := range words {
+		upper := strings.ToUpper(w)
+		if commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		rs := []rune(strings.ToLower(w))
+		rs[0] = unicode.ToUpper(rs[0])
+		b.WriteString(string(rs))
+	}
+	res := b.String()
+	if res != "" && unicode.IsDigit([]rune(res)[0]) {
+		res = "C" + res
+	}
+	return res
+}
+
+// paramName converts a parameter name to a Go identifier, e.g. user_id to userID.
+func paramName(name string) string {
+	exported := exportedName(name)
+	if exported == "" {
+		return "arg"
+	}
+	res := lowerFirst(exported)
+	if isReserved(res) {
+		res += "Arg"
+	}
+	return res
+}
+
+// lowerFirst lower cases the leading word of an exported name, e.g. ID to id, UserID to userID, GetUser to getUser.
+func lowerFirst(name string) string {
+	rs := []rune(name)
+	n := 1
+	for n < len(rs) && unicode.IsUpper(rs[n]) && (n+1 == len(rs) || unicode.IsUpper(rs[n+1])) {
+		n++
+	}
+	for i := 0; i < n; i++ {
+		rs[i] = unicode.ToLower(rs[i])
+	}
+	return string(rs)
+}
+
+func isReserved(name string) bool {
+	switch name {
+	case "break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for", "func",
+		"go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select", "struct",
+		"switch", "type", "var", "ctx", "db", "err", "r", "rows", "res":
+		return true
+	}
+	return false
+}
+
+// quote returns query as a raw string literal when it can be one so the generated SQL stays readable.
+func quote(query string) string {
+	if !strings.Contains(query, "`") {
+		return "`" + query + "`"
+	}
+	return strconv.Quote(query)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/isqltest"
+)
+
+func generateFile(t *testing.T, name, src string) (string, error) {
+	t.Helper()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "q.sql")
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "q.gen.go")
+	if err := run(isqltest.DriverName, name, "postgres", "queries", out, time.Second, []string{file}); err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b), nil
+}
+
+func TestGenerate(t *testing.T) {
+	f := isqltest.NewFake("isqlgen")
+	defer f.Close()
+	f.On(isqltest.Regex(`FROM users WHERE id`)).WillReturnRows([]string{"id", "user_name", "created_at", "ttl", "location", "hits"}).
+		WithColumnTypes("INT8", "TEXT", "TIMESTAMP", "INTERVAL", "POINT", "BIGINT UNSIGNED")
+	src, err := generateFile(t, "isqlgen", `-- name: GetUser :one
+-- param: id int64
+SELECT id, user_name, created_at, ttl, location, hits FROM users WHERE id = :id AND x::text = ':nope' AND y = :id;
+
+-- name: DeleteUser :exec
+-- param: id int64
+-- param: at time.Time
+DELETE FROM users WHERE id = :id AND deleted < :at AND type = :type;
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"func GetUser(ctx context.Context, db isql.DBCore, id int64) (GetUserRow, error)",
+		"WHERE id = $1 AND x::text = ':nope' AND y = $1",
+		"ID        sql.NullInt64",
+		"UserName  sql.NullString",
+		"CreatedAt sql.NullTime",
+		"TTL       interface{}",
+		"Location  interface{}",
+		"Hits      *uint64",
+		"func DeleteUser(ctx context.Context, db isql.DBCore, id int64, at time.Time, typeArg interface{}) (sql.Result, error)",
+	} {
+		if !strings.Contains(src, expected) {
+			t.Errorf("generated code is missing %q\n%s", expected, src)
+		}
+	}
+}
+
+// columnType reports a database type name, scan type and nullability as a driver would.
+type columnType struct {
+	isql.ColumnType
+	name      string
+	scan      reflect.Type
+	nullable  bool
+	nullKnown bool
+}
+
+func (c *columnType) DatabaseTypeName() string {
+	return c.name
+}
+
+func (c *columnType) ScanType() reflect.Type {
+	return c.scan
+}
+
+func (c *columnType) Nullable() (bool, bool) {
+	return c.nullable, c.nullKnown
+}
+
+func TestGoType(t *testing.T) {
+	nullInt64 := reflect.TypeOf(sql.NullInt64{})
+	tests := []struct {
+		c      columnType
+		goType string
+	}{
+		{columnType{name: "BIGINT UNSIGNED", scan: reflect.TypeOf(uint64(0)), nullKnown: true}, "uint64"},
+		// drivers scan nullable unsigned BIGINTs as sql.NullInt64
+		{columnType{name: "BIGINT UNSIGNED", scan: nullInt64, nullable: true, nullKnown: true}, "*uint64"},
+		{columnType{name: "UNSIGNED BIGINT"}, "*uint64"},
+		{columnType{scan: reflect.TypeOf(uint(0)), nullKnown: true}, "uint64"},
+		{columnType{name: "INT UNSIGNED", scan: reflect.TypeOf(uint32(0)), nullKnown: true}, "int64"},
+		{columnType{name: "BIGINT", scan: nullInt64, nullable: true, nullKnown: true}, "sql.NullInt64"},
+	}
+	for _, tt := range tests {
+		g := &generator{imports: map[string]bool{}}
+		if goType := g.goType(&tt.c); goType != tt.goType {
+			t.Errorf("%s %v: type %s, expected %s", tt.c.name, tt.c.scan, goType, tt.goType)
+		}
+	}
+}
+
+func TestGenerateNameClash(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{"params", "-- name: DeleteUser :exec\nDELETE FROM users WHERE user_id = :user_id OR id = :userId;\n",
+			"q.sql:1: DeleteUser: parameters user_id and userId are both named userID in Go"},
+		{"const", "-- name: DeleteUser :exec\nDELETE FROM users WHERE id = :id OR q = :delete_user_query;\n",
+			"q.sql:1: DeleteUser: parameter delete_user_query is named deleteUserQuery in Go which is also the name of the query constant"},
+		{"query", "-- name: DeleteUser :exec\nDELETE FROM users;\n-- name: DeleteUser :exec\nDELETE FROM posts;\n",
+			"q.sql:3: query DeleteUser is already declared at"},
+	}
+	for _, tt := range tests {
+		_, err := generateFile(t, "", tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error %v, expected %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		query string
+		d     isql.Dialect
+		bound string
+		args  string
+	}{
+		{"a = :a AND b = :b AND c = :a", isql.MySQL, "a = ? AND b = ? AND c = ?", "a,b,a"},
+		{"a = :a AND b = :b AND c = :a", isql.Postgres, "a = $1 AND b = $2 AND c = $1", "a,b"},
+		{"a::int = :a -- :b", isql.Postgres, "a::int = $1 -- :b", "a"},
+	}
+	for _, tt := range tests {
+		bound, args := bind(tt.query, tt.d)
+		if bound != tt.bound || strings.Join(args, ",") != tt.args {
+			t.Errorf("%s: bound %q with args %q, expected %q with %q", tt.query, bound, args, tt.bound, tt.args)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		exported string
+		param    string
+	}{
+		{"user_id", "UserID", "userID"},
+		{"createdAt", "CreatedAt", "createdAt"},
+		{"URL", "URL", "url"},
+		{"2fa", "C2fa", "c2fa"},
+		{"type", "Type", "typeArg"},
+		{"err", "Err", "errArg"},
+	}
+	for _, tt := range tests {
+		if exported, param := exportedName(tt.name), paramName(tt.name); exported != tt.exported || param != tt.param {
+			t.Errorf("%s: names %s and %s, expected %s and %s", tt.name, exported, param, tt.exported, tt.param)
+		}
+	}
+}
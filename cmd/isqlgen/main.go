@@ -0,0 +1,144 @@
+// Command isqlgen generates typed Go functions from annotated SQL files.
+//
+// Each query in a .sql file starts with a "-- name: <Name> <:one|:many|:exec>" line, parameters are written
+// as :name and typed with "-- param: <name> <go type>" lines, e.g.
+//
+//	-- name: GetUser :one
+//	-- param: id int64
+//	SELECT id, name, email FROM users WHERE id = :id;
+//
+// generates a GetUserRow struct and
+//
+//	func GetUser(ctx context.Context, db isql.DBCore, id int64) (GetUserRow, error)
+//
+// :many queries return a slice of rows and :exec queries return the sql.Result. Result columns and their types
+// are read by running every :one and :many query with NULL arguments in a rolled back transaction against the
+// database given by -driver and -dsn, so it should be a local copy of the schema. As the generated functions
+// take an isql.DBCore they can be given a mock.MockDBCore in tests.
+//
+// Database drivers are compiled in with build tags, one of postgres, mysql, sqlite or sqlserver. The drivers are
+// not required by the isql module, so the one a tag needs must be added to the module isqlgen is built in first:
+//
+//	postgres   go get github.com/lib/pq
+//	mysql      go get github.com/go-sql-driver/mysql
+//	sqlite     go get github.com/mattn/go-sqlite3 (needs cgo)
+//	sqlserver  go get github.com/microsoft/go-mssqldb
+//
+// then e.g. go build -tags postgres github.com/0xor1/isql/cmd/isqlgen.
+//
+// Usage:
+//
+//	isqlgen -driver postgres -dsn "postgres://localhost/app?sslmode=disable" -dialect postgres -pkg queries -out queries.gen.go queries/*.sql
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+var dialects = map[string]isql.Dialect{
+	"postgres":  isql.Postgres,
+	"mysql":     isql.MySQL,
+	"sqlite":    isql.SQLite,
+	"sqlite3":   isql.SQLite,
+	"sqlserver": isql.SQLServer,
+}
+
+func main() {
+	driverName := flag.String("driver", "", "database/sql driver name used to read result column types")
+	dsn := flag.String("dsn", "", "data source name of the database used to read result column types")
+	dialectName := flag.String("dialect", "", "placeholder dialect, one of postgres, mysql, sqlite or sqlserver, defaults to -driver")
+	pkg := flag.String("pkg", "queries", "package name of the generated file")
+	out := flag.String("out", "queries.gen.go", "generated file, - for stdout")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout for reading column types")
+	flag.Parse()
+	if err := run(*driverName, *dsn, *dialectName, *pkg, *out, *timeout, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "isqlgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(driverName, dsn, dialectName, pkg, out string, timeout time.Duration, files []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no .sql files given")
+	}
+	if dialectName == "" {
+		dialectName = driverName
+	}
+	d, ok := dialects[dialectName]
+	if !ok {
+		return fmt.Errorf("unknown dialect %q", dialectName)
+	}
+	var queries []*query
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		qs, err := parse(file, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		queries = append(queries, qs...)
+	}
+	g := &generator{
+		pkg: pkg,
+		d:   d,
+	}
+	if needsColumns(queries) {
+		if driverName == "" || dsn == "" {
+			return fmt.Errorf("-driver and -dsn are required to read the columns of :one and :many queries")
+		}
+		db, err := isql.NewOpener().Open(driverName, dsn)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		g.describe = func(q *query, bound string, args []string) ([]isql.ColumnType, error) {
+			return describe(ctx, db, bound, len(args))
+		}
+	}
+	src, err := g.generate(queries)
+	if err != nil {
+		return err
+	}
+	if out == "-" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0644)
+}
+
+func needsColumns(queries []*query) bool {
+	for _, q := range queries {
+		if q.kind != kindExec {
+			return true
+		}
+	}
+	return false
+}
+
+// describe runs query with NULL arguments in a transaction which is always rolled back and returns its result
+// columns, the query is executed but NULL arguments typically match nothing.
+func describe(ctx context.Context, db isql.DB, query string, argCount int) ([]isql.ColumnType, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	args := make([]interface{}, argCount)
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rows.ColumnTypes()
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/0xor1/isql"
+)
+
+type kind string
+
+const (
+	kindOne  kind = ":one"
+	kindMany kind = ":many"
+	kindExec kind = ":exec"
+)
+
+type param struct {
+	name   string
+	goType string
+}
+
+type query struct {
+	file string
+	line int
+	name string
+	kind kind
+	// sql is the query as written with :name parameters.
+	sql string
+	// params are the distinct named parameters in order of first use.
+	params []param
+}
+
+var (
+	nameAnnotation  = regexp.MustCompile(`^--\s*name:\s*([A-Za-z_][A-Za-z0-9_]*)\s+(:one|:many|:exec)\s*$`)
+	paramAnnotation = regexp.MustCompile(`^--\s*param:\s*([A-Za-z_][A-Za-z0-9_]*)\s+(\S.*?)\s*$`)
+)
+
+// parse reads every annotated query in r, a query runs from its "-- name: X :kind" line to the next one or the
+// end of the file and "-- param: name type" lines within it type its parameters, untyped ones are interface{}.
+func parse(file string, r io.Reader) ([]*query, error) {
+	var queries []*query
+	var current *query
+	var body []string
+	paramTypes := map[string]string{}
+	finish := func() error {
+		if current == nil {
+			return nil
+		}
+		current.sql = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(strings.Join(body, "\n")), ";"))
+		if current.sql == "" {
+			return fmt.Errorf("%s:%d: query %s is empty", file, current.line, current.name)
+		}
+		for _, name := range namedParams(current.sql) {
+			goType, ok := paramTypes[name]
+			if !ok {
+				goType = "interface{}"
+			}
+			current.params = append(current.params, param{
+				name:   name,
+				goType: goType,
+			})
+		}
+		queries = append(queries, current)
+		return nil
+	}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if m := nameAnnotation.FindStringSubmatch(trimmed); m != nil {
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			current = &query{
+				file: file,
+				line: lineNo,
+				name: m[1],
+				kind: kind(m[2]),
+			}
+			body = nil
+			paramTypes = map[string]string{}
+			continue
+		}
+		if m := paramAnnotation.FindStringSubmatch(trimmed); m != nil {
+			paramTypes[m[1]] = m[2]
+			continue
+		}
+		if current != nil {
+			body = append(body, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	return queries, nil
+}
+
+// namedParams returns the distinct :name parameters in query in order of first use.
+func namedParams(query string) []string {
+	var names []string
+	seen := map[string]bool{}
+	walkNamed(query, func(name string) string {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		return ""
+	})
+	return names
+}
+
+// bind rewrites :name parameters to d's placeholders, returning the names in argument order. Numbered
+// placeholders are reused for repeated names, with ? each use is a separate argument.
+func bind(query string, d isql.Dialect) (string, []string) {
+	numbered := d.Placeholder(1) != d.Placeholder(2)
+	var args []string
+	index := map[string]int{}
+	bound := walkNamed(query, func(name string) string {
+		if numbered {
+			if n, ok := index[name]; ok {
+				return d.Placeholder(n)
+			}
+		}
+		args = append(args, name)
+		index[name] = len(args)
+		return d.Placeholder(len(args))
+	})
+	return bound, args
+}
+
+// walkNamed calls fn for every :name parameter in query outside quotes and comments, replacing it with the
+// returned string, Postgres :: casts are left alone.
+func walkNamed(query string, fn func(name string) string) string {
+	b := &strings.Builder{}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 1
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+end])
+			i += end - 1
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			b.WriteString("::")
+			i++
+		case c == ':' && i+1 < len(query) && isIdentStart(query[i+1]) && (i == 0 || !isIdentPart(query[i-1])):
+			j := i + 1
+			for j < len(query) && isIdentPart(query[j]) {
+				j++
+			}
+			b.WriteString(fn(query[i+1 : j]))
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
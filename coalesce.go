@@ -0,0 +1,95 @@
+package isql
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+)
+
+type CoalescerStats struct {
+	// Executions counts reads run against the underlying DBCore.
+	Executions int64
+	// Shared counts reads which were served by an execution started by another caller.
+	Shared int64
+}
+
+type Coalescer interface {
+	DBCore
+	Stats() CoalescerStats
+}
+
+// NewCoalescer returns a DBCore on which concurrent identical reads, the same query with the same args, share one
+// execution against db. Results are materialized and every caller gets its own replay of them, each caller's
+// context only cancels its own wait, the shared execution is cancelled once every caller waiting on it has gone.
+// Args are compared by their driver value, a read with an arg which has no driver value runs on its own. ExecContext
+// is passed straight through.
+func NewCoalescer(db DBCore) Coalescer {
+	return &coalescer{
+		db: db,
+	}
+}
+
+type coalescer struct {
+	db         DBCore
+	flights    flightGroup
+	executions int64
+	shared     int64
+}
+
+func (c *coalescer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.db.ExecContext(ctx, query, args...)
+}
+
+func (c *coalescer) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	sets, ok, err := c.read(ctx, query, args)
+	if !ok {
+		return c.db.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewDataRows(sets...)
+}
+
+func (c *coalescer) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	sets, ok, err := c.read(ctx, query, args)
+	if !ok {
+		return c.db.QueryRowContext(ctx, query, args...)
+	}
+	if err != nil {
+		return &errRow{
+			err: err,
+		}
+	}
+	if len(sets) == 0 {
+		return NewDataRow(ResultSet{})
+	}
+	return NewDataRow(sets[0])
+}
+
+func (c *coalescer) Stats() CoalescerStats {
+	return CoalescerStats{
+		Executions: atomic.LoadInt64(&c.executions),
+		Shared:     atomic.LoadInt64(&c.shared),
+	}
+}
+
+// read returns ok false if an arg has no driver value to key the read by, such a read is run on its own.
+func (c *coalescer) read(ctx context.Context, query string, args []interface{}) ([]ResultSet, bool, error) {
+	key, ok := queryKey(query, args)
+	if !ok {
+		return nil, false, nil
+	}
+	sets, shared, err := c.flights.do(ctx, key, func(ctx context.Context) ([]ResultSet, error) {
+		atomic.AddInt64(&c.executions, 1)
+		rows, err := c.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		return ReadResultSets(rows)
+	})
+	if shared {
+		atomic.AddInt64(&c.shared, 1)
+	}
+	return sets, true, err
+}
@@ -0,0 +1,211 @@
+package isql_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+type ctxKey struct{}
+
+// gateDB blocks every read until release is closed or the read's context is done, recording the read's context.
+type gateDB struct {
+	mtx     sync.Mutex
+	ctxs    []context.Context
+	started chan struct{}
+	release chan struct{}
+	err     error
+}
+
+func newGateDB() *gateDB {
+	return &gateDB{
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func (g *gateDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return isql.NewResult(0, 1), nil
+}
+
+func (g *gateDB) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	g.mtx.Lock()
+	g.ctxs = append(g.ctxs, ctx)
+	g.mtx.Unlock()
+	g.started <- struct{}{}
+	select {
+	case <-g.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+	return isql.NewDataRows(isql.ResultSet{Columns: []string{"a"}, Rows: [][]interface{}{{1}, {2}}})
+}
+
+func (g *gateDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	return isql.NewDataRow(isql.ResultSet{})
+}
+
+func (g *gateDB) ctx(i int) context.Context {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	return g.ctxs[i]
+}
+
+// settle gives callers started concurrently time to join the execution in flight, the coalescer only counts them
+// once they return.
+func settle() {
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestCoalescer(t *testing.T) {
+	db := newGateDB()
+	c := isql.NewCoalescer(db)
+	// the shared execution keeps the first caller's values but none of its deadline or cancellation
+	first, cancelFirst := context.WithTimeout(context.WithValue(context.Background(), ctxKey{}, "v"), time.Minute)
+	defer cancelFirst()
+	type result struct {
+		sum int
+		err error
+	}
+	results := make(chan result)
+	read := func(ctx context.Context) {
+		rows, err := c.QueryContext(ctx, "SELECT a FROM t WHERE id = ?", 1)
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer rows.Close()
+		sum := 0
+		for rows.Next() {
+			var a int
+			rows.Scan(&a)
+			sum += a
+		}
+		results <- result{sum: sum, err: rows.Err()}
+	}
+	go read(first)
+	<-db.started
+	for i := 0; i < 3; i++ {
+		go read(context.Background())
+	}
+	settle()
+	execCtx := db.ctx(0)
+	if _, ok := execCtx.Deadline(); ok || execCtx.Value(ctxKey{}) != "v" {
+		t.Fatal("expected the execution context to keep the first caller's values without its deadline")
+	}
+
+	cancelFirst()
+	if r := <-results; r.err != context.Canceled {
+		t.Fatalf("error %v, expected the first caller to be cancelled", r.err)
+	}
+	if execCtx.Err() != nil {
+		t.Fatal("expected the execution to continue while other callers wait on it")
+	}
+	close(db.release)
+	for i := 0; i < 3; i++ {
+		// every caller gets its own replay of both rows
+		if r := <-results; r.err != nil || r.sum != 3 {
+			t.Fatalf("read %d with error %v", r.sum, r.err)
+		}
+	}
+	if s := c.Stats(); s.Executions != 1 || s.Shared != 3 {
+		t.Fatalf("stats %+v", s)
+	}
+}
+
+func TestCoalescerAllCancelled(t *testing.T) {
+	db := newGateDB()
+	c := isql.NewCoalescer(db)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	for i := 0; i < 2; i++ {
+		go func() {
+			errs <- c.QueryRowContext(ctx, "SELECT a").Scan(new(int))
+		}()
+	}
+	<-db.started
+	settle()
+	cancel()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != context.Canceled {
+			t.Fatalf("error %v, expected %v", err, context.Canceled)
+		}
+	}
+	execCtx := db.ctx(0)
+	<-execCtx.Done()
+
+	// a later read must not join the cancelled execution
+	close(db.release)
+	var a int
+	if err := c.QueryRowContext(context.Background(), "SELECT a").Scan(&a); err != nil || a != 1 {
+		t.Fatalf("read %d with error %v", a, err)
+	}
+	if s := c.Stats(); s.Executions != 2 {
+		t.Fatalf("stats %+v, expected a new execution", s)
+	}
+}
+
+func TestCoalescerError(t *testing.T) {
+	db := newGateDB()
+	db.err = errors.New("boom")
+	c := isql.NewCoalescer(db)
+	errs := make(chan error)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := c.QueryContext(context.Background(), "SELECT a", sql.Named("id", 1))
+			errs <- err
+		}()
+	}
+	<-db.started
+	settle()
+	close(db.release)
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != db.err {
+			t.Fatalf("error %v, expected every caller to get %v", err, db.err)
+		}
+	}
+	if _, err := c.ExecContext(context.Background(), "UPDATE t"); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.Stats(); s.Executions != 1 || s.Shared != 1 {
+		t.Fatalf("stats %+v", s)
+	}
+}
+
+func TestCoalescerArgs(t *testing.T) {
+	db := newGateDB()
+	c := isql.NewCoalescer(db)
+	id := 1
+	errs := make(chan error)
+	read := func(arg interface{}) {
+		go func() {
+			rows, err := c.QueryContext(context.Background(), "SELECT a WHERE id = ?", arg)
+			if err == nil {
+				rows.Close()
+			}
+			errs <- err
+		}()
+		<-db.started
+	}
+	read(&id)
+	other := 2
+	read(&other)
+	read(struct{ id int }{1})
+	close(db.release)
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := c.Stats(); s.Executions != 2 || s.Shared != 0 {
+		t.Fatalf("stats %+v, expected pointers to different values not to share and an unkeyable arg to run on its own", s)
+	}
+}
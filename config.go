@@ -0,0 +1,176 @@
+package isql
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interceptor wraps the connector a DB is opened with, e.g. to add tracing or to rewrite connections.
+type Interceptor func(driver.Connector) driver.Connector
+
+// WithInterceptors wraps the connector of every DB opened by an Opener in each interceptor in order, so the last
+// one given is the outermost. It only applies to DBs opened by an Opener.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(o *options) {
+		o.interceptors = append(o.interceptors, interceptors...)
+	}
+}
+
+// Config describes a pool to open with Opener.OpenConfig. Zero values leave the database/sql defaults in place,
+// to retain no idle connections set MaxIdle to a negative value.
+type Config struct {
+	Driver          string
+	DSN             string
+	MaxOpen         int
+	MaxIdle         int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	PingOnOpen      bool
+	Interceptors    []Interceptor
+}
+
+type configJSON struct {
+	Driver          string `json:"driver"`
+	DSN             string `json:"dsn"`
+	MaxOpen         int    `json:"maxOpen"`
+	MaxIdle         int    `json:"maxIdle"`
+	ConnMaxLifetime string `json:"connMaxLifetime"`
+	ConnMaxIdleTime string `json:"connMaxIdleTime"`
+	PingOnOpen      bool   `json:"pingOnOpen"`
+}
+
+// MarshalJSON writes durations as strings in time.Duration format, Interceptors are not written.
+func (c Config) MarshalJSON() ([]byte, error) {
+	cj := configJSON{
+		Driver:     c.Driver,
+		DSN:        c.DSN,
+		MaxOpen:    c.MaxOpen,
+		MaxIdle:    c.MaxIdle,
+		PingOnOpen: c.PingOnOpen,
+	}
+	if c.ConnMaxLifetime != 0 {
+		cj.ConnMaxLifetime = c.ConnMaxLifetime.String()
+	}
+	if c.ConnMaxIdleTime != 0 {
+		cj.ConnMaxIdleTime = c.ConnMaxIdleTime.String()
+	}
+	return json.Marshal(&cj)
+}
+
+// UnmarshalJSON reads durations as strings in time.Duration format, e.g. "5m".
+func (c *Config) UnmarshalJSON(data []byte) error {
+	cj := configJSON{}
+	if err := json.Unmarshal(data, &cj); err != nil {
+		return err
+	}
+	connMaxLifetime, err := parseConfigDuration("connMaxLifetime", cj.ConnMaxLifetime)
+	if err != nil {
+		return err
+	}
+	connMaxIdleTime, err := parseConfigDuration("connMaxIdleTime", cj.ConnMaxIdleTime)
+	if err != nil {
+		return err
+	}
+	c.Driver = cj.Driver
+	c.DSN = cj.DSN
+	c.MaxOpen = cj.MaxOpen
+	c.MaxIdle = cj.MaxIdle
+	c.ConnMaxLifetime = connMaxLifetime
+	c.ConnMaxIdleTime = connMaxIdleTime
+	c.PingOnOpen = cj.PingOnOpen
+	return nil
+}
+
+// LoadConfig reads a JSON encoded Config from the file at path.
+func LoadConfig(path string) (Config, error) {
+	cfg := Config{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("isql: config %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// ConfigFromEnv reads a Config from environment variables named prefix followed by DRIVER, DSN, MAX_OPEN,
+// MAX_IDLE, CONN_MAX_LIFETIME, CONN_MAX_IDLE_TIME and PING_ON_OPEN, e.g. with prefix "APP_DB_" the DSN is read
+// from APP_DB_DSN. Unset variables are left at their zero values.
+func ConfigFromEnv(prefix string) (Config, error) {
+	cfg := Config{
+		Driver: os.Getenv(prefix + "DRIVER"),
+		DSN:    os.Getenv(prefix + "DSN"),
+	}
+	var err error
+	if cfg.MaxOpen, err = envInt(prefix + "MAX_OPEN"); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxIdle, err = envInt(prefix + "MAX_IDLE"); err != nil {
+		return cfg, err
+	}
+	name := prefix + "CONN_MAX_LIFETIME"
+	if cfg.ConnMaxLifetime, err = parseConfigDuration(name, os.Getenv(name)); err != nil {
+		return cfg, err
+	}
+	name = prefix + "CONN_MAX_IDLE_TIME"
+	if cfg.ConnMaxIdleTime, err = parseConfigDuration(name, os.Getenv(name)); err != nil {
+		return cfg, err
+	}
+	name = prefix + "PING_ON_OPEN"
+	if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+		if cfg.PingOnOpen, err = strconv.ParseBool(v); err != nil {
+			return cfg, fmt.Errorf("isql: config %s: %v", name, err)
+		}
+	}
+	return cfg, nil
+}
+
+func (c *Config) apply(db DB) error {
+	if c.MaxOpen != 0 {
+		db.SetMaxOpenConns(c.MaxOpen)
+	}
+	if c.MaxIdle != 0 {
+		db.SetMaxIdleConns(c.MaxIdle)
+	}
+	if c.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+	if c.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	}
+	if c.PingOnOpen {
+		return db.Ping()
+	}
+	return nil
+}
+
+func envInt(name string) (int, error) {
+	v := strings.TrimSpace(os.Getenv(name))
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("isql: config %s: %v", name, err)
+	}
+	return n, nil
+}
+
+func parseConfigDuration(name, v string) (time.Duration, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("isql: config %s: %v", name, err)
+	}
+	return d, nil
+}
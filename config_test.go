@@ -0,0 +1,111 @@
+package isql_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/isqltest"
+)
+
+type countingConnector struct {
+	driver.Connector
+	name  string
+	calls *[]string
+}
+
+func (c *countingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	*c.calls = append(*c.calls, c.name)
+	return c.Connector.Connect(ctx)
+}
+
+func TestOpenConfig(t *testing.T) {
+	f := isqltest.NewFake("open_config")
+	defer f.Close()
+	var calls []string
+	intercept := func(name string) isql.Interceptor {
+		return func(c driver.Connector) driver.Connector {
+			return &countingConnector{Connector: c, name: name, calls: &calls}
+		}
+	}
+	path := filepath.Join(t.TempDir(), "db.json")
+	if err := os.WriteFile(path, []byte(`{"driver":"isqlfake","dsn":"open_config","maxOpen":3,"connMaxLifetime":"5m","pingOnOpen":true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := isql.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := isql.Config{Driver: "isqlfake", DSN: "open_config", MaxOpen: 3, ConnMaxLifetime: 5 * time.Minute, PingOnOpen: true}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Fatalf("loaded %+v, expected %+v", cfg, expected)
+	}
+	cfg.Interceptors = []isql.Interceptor{intercept("config")}
+	db, err := isql.NewOpener(isql.WithInterceptors(intercept("opener"))).OpenConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// the ping on open connects once through every interceptor, the config's are applied last so are outermost
+	if !reflect.DeepEqual(calls, []string{"config", "opener"}) {
+		t.Fatalf("interceptors called %v", calls)
+	}
+	if db.Stats().MaxOpenConnections != 3 {
+		t.Fatalf("max open %d", db.Stats().MaxOpenConnections)
+	}
+	if f.Connects() != 1 {
+		t.Fatalf("connects %d", f.Connects())
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip := isql.Config{}
+	if err := json.Unmarshal(data, &roundTrip); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(roundTrip, expected) {
+		t.Fatalf("round tripped %+v, expected %+v", roundTrip, expected)
+	}
+}
+
+func TestOpenConfigPingFails(t *testing.T) {
+	if _, err := isql.NewOpener().OpenConfig(isql.Config{Driver: isqltest.DriverName, DSN: "missing", PingOnOpen: true}); err == nil {
+		t.Fatal("expected the ping on open to fail")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		env      map[string]string
+		expected isql.Config
+		err      bool
+	}{
+		{map[string]string{"DRIVER": "pg", "DSN": "x", "MAX_OPEN": "4", "MAX_IDLE": "-1", "CONN_MAX_IDLE_TIME": "1m", "PING_ON_OPEN": "true"},
+			isql.Config{Driver: "pg", DSN: "x", MaxOpen: 4, MaxIdle: -1, ConnMaxIdleTime: time.Minute, PingOnOpen: true}, false},
+		{map[string]string{"DRIVER": "pg"}, isql.Config{Driver: "pg"}, false},
+		{map[string]string{"MAX_OPEN": "many"}, isql.Config{}, true},
+		{map[string]string{"CONN_MAX_LIFETIME": "bad"}, isql.Config{}, true},
+		{map[string]string{"PING_ON_OPEN": "maybe"}, isql.Config{}, true},
+	}
+	for i, tt := range tests {
+		for _, name := range []string{"DRIVER", "DSN", "MAX_OPEN", "MAX_IDLE", "CONN_MAX_LIFETIME", "CONN_MAX_IDLE_TIME", "PING_ON_OPEN"} {
+			t.Setenv("TEST_DB_"+name, tt.env[name])
+		}
+		cfg, err := isql.ConfigFromEnv("TEST_DB_")
+		if (err != nil) != tt.err {
+			t.Errorf("case %d: error %v", i, err)
+			continue
+		}
+		if !tt.err && !reflect.DeepEqual(cfg, tt.expected) {
+			t.Errorf("case %d: read %+v, expected %+v", i, cfg, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package isql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+// WithOnConnect runs fn on every new physical connection before it is used, making it the place to set connection
+// pinned session state, e.g. SET search_path or PRAGMA foreign_keys=ON. If fn returns an error the connection is
+// closed and the error returned to the caller which needed it. It only applies to DBs opened by an Opener.
+func WithOnConnect(fn OnConnectFunc) Option {
+	return func(o *options) {
+		o.onConnect = append(o.onConnect, fn)
+	}
+}
+
+type OnConnectFunc func(ctx context.Context, conn Conn) error
+
+// ExecOnConnect returns an OnConnectFunc which executes each query in order.
+func ExecOnConnect(queries ...string) OnConnectFunc {
+	return func(ctx context.Context, conn Conn) error {
+		for _, query := range queries {
+			if _, err := conn.ExecContext(ctx, query); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Conn is a new physical connection which has not yet been added to the pool.
+type Conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (driver.Result, error)
+	Raw() driver.Conn
+}
+
+// driverConnector returns the connector sql.Open would use for driverName and dataSourceName.
+func driverConnector(driverName, dataSourceName string) (driver.Connector, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	db.Close()
+	if dc, ok := drv.(driver.DriverContext); ok {
+		return dc.OpenConnector(dataSourceName)
+	}
+	return &dsnConnector{
+		driver:         drv,
+		dataSourceName: dataSourceName,
+	}, nil
+}
+
+type dsnConnector struct {
+	driver         driver.Driver
+	dataSourceName string
+}
+
+func (c *dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dataSourceName)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type hookConnector struct {
+	connector driver.Connector
+	onConnect []OnConnectFunc
+}
+
+func (c *hookConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	raw, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn := &connWrapper{
+		conn: raw,
+	}
+	for _, fn := range c.onConnect {
+		if err := fn(ctx, conn); err != nil {
+			raw.Close()
+			return nil, err
+		}
+	}
+	return raw, nil
+}
+
+func (c *hookConnector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+type connWrapper struct {
+	conn driver.Conn
+}
+
+func (c *connWrapper) Raw() driver.Conn {
+	return c.conn
+}
+
+func (c *connWrapper) ExecContext(ctx context.Context, query string, args ...interface{}) (driver.Result, error) {
+	namedArgs := make([]driver.NamedValue, 0, len(args))
+	for i, arg := range args {
+		v, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			return nil, err
+		}
+		namedArgs = append(namedArgs, driver.NamedValue{Ordinal: i + 1, Value: v})
+	}
+	if execer, ok := c.conn.(driver.ExecerContext); ok {
+		res, err := execer.ExecContext(ctx, query, namedArgs)
+		if err != driver.ErrSkip {
+			return res, err
+		}
+	}
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	if execer, ok := stmt.(driver.StmtExecContext); ok {
+		return execer.ExecContext(ctx, namedArgs)
+	}
+	values := make([]driver.Value, 0, len(namedArgs))
+	for _, arg := range namedArgs {
+		values = append(values, arg.Value)
+	}
+	if n := stmt.NumInput(); n >= 0 && n != len(values) {
+		return nil, errors.New("isql: on connect query expects a different number of args")
+	}
+	return stmt.Exec(values)
+}
@@ -0,0 +1,103 @@
+package isql_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/isqltest"
+)
+
+func TestOnConnect(t *testing.T) {
+	f := isqltest.NewFake("on_connect")
+	defer f.Close()
+	f.On(isqltest.Regex(``)).WillReturnResult(0, 0)
+	conns := 0
+	db, err := isql.NewOpener(
+		isql.WithOnConnect(isql.ExecOnConnect("SET search_path = app", "SET timezone = 'UTC'")),
+		isql.WithOnConnect(func(ctx context.Context, conn isql.Conn) error {
+			conns++
+			_, err := conn.ExecContext(ctx, "SELECT set_config($1, $2, false)", "app.user", 7)
+			return err
+		}),
+	).Open(isqltest.DriverName, "on_connect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the tx holds the first connection so this needs a second one
+	if _, err := db.ExecContext(ctx, "UPDATE a"); err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+	if _, err := db.ExecContext(ctx, "UPDATE b"); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"SET search_path = app", "SET timezone = 'UTC'", "SELECT set_config($1, $2, false)", "BEGIN",
+		"SET search_path = app", "SET timezone = 'UTC'", "SELECT set_config($1, $2, false)", "UPDATE a",
+		"COMMIT", "UPDATE b",
+	}
+	if queries := f.Queries(); !reflect.DeepEqual(queries, expected) {
+		t.Fatalf("queries %q, expected %q", queries, expected)
+	}
+	if conns != 2 {
+		t.Fatalf("hook ran on %d connections, expected 2", conns)
+	}
+}
+
+func TestOnConnectError(t *testing.T) {
+	f := isqltest.NewFake("on_connect_error")
+	defer f.Close()
+	boom := errors.New("boom")
+	f.On(isqltest.Exact("SET role = app")).WillReturnError(boom)
+	f.On(isqltest.Regex(``)).WillReturnResult(0, 0)
+	db, err := isql.NewOpener(isql.WithOnConnect(isql.ExecOnConnect("SET role = app"))).Open(isqltest.DriverName, "on_connect_error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(context.Background(), "UPDATE a"); !errors.Is(err, boom) {
+		t.Fatalf("error %v, expected the hook error", err)
+	}
+	if queries := f.Queries(); !reflect.DeepEqual(queries, []string{"SET role = app"}) {
+		t.Fatalf("queries %q, the query must not run on a connection whose hook failed", queries)
+	}
+}
+
+func TestOnConnectReplicaSet(t *testing.T) {
+	primary, slave := isqltest.NewFake("on_connect_primary"), isqltest.NewFake("on_connect_slave")
+	defer primary.Close()
+	defer slave.Close()
+	primary.On(isqltest.Regex(``)).WillReturnResult(0, 0)
+	slave.On(isqltest.Regex(``)).WillReturnRows([]string{"a"})
+	rs, err := isql.NewReplicaSetFromOpeners(
+		isql.NewOpener(isql.WithOnConnect(isql.ExecOnConnect("SET primary"))),
+		isql.NewOpener(isql.WithOnConnect(isql.ExecOnConnect("SET slave"))),
+		isqltest.DriverName, "on_connect_primary", "on_connect_slave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := rs.ExecContext(ctx, "UPDATE a"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := rs.QueryContext(ctx, "SELECT a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+	if queries := primary.Queries(); !reflect.DeepEqual(queries, []string{"SET primary", "UPDATE a"}) {
+		t.Fatalf("primary queries %q", queries)
+	}
+	if queries := slave.Queries(); !reflect.DeepEqual(queries, []string{"SET slave", "SELECT a"}) {
+		t.Fatalf("slave queries %q", queries)
+	}
+}
@@ -6,13 +6,38 @@ import (
 	"database/sql/driver"
 	"math/rand"
 	"reflect"
+	"sync"
 	"time"
 )
 
+type options struct {
+	stmtCacheSize int
+	onConnect     []OnConnectFunc
+	interceptors  []Interceptor
+	timeouts      Timeouts
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
 type opener struct {
+	opts []Option
 }
 
 func (o *opener) Open(driverName, dataSourceName string) (DB, error) {
+	opts := newOptions(o.opts)
+	if len(opts.onConnect) > 0 || len(opts.interceptors) > 0 {
+		connector, err := driverConnector(driverName, dataSourceName)
+		if err != nil {
+			return nil, err
+		}
+		return openConnector(connector, opts), nil
+	}
 	db, err := sql.Open(driverName, dataSourceName)
 	if err != nil {
 		if db != nil {
@@ -20,37 +45,118 @@ func (o *opener) Open(driverName, dataSourceName string) (DB, error) {
 		}
 		return nil, err
 	}
-	return NewDB(db), nil
+	return newDB(db, opts), nil
+}
+
+func (o *opener) OpenConnector(connector driver.Connector) (DB, error) {
+	return openConnector(connector, newOptions(o.opts)), nil
+}
+
+func (o *opener) OpenConfig(cfg Config) (DB, error) {
+	opts := make([]Option, 0, len(o.opts)+1)
+	opts = append(opts, o.opts...)
+	opts = append(opts, WithInterceptors(cfg.Interceptors...))
+	op := &opener{
+		opts: opts,
+	}
+	db, err := op.Open(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.apply(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func openConnector(connector driver.Connector, opts *options) DB {
+	if len(opts.onConnect) > 0 {
+		connector = &hookConnector{
+			connector: connector,
+			onConnect: opts.onConnect,
+		}
+	}
+	for _, interceptor := range opts.interceptors {
+		connector = interceptor(connector)
+	}
+	return newDB(sql.OpenDB(connector), opts)
+}
+
+func newDB(db *sql.DB, opts *options) DB {
+	d := &dbWrapper{
+		db:       db,
+		timeouts: opts.timeouts,
+	}
+	if opts.stmtCacheSize > 0 {
+		d.stmts = newStmtCache(db, opts.stmtCacheSize)
+	}
+	return d
 }
 
 type dbWrapper struct {
-	db *sql.DB
+	db       *sql.DB
+	stmts    *stmtCache
+	timeouts Timeouts
 }
 
 func (d *dbWrapper) Begin() (Tx, error) {
-	tx, err := d.db.Begin()
-	return NewTx(tx), err
+	return d.BeginTx(context.Background(), nil)
 }
 
 func (d *dbWrapper) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	ctx, cancel := d.timeouts.tx(ctx)
 	tx, err := d.db.BeginTx(ctx, opts)
-	return NewTx(tx), err
+	if tx == nil {
+		cancel()
+		return nil, err
+	}
+	return &txWrapper{
+		tx:       tx,
+		stmts:    d.stmts,
+		timeouts: d.timeouts,
+		cancel:   cancel,
+		leak:     trackLeak("Tx"),
+	}, err
 }
 
 func (d *dbWrapper) Close() error {
+	if d.stmts != nil {
+		d.stmts.close()
+	}
 	return d.db.Close()
 }
 
+func (d *dbWrapper) Conn(ctx context.Context) (PinnedConn, error) {
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := NewPinnedConn(conn).(*pinnedConnWrapper)
+	c.timeouts = d.timeouts
+	return c, nil
+}
+
 func (d *dbWrapper) Driver() driver.Driver {
 	return d.db.Driver()
 }
 
 func (d *dbWrapper) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return d.db.Exec(query, args...)
+	return d.ExecContext(context.Background(), query, args...)
 }
 
 func (d *dbWrapper) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	return d.db.ExecContext(ctx, query, args...)
+	ctx, cancel := d.timeouts.write(ctx)
+	defer cancel()
+	if d.stmts == nil {
+		return d.db.ExecContext(ctx, query, args...)
+	}
+	e, err := d.stmts.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer d.stmts.release(e)
+	return e.stmt.ExecContext(ctx, args...)
 }
 
 func (d *dbWrapper) Ping() error {
@@ -72,21 +178,45 @@ func (d *dbWrapper) PrepareContext(ctx context.Context, query string) (Stmt, err
 }
 
 func (d *dbWrapper) Query(query string, args ...interface{}) (Rows, error) {
-	rows, err := d.db.Query(query, args...)
-	return NewRows(rows), err
+	return d.QueryContext(context.Background(), query, args...)
 }
 
 func (d *dbWrapper) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
-	rows, err := d.db.QueryContext(ctx, query, args...)
-	return NewRows(rows), err
+	ctx, cancel := d.timeouts.read(ctx)
+	if d.stmts == nil {
+		rows, err := d.db.QueryContext(ctx, query, args...)
+		return newTimedRows(rows, cancel), err
+	}
+	e, err := d.stmts.acquire(ctx, query)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	defer d.stmts.release(e)
+	rows, err := e.stmt.QueryContext(ctx, args...)
+	return newTimedRows(rows, cancel), err
 }
 
 func (d *dbWrapper) QueryRow(query string, args ...interface{}) Row {
-	return NewRow(d.db.QueryRow(query, args...))
+	return d.QueryRowContext(context.Background(), query, args...)
 }
 
 func (d *dbWrapper) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
-	return NewRow(d.db.QueryRowContext(ctx, query, args...))
+	ctx, cancel := d.timeouts.read(ctx)
+	if d.stmts == nil {
+		return newTimedRow(d.db.QueryRowContext(ctx, query, args...), cancel)
+	}
+	e, err := d.stmts.acquire(ctx, query)
+	if err != nil {
+		cancel()
+		return &errRow{err: err}
+	}
+	defer d.stmts.release(e)
+	return newTimedRow(e.stmt.QueryRowContext(ctx, args...), cancel)
+}
+
+func (d *dbWrapper) SetConnMaxIdleTime(dur time.Duration) {
+	d.db.SetConnMaxIdleTime(dur)
 }
 
 func (d *dbWrapper) SetConnMaxLifetime(dur time.Duration) {
@@ -105,6 +235,13 @@ func (d *dbWrapper) Stats() sql.DBStats {
 	return d.db.Stats()
 }
 
+func (d *dbWrapper) StmtCacheStats() StmtCacheStats {
+	if d.stmts == nil {
+		return StmtCacheStats{}
+	}
+	return d.stmts.stats()
+}
+
 type replicaSet struct {
 	primary DBCore
 	slaves  []DBCore
@@ -115,19 +252,27 @@ func (r *replicaSet) ExecContext(ctx context.Context, query string, args ...inte
 }
 
 func (r *replicaSet) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
-	if len(r.slaves) > 0 {
-		return r.slaves[rand.Intn(len(r.slaves))].QueryContext(ctx, query, args...)
-	} else {
-		return r.primary.QueryContext(ctx, query, args...)
-	}
+	return r.reader().QueryContext(ctx, query, args...)
 }
 
 func (r *replicaSet) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
-	if len(r.slaves) > 0 {
-		return r.slaves[rand.Intn(len(r.slaves))].QueryRowContext(ctx, query, args...)
-	} else {
-		return r.primary.QueryRowContext(ctx, query, args...)
+	return r.reader().QueryRowContext(ctx, query, args...)
+}
+
+// reader picks a random slave, skipping those whose circuit breaker would reject the query, or the primary if there
+// are none available.
+func (r *replicaSet) reader() DBCore {
+	if len(r.slaves) == 0 {
+		return r.primary
+	}
+	start := rand.Intn(len(r.slaves))
+	for i := range r.slaves {
+		slave := r.slaves[(start+i)%len(r.slaves)]
+		if cb, ok := slave.(CircuitBreaker); !ok || cb.Available() {
+			return slave
+		}
 	}
+	return r.primary
 }
 
 func (r *replicaSet) Primary() DBCore {
@@ -139,19 +284,38 @@ func (r *replicaSet) Slaves() []DBCore {
 }
 
 type rowWrapper struct {
-	row *sql.Row
+	row    *sql.Row
+	cancel context.CancelFunc
 }
 
 func (r *rowWrapper) Scan(dest ...interface{}) error {
+	if r.cancel != nil {
+		defer r.cancel()
+	}
 	return r.row.Scan(dest...)
 }
 
+type errRow struct {
+	err error
+}
+
+func (r *errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
+
 type rowsWrapper struct {
-	rows *sql.Rows
+	rows   *sql.Rows
+	leak   *leakHandle
+	cancel context.CancelFunc
 }
 
 func (r *rowsWrapper) Close() error {
-	return r.rows.Close()
+	r.leak.release()
+	err := r.rows.Close()
+	if r.cancel != nil {
+		r.cancel()
+	}
+	return err
 }
 
 func (r *rowsWrapper) ColumnTypes() ([]ColumnType, error) {
@@ -172,11 +336,32 @@ func (r *rowsWrapper) Err() error {
 }
 
 func (r *rowsWrapper) Next() bool {
-	return r.rows.Next()
+	if r.rows.Next() {
+		return true
+	}
+	// exhausted rows are closed by "database/sql", unless there is another result set in which case Columns still
+	// succeeds and the leak handle and timeout must be kept for NextResultSet
+	if _, err := r.rows.Columns(); err != nil {
+		r.closed()
+	}
+	return false
 }
 
 func (r *rowsWrapper) NextResultSet() bool {
-	return r.rows.NextResultSet()
+	if r.rows.NextResultSet() {
+		return true
+	}
+	// "database/sql" closes rows which have no more result sets
+	r.closed()
+	return false
+}
+
+// closed releases the leak handle and timeout of rows "database/sql" has closed itself.
+func (r *rowsWrapper) closed() {
+	r.leak.release()
+	if r.cancel != nil {
+		r.cancel()
+	}
 }
 
 func (r *rowsWrapper) Scan(dest ...interface{}) error {
@@ -185,9 +370,11 @@ func (r *rowsWrapper) Scan(dest ...interface{}) error {
 
 type stmtWrapper struct {
 	stmt *sql.Stmt
+	leak *leakHandle
 }
 
 func (s *stmtWrapper) Close() error {
+	s.leak.release()
 	return s.stmt.Close()
 }
 
@@ -218,59 +405,201 @@ func (s *stmtWrapper) QueryRowContext(ctx context.Context, args ...interface{})
 }
 
 type txWrapper struct {
-	tx *sql.Tx
+	tx           *sql.Tx
+	stmts        *stmtCache
+	timeouts     Timeouts
+	cancel       context.CancelFunc
+	txStmtsMtx   sync.Mutex
+	txStmts      map[string]*sql.Stmt
+	leak         *leakHandle
+	stmtLeaksMtx sync.Mutex
+	stmtLeaks    []*leakHandle
+}
+
+// newStmt tracks stmts prepared on the tx so they are released when it ends, as "database/sql" closes them then.
+func (t *txWrapper) newStmt(stmt *sql.Stmt) Stmt {
+	if stmt == nil {
+		return nil
+	}
+	s := NewStmt(stmt).(*stmtWrapper)
+	if s.leak != nil {
+		t.stmtLeaksMtx.Lock()
+		t.stmtLeaks = append(t.stmtLeaks, s.leak)
+		t.stmtLeaksMtx.Unlock()
+	}
+	return s
+}
+
+// end releases the txs leak handles and its timeout, it is called by Commit and Rollback.
+func (t *txWrapper) end() {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	t.releaseLeaks()
+}
+
+func (t *txWrapper) releaseLeaks() {
+	t.leak.release()
+	t.stmtLeaksMtx.Lock()
+	defer t.stmtLeaksMtx.Unlock()
+	for _, l := range t.stmtLeaks {
+		l.release()
+	}
+	t.stmtLeaks = nil
+}
+
+// stmt returns the tx bound version of the DBs cached statement for query.
+func (t *txWrapper) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	t.txStmtsMtx.Lock()
+	defer t.txStmtsMtx.Unlock()
+	if stmt, ok := t.txStmts[query]; ok {
+		return stmt, nil
+	}
+	e, err := t.stmts.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer t.stmts.release(e)
+	stmt := t.tx.StmtContext(ctx, e.stmt)
+	if t.txStmts == nil {
+		t.txStmts = make(map[string]*sql.Stmt)
+	}
+	t.txStmts[query] = stmt
+	return stmt, nil
 }
 
 func (t *txWrapper) Commit() error {
+	defer t.end()
 	return t.tx.Commit()
 }
 
 func (t *txWrapper) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return t.tx.Exec(query, args...)
+	return t.ExecContext(context.Background(), query, args...)
 }
 
 func (t *txWrapper) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	return t.tx.ExecContext(ctx, query, args...)
+	ctx, cancel := t.timeouts.write(ctx)
+	defer cancel()
+	if t.stmts == nil {
+		return t.tx.ExecContext(ctx, query, args...)
+	}
+	stmt, err := t.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ExecContext(ctx, args...)
 }
 
 func (t *txWrapper) Prepare(query string) (Stmt, error) {
 	stmt, err := t.tx.Prepare(query)
-	return NewStmt(stmt), err
+	return t.newStmt(stmt), err
 }
 
 func (t *txWrapper) PrepareContext(ctx context.Context, query string) (Stmt, error) {
 	stmt, err := t.tx.PrepareContext(ctx, query)
-	return NewStmt(stmt), err
+	return t.newStmt(stmt), err
 }
 
 func (t *txWrapper) Query(query string, args ...interface{}) (Rows, error) {
-	rows, err := t.tx.Query(query, args...)
-	return NewRows(rows), err
+	return t.QueryContext(context.Background(), query, args...)
 }
 
 func (t *txWrapper) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
-	rows, err := t.tx.QueryContext(ctx, query, args...)
-	return NewRows(rows), err
+	ctx, cancel := t.timeouts.read(ctx)
+	if t.stmts == nil {
+		rows, err := t.tx.QueryContext(ctx, query, args...)
+		return newTimedRows(rows, cancel), err
+	}
+	stmt, err := t.stmt(ctx, query)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	return newTimedRows(rows, cancel), err
 }
 
 func (t *txWrapper) QueryRow(query string, args ...interface{}) Row {
-	return NewRow(t.tx.QueryRow(query, args...))
+	return t.QueryRowContext(context.Background(), query, args...)
 }
 
 func (t *txWrapper) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
-	return NewRow(t.tx.QueryRowContext(ctx, query, args...))
+	ctx, cancel := t.timeouts.read(ctx)
+	if t.stmts == nil {
+		return newTimedRow(t.tx.QueryRowContext(ctx, query, args...), cancel)
+	}
+	stmt, err := t.stmt(ctx, query)
+	if err != nil {
+		cancel()
+		return &errRow{err: err}
+	}
+	return newTimedRow(stmt.QueryRowContext(ctx, args...), cancel)
 }
 
 func (t *txWrapper) Rollback() error {
+	defer t.end()
 	return t.tx.Rollback()
 }
 
 func (t *txWrapper) Stmt(stmt *sql.Stmt) Stmt {
-	return NewStmt(t.tx.Stmt(stmt))
+	return t.newStmt(t.tx.Stmt(stmt))
 }
 
 func (t *txWrapper) StmtContext(ctx context.Context, stmt *sql.Stmt) Stmt {
-	return NewStmt(t.tx.StmtContext(ctx, stmt))
+	return t.newStmt(t.tx.StmtContext(ctx, stmt))
+}
+
+// pinnedConnWrapper does not use the DBs statement cache as its statements are prepared on other connections.
+type pinnedConnWrapper struct {
+	conn     *sql.Conn
+	timeouts Timeouts
+	leak     *leakHandle
+}
+
+func (c *pinnedConnWrapper) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	ctx, cancel := c.timeouts.tx(ctx)
+	tx, err := c.conn.BeginTx(ctx, opts)
+	if tx == nil {
+		cancel()
+		return nil, err
+	}
+	return &txWrapper{
+		tx:       tx,
+		timeouts: c.timeouts,
+		cancel:   cancel,
+		leak:     trackLeak("Tx"),
+	}, err
+}
+
+func (c *pinnedConnWrapper) Close() error {
+	defer c.leak.release()
+	return c.conn.Close()
+}
+
+func (c *pinnedConnWrapper) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := c.timeouts.write(ctx)
+	defer cancel()
+	return c.conn.ExecContext(ctx, query, args...)
+}
+
+func (c *pinnedConnWrapper) PingContext(ctx context.Context) error {
+	return c.conn.PingContext(ctx)
+}
+
+func (c *pinnedConnWrapper) PrepareContext(ctx context.Context, query string) (Stmt, error) {
+	stmt, err := c.conn.PrepareContext(ctx, query)
+	return NewStmt(stmt), err
+}
+
+func (c *pinnedConnWrapper) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	ctx, cancel := c.timeouts.read(ctx)
+	rows, err := c.conn.QueryContext(ctx, query, args...)
+	return newTimedRows(rows, cancel), err
+}
+
+func (c *pinnedConnWrapper) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	ctx, cancel := c.timeouts.read(ctx)
+	return newTimedRow(c.conn.QueryRowContext(ctx, query, args...), cancel)
 }
 
 type columnTypeWrapper struct {
@@ -0,0 +1,175 @@
+package isql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+)
+
+// CredentialProvider returns the data source name, including current credentials, to use for a new physical
+// connection. It is called every time one is opened so rotated credentials are picked up without a restart.
+type CredentialProvider interface {
+	DataSourceName(ctx context.Context) (string, error)
+}
+
+type CredentialProviderFunc func(ctx context.Context) (string, error)
+
+func (f CredentialProviderFunc) DataSourceName(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// NewCredentialConnector returns a connector for driverName which fetches a data source name from provider for
+// every physical connection it opens, open a DB with it using Opener.OpenConnector or NewReplicaSetFromConnectors.
+func NewCredentialConnector(driverName string, provider CredentialProvider) (CredentialConnector, error) {
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	db.Close()
+	return &credentialConnector{
+		driver:   drv,
+		provider: provider,
+	}, nil
+}
+
+type CredentialConnector interface {
+	driver.Connector
+	// Rotate should be called when credentials are rotated, every connection opened before the call is treated as
+	// stale and is closed, rather than reused, the next time it is returned to or taken from the pool.
+	Rotate()
+}
+
+type credentialConnector struct {
+	driver     driver.Driver
+	provider   CredentialProvider
+	generation uint64
+}
+
+func (c *credentialConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	generation := atomic.LoadUint64(&c.generation)
+	dataSourceName, err := c.provider.DataSourceName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var conn driver.Conn
+	if dc, ok := c.driver.(driver.DriverContext); ok {
+		connector, err := dc.OpenConnector(dataSourceName)
+		if err != nil {
+			return nil, err
+		}
+		conn, err = connector.Connect(ctx)
+	} else {
+		conn, err = c.driver.Open(dataSourceName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &credentialConn{
+		conn:       conn,
+		connector:  c,
+		generation: generation,
+	}, nil
+}
+
+func (c *credentialConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func (c *credentialConnector) Rotate() {
+	atomic.AddUint64(&c.generation, 1)
+}
+
+// credentialConn passes every optional driver interface through to conn so wrapping it does not change how
+// database/sql uses the driver, it additionally reports itself invalid once its credentials have been rotated.
+type credentialConn struct {
+	conn       driver.Conn
+	connector  *credentialConnector
+	generation uint64
+}
+
+func (c *credentialConn) stale() bool {
+	return atomic.LoadUint64(&c.connector.generation) != c.generation
+}
+
+func (c *credentialConn) Prepare(query string) (driver.Stmt, error) {
+	return c.conn.Prepare(query)
+}
+
+func (c *credentialConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.conn.Prepare(query)
+}
+
+func (c *credentialConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *credentialConn) Begin() (driver.Tx, error) {
+	return c.conn.Begin()
+}
+
+func (c *credentialConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New("isql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("isql: driver does not support read-only transactions")
+	}
+	return c.conn.Begin()
+}
+
+func (c *credentialConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *credentialConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *credentialConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *credentialConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *credentialConn) ResetSession(ctx context.Context) error {
+	if c.stale() {
+		return driver.ErrBadConn
+	}
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *credentialConn) IsValid() bool {
+	if c.stale() {
+		return false
+	}
+	if validator, ok := c.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
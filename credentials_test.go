@@ -0,0 +1,82 @@
+package isql_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/isqltest"
+)
+
+func TestCredentialRotation(t *testing.T) {
+	f := isqltest.NewFake("credentials")
+	defer f.Close()
+	f.RequirePassword("old")
+	f.On(isqltest.Exact("SELECT 1")).WillReturnRows([]string{"a"}, []interface{}{1})
+	var password atomic.Value
+	password.Store("old")
+	connector, err := isql.NewCredentialConnector(isqltest.DriverName, isql.CredentialProviderFunc(func(ctx context.Context) (string, error) {
+		return "credentials?password=" + password.Load().(string), nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := isql.NewOpener().OpenConnector(connector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	query := func(q isql.DBCore) error {
+		var a int
+		return q.QueryRowContext(ctx, "SELECT 1").Scan(&a)
+	}
+
+	if err := query(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := query(db); err != nil {
+		t.Fatal(err)
+	}
+	if f.Connects() != 1 {
+		t.Fatalf("connects %d, expected the idle connection to be reused", f.Connects())
+	}
+	held, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the database only accepts the new password from now on, connections already open stay usable
+	f.RequirePassword("new")
+	password.Store("new")
+	connector.Rotate()
+	if err := query(held); err != nil {
+		t.Fatalf("open connection failed after rotation: %v", err)
+	}
+	if err := query(db); err != nil {
+		t.Fatalf("new connection failed after rotation: %v", err)
+	}
+	if f.Connects() != 2 {
+		t.Fatalf("connects %d, expected one new connection with the new password", f.Connects())
+	}
+	if err := held.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if open := db.Stats().OpenConnections; open != 1 {
+		t.Fatalf("%d open connections, expected the stale one to be closed when returned to the pool", open)
+	}
+	if err := query(db); err != nil {
+		t.Fatal(err)
+	}
+	if f.Connects() != 2 {
+		t.Fatalf("connects %d, expected the connection opened after rotation to be reused", f.Connects())
+	}
+
+	password.Store("stale")
+	connector.Rotate()
+	if err := query(db); !errors.Is(err, isqltest.ErrAuthFailed) {
+		t.Fatalf("error %v, expected %v", err, isqltest.ErrAuthFailed)
+	}
+}
@@ -0,0 +1,341 @@
+package isql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// NewDataRows returns Rows which iterate over sets in order, moving between them with NextResultSet. Values are
+// converted as if they had been returned by a driver and Scan follows the same conversion rules as "database/sql".
+func NewDataRows(sets ...ResultSet) (Rows, error) {
+	if len(sets) == 0 {
+		sets = []ResultSet{{}}
+	}
+	converted, err := convertResultSets(sets)
+	if err != nil {
+		return nil, err
+	}
+	return &dataRows{
+		sets: converted,
+		row:  -1,
+	}, nil
+}
+
+// NewDataRow returns a Row which scans the first row of set, or returns sql.ErrNoRows if it has none.
+func NewDataRow(set ResultSet) Row {
+	converted, err := convertResultSets([]ResultSet{set})
+	if err != nil {
+		return &errRow{err: err}
+	}
+	return &dataRow{
+		rows: &dataRows{
+			sets: converted,
+			row:  -1,
+		},
+	}
+}
+
+func NewResult(lastInsertID, rowsAffected int64) sql.Result {
+	return &result{
+		lastInsertID: lastInsertID,
+		rowsAffected: rowsAffected,
+	}
+}
+
+// ReadResultSets reads every remaining row of every result set in rows into memory and closes rows, values are
+// the raw driver values. An error returned by rows.Err is recorded in the result set it occurred in, so replaying
+// the sets with NewDataRows fails at the same point.
+func ReadResultSets(rows Rows) ([]ResultSet, error) {
+	defer rows.Close()
+	var sets []ResultSet
+	for {
+		set, err := readResultSet(rows)
+		if err != nil {
+			return sets, err
+		}
+		sets = append(sets, set)
+		if set.Err != nil || !rows.NextResultSet() {
+			return sets, nil
+		}
+	}
+}
+
+func readResultSet(rows Rows) (ResultSet, error) {
+	return readResultSetLimit(rows, 0)
+}
+
+// readResultSetLimit reads at most limit rows of the current result set, every row if limit <= 0.
+func readResultSetLimit(rows Rows, limit int) (ResultSet, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return ResultSet{}, err
+	}
+	set := ResultSet{
+		Columns: columns,
+	}
+	if columnTypes, err := rows.ColumnTypes(); err == nil {
+		set.ColumnTypes = make([]ColumnTypeInfo, 0, len(columnTypes))
+		for _, ct := range columnTypes {
+			set.ColumnTypes = append(set.ColumnTypes, NewColumnTypeInfo(ct))
+		}
+	}
+	for (limit <= 0 || len(set.Rows) < limit) && rows.Next() {
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return set, err
+		}
+		set.Rows = append(set.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		set.Err = err
+		set.ErrAt = len(set.Rows)
+	}
+	return set, nil
+}
+
+func NewColumnTypeInfo(ct ColumnType) ColumnTypeInfo {
+	info := ColumnTypeInfo{
+		DatabaseType: ct.DatabaseTypeName(),
+		ScanType:     ct.ScanType(),
+	}
+	info.Length, info.HasLength = ct.Length()
+	info.Precision, info.Scale, info.HasDecimalSize = ct.DecimalSize()
+	info.Nullable, info.HasNullable = ct.Nullable()
+	return info
+}
+
+type ResultSet struct {
+	Columns []string
+	// ColumnTypes is optional metadata returned by Rows.ColumnTypes, if set it must have an entry per column.
+	ColumnTypes []ColumnTypeInfo
+	Rows        [][]interface{}
+	// Err if set is returned when advancing to the row at index ErrAt, Next returns false and Rows.Err returns Err.
+	// ErrAt may be len(Rows) to fail after the last row.
+	Err   error
+	ErrAt int
+}
+
+type ColumnTypeInfo struct {
+	DatabaseType   string
+	Length         int64
+	HasLength      bool
+	Precision      int64
+	Scale          int64
+	HasDecimalSize bool
+	Nullable       bool
+	HasNullable    bool
+	ScanType       reflect.Type
+}
+
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r *result) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+func (r *result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+// convertResultSets checks sets are well formed and converts their values as a driver would return them.
+func convertResultSets(sets []ResultSet) ([]dataSet, error) {
+	converted := make([]dataSet, 0, len(sets))
+	for i, set := range sets {
+		if set.ColumnTypes != nil && len(set.ColumnTypes) != len(set.Columns) {
+			return nil, fmt.Errorf("isql: result set %d has %d column types, expected %d", i, len(set.ColumnTypes), len(set.Columns))
+		}
+		if set.Err != nil && (set.ErrAt < 0 || set.ErrAt > len(set.Rows)) {
+			return nil, fmt.Errorf("isql: result set %d ErrAt %d is out of range for %d rows", i, set.ErrAt, len(set.Rows))
+		}
+		values := make([][]driver.Value, 0, len(set.Rows))
+		for r, row := range set.Rows {
+			if len(row) != len(set.Columns) {
+				return nil, fmt.Errorf("isql: result set %d row %d has %d values, expected %d", i, r, len(row), len(set.Columns))
+			}
+			vs := make([]driver.Value, len(row))
+			for c, v := range row {
+				var err error
+				if vs[c], err = driver.DefaultParameterConverter.ConvertValue(v); err != nil {
+					return nil, fmt.Errorf("isql: result set %d row %d column %q: %s", i, r, set.Columns[c], err)
+				}
+			}
+			values = append(values, vs)
+		}
+		converted = append(converted, dataSet{
+			ResultSet: set,
+			values:    values,
+		})
+	}
+	return converted, nil
+}
+
+type dataSet struct {
+	ResultSet
+	values [][]driver.Value
+}
+
+var errDataRowsClosed = errors.New("isql: rows are closed")
+
+// dataRows serves result sets held in memory with the same behaviour as *sql.Rows, it is closed once Next reads
+// past the last row of the last set or fails.
+type dataRows struct {
+	sets   []dataSet
+	set    int
+	row    int
+	closed bool
+	err    error
+}
+
+func (r *dataRows) current() *dataSet {
+	return &r.sets[r.set]
+}
+
+func (r *dataRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (r *dataRows) ColumnTypes() ([]ColumnType, error) {
+	if r.closed {
+		return nil, errDataRowsClosed
+	}
+	s := r.current()
+	res := make([]ColumnType, len(s.Columns))
+	for i, name := range s.Columns {
+		ct := &dataColumnType{
+			name: name,
+		}
+		if s.ColumnTypes != nil {
+			ct.info = s.ColumnTypes[i]
+		}
+		res[i] = ct
+	}
+	return res, nil
+}
+
+func (r *dataRows) Columns() ([]string, error) {
+	if r.closed {
+		return nil, errDataRowsClosed
+	}
+	return append([]string{}, r.current().Columns...), nil
+}
+
+func (r *dataRows) Err() error {
+	return r.err
+}
+
+func (r *dataRows) Next() bool {
+	if r.closed {
+		return false
+	}
+	s := r.current()
+	next := r.row + 1
+	if s.Err != nil && next == s.ErrAt {
+		r.err = s.Err
+		r.closed = true
+		return false
+	}
+	if next >= len(s.values) {
+		r.row = len(s.values)
+		if r.set+1 >= len(r.sets) {
+			r.closed = true
+		}
+		return false
+	}
+	r.row = next
+	return true
+}
+
+func (r *dataRows) NextResultSet() bool {
+	if r.closed {
+		return false
+	}
+	if r.set+1 >= len(r.sets) {
+		r.closed = true
+		return false
+	}
+	r.set++
+	r.row = -1
+	return true
+}
+
+func (r *dataRows) Scan(dest ...interface{}) error {
+	if r.closed {
+		return errDataRowsClosed
+	}
+	s := r.current()
+	if r.row < 0 || r.row >= len(s.values) {
+		return errors.New("isql: Scan called without calling Next")
+	}
+	if len(dest) != len(s.Columns) {
+		return fmt.Errorf("isql: expected %d destination arguments in Scan, not %d", len(s.Columns), len(dest))
+	}
+	for i, v := range s.values[r.row] {
+		if err := convertAssign(dest[i], v); err != nil {
+			return fmt.Errorf("isql: Scan error on column index %d, name %q: %w", i, s.Columns[i], err)
+		}
+	}
+	return nil
+}
+
+type dataRow struct {
+	rows *dataRows
+}
+
+func (r *dataRow) Scan(dest ...interface{}) error {
+	defer r.rows.Close()
+	for _, d := range dest {
+		if _, ok := d.(*sql.RawBytes); ok {
+			return errors.New("isql: RawBytes isn't allowed on Row.Scan")
+		}
+	}
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	return r.rows.Scan(dest...)
+}
+
+type dataColumnType struct {
+	name string
+	info ColumnTypeInfo
+}
+
+func (c *dataColumnType) DatabaseTypeName() string {
+	return c.info.DatabaseType
+}
+
+func (c *dataColumnType) DecimalSize() (precision, scale int64, ok bool) {
+	return c.info.Precision, c.info.Scale, c.info.HasDecimalSize
+}
+
+func (c *dataColumnType) Length() (length int64, ok bool) {
+	return c.info.Length, c.info.HasLength
+}
+
+func (c *dataColumnType) Name() string {
+	return c.name
+}
+
+func (c *dataColumnType) Nullable() (nullable, ok bool) {
+	return c.info.Nullable, c.info.HasNullable
+}
+
+func (c *dataColumnType) ScanType() reflect.Type {
+	if c.info.ScanType != nil {
+		return c.info.ScanType
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
@@ -0,0 +1,185 @@
+package isql_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/isqltest"
+)
+
+type myString string
+
+func TestDataRowsScanMatchesDatabaseSQL(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	sources := []interface{}{int64(7), int64(300), int64(-1), "7", []byte("7"), "x", 1.5, true, nil, ts}
+	dests := []func() interface{}{
+		func() interface{} { return new(int) },
+		func() interface{} { return new(int8) },
+		func() interface{} { return new(uint) },
+		func() interface{} { return new(float32) },
+		func() interface{} { return new(string) },
+		func() interface{} { return new([]byte) },
+		func() interface{} { return new(bool) },
+		func() interface{} { return new(interface{}) },
+		func() interface{} { return new(*int) },
+		func() interface{} { return new(myString) },
+		func() interface{} { return new(time.Time) },
+		func() interface{} { return new(sql.NullInt64) },
+		func() interface{} { return new(sql.NullString) },
+		func() interface{} { return new(sql.NullTime) },
+	}
+	f := isqltest.NewFake("data_rows")
+	defer f.Close()
+	db, err := isql.NewOpener().Open(isqltest.DriverName, "data_rows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for i, src := range sources {
+		query := "SELECT " + string(rune('a'+i))
+		f.On(isqltest.Exact(query)).WillReturnRows([]string{"v"}, []interface{}{src})
+		for _, newDest := range dests {
+			expected, actual := newDest(), newDest()
+			expectedErr := db.QueryRowContext(context.Background(), query).Scan(expected)
+			actualErr := isql.NewDataRow(isql.ResultSet{Columns: []string{"v"}, Rows: [][]interface{}{{src}}}).Scan(actual)
+			if (expectedErr == nil) != (actualErr == nil) {
+				t.Errorf("scan %T %v into %T: error %v, database/sql error %v", src, src, actual, actualErr, expectedErr)
+				continue
+			}
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("scan %T %v into %T: got %v, database/sql got %v", src, src, actual, reflect.ValueOf(actual).Elem(), reflect.ValueOf(expected).Elem())
+			}
+		}
+	}
+}
+
+func TestDataRows(t *testing.T) {
+	boom := errors.New("boom")
+	rows, err := isql.NewDataRows(
+		isql.ResultSet{
+			Columns:     []string{"a", "b"},
+			ColumnTypes: []isql.ColumnTypeInfo{{DatabaseType: "INT"}, {DatabaseType: "TEXT", Nullable: true, HasNullable: true}},
+			Rows:        [][]interface{}{{1, "x"}, {2, nil}},
+		},
+		isql.ResultSet{
+			Columns: []string{"c"},
+			Rows:    [][]interface{}{{"y"}},
+			Err:     boom,
+			ErrAt:   1,
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rows.Scan(new(int), new(string)); err == nil {
+		t.Fatal("expected Scan before Next to fail")
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil || types[0].Name() != "a" || types[1].DatabaseTypeName() != "TEXT" {
+		t.Fatal("unexpected column types", err)
+	}
+	if nullable, ok := types[1].Nullable(); !nullable || !ok {
+		t.Fatal("expected b to be nullable")
+	}
+	var got []interface{}
+	for rows.Next() {
+		var a int
+		var b sql.NullString
+		if err := rows.Scan(&a); err == nil {
+			t.Fatal("expected Scan with too few dest to fail")
+		}
+		if err := rows.Scan(&a, &b); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, a, b)
+	}
+	if !reflect.DeepEqual(got, []interface{}{1, sql.NullString{String: "x", Valid: true}, 2, sql.NullString{}}) {
+		t.Fatalf("read %v", got)
+	}
+	if !rows.NextResultSet() {
+		t.Fatal("expected a second result set")
+	}
+	if columns, _ := rows.Columns(); !reflect.DeepEqual(columns, []string{"c"}) {
+		t.Fatalf("columns %v", columns)
+	}
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	if n != 1 || rows.Err() != boom {
+		t.Fatalf("read %d rows with error %v, expected 1 and boom", n, rows.Err())
+	}
+	if rows.NextResultSet() {
+		t.Fatal("expected no more result sets")
+	}
+	if _, err := rows.Columns(); err == nil {
+		t.Fatal("expected Columns to fail once rows are closed")
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDataRowsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		set  isql.ResultSet
+	}{
+		{"short row", isql.ResultSet{Columns: []string{"a", "b"}, Rows: [][]interface{}{{1}}}},
+		{"column types", isql.ResultSet{Columns: []string{"a"}, ColumnTypes: []isql.ColumnTypeInfo{{}, {}}}},
+		{"unsupported value", isql.ResultSet{Columns: []string{"a"}, Rows: [][]interface{}{{struct{}{}}}}},
+		{"ErrAt past the end", isql.ResultSet{Columns: []string{"a"}, Rows: [][]interface{}{{1}}, Err: errors.New("x"), ErrAt: 2}},
+		{"negative ErrAt", isql.ResultSet{Columns: []string{"a"}, Err: errors.New("x"), ErrAt: -1}},
+	}
+	for _, tt := range tests {
+		if _, err := isql.NewDataRows(tt.set); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+		if err := isql.NewDataRow(tt.set).Scan(new(interface{})); err == nil {
+			t.Errorf("%s: expected Row.Scan to fail", tt.name)
+		}
+	}
+}
+
+func TestDataRowsErrAfterLastRow(t *testing.T) {
+	boom := errors.New("boom")
+	rows, err := isql.NewDataRows(isql.ResultSet{Columns: []string{"a"}, Rows: [][]interface{}{{1}, {2}}, Err: boom, ErrAt: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	if n != 2 || rows.Err() != boom {
+		t.Fatalf("read %d rows with error %v, expected 2 and boom", n, rows.Err())
+	}
+}
+
+func TestDataRow(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name     string
+		set      isql.ResultSet
+		expected int
+		err      error
+	}{
+		{"first row", isql.ResultSet{Columns: []string{"a"}, Rows: [][]interface{}{{"1"}, {2}}}, 1, nil},
+		{"no rows", isql.ResultSet{Columns: []string{"a"}}, 0, sql.ErrNoRows},
+		{"error", isql.ResultSet{Columns: []string{"a"}, Rows: [][]interface{}{{1}}, Err: boom}, 0, boom},
+	}
+	for _, tt := range tests {
+		var a int
+		if err := isql.NewDataRow(tt.set).Scan(&a); err != tt.err || a != tt.expected {
+			t.Errorf("%s: scanned %d with error %v, expected %d and %v", tt.name, a, err, tt.expected, tt.err)
+		}
+	}
+	if err := isql.NewDataRow(isql.ResultSet{Columns: []string{"a"}, Rows: [][]interface{}{{1}}}).Scan(new(sql.RawBytes)); err == nil {
+		t.Fatal("expected RawBytes to be rejected")
+	}
+}
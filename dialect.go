@@ -0,0 +1,88 @@
+package isql
+
+import (
+	"strconv"
+	"strings"
+)
+
+var (
+	Postgres Dialect = &dialect{name: "postgres", maxParams: 65535, placeholder: dollarPlaceholder, quote: `""`}
+	MySQL    Dialect = &dialect{name: "mysql", maxParams: 65535, placeholder: questionPlaceholder, quote: "``"}
+	SQLite   Dialect = &dialect{name: "sqlite", maxParams: 999, placeholder: questionPlaceholder, quote: `""`}
+	// SQLServer allows 2100 parameters but drivers may use up to 2 of them, e.g. for sp_executesql.
+	SQLServer Dialect = &dialect{name: "sqlserver", maxParams: 2098, maxRows: 1000, placeholder: atPlaceholder, quote: "[]"}
+)
+
+type Dialect interface {
+	Name() string
+	// MaxParams is the maximum number of bind parameters allowed in a single statement.
+	MaxParams() int
+	// MaxRows is the maximum number of rows in a single INSERT ... VALUES statement, 0 if there is no limit.
+	MaxRows() int
+	// Placeholder returns the bind parameter placeholder for the nth (1 based) argument.
+	Placeholder(n int) string
+	// QuoteIdent quotes each dot separated part of ident.
+	QuoteIdent(ident string) string
+}
+
+type dialect struct {
+	name        string
+	maxParams   int
+	maxRows     int
+	placeholder func(n int) string
+	quote       string
+}
+
+func (d *dialect) Name() string {
+	return d.name
+}
+
+func (d *dialect) MaxParams() int {
+	return d.maxParams
+}
+
+func (d *dialect) MaxRows() int {
+	return d.maxRows
+}
+
+func (d *dialect) Placeholder(n int) string {
+	return d.placeholder(n)
+}
+
+func (d *dialect) QuoteIdent(ident string) string {
+	open, close := d.quote[:1], d.quote[1:]
+	parts := strings.Split(ident, ".")
+	for i, part := range parts {
+		parts[i] = open + strings.Replace(part, close, close+close, -1) + close
+	}
+	return strings.Join(parts, ".")
+}
+
+func dollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func questionPlaceholder(n int) string {
+	return "?"
+}
+
+func atPlaceholder(n int) string {
+	return "@p" + strconv.Itoa(n)
+}
+
+// IsIntegerType reports whether databaseType, a name as returned by ColumnType.DatabaseTypeName, is an integer type
+// such as INT, BIGINT UNSIGNED, INT8 or BIGSERIAL, other names containing INT such as INTERVAL or POINT are not.
+func IsIntegerType(databaseType string) bool {
+	name := strings.ToUpper(strings.TrimSpace(databaseType))
+	// MySQL display widths, e.g. INT(11) UNSIGNED
+	if i, j := strings.IndexByte(name, '('), strings.IndexByte(name, ')'); i >= 0 && j > i {
+		name = strings.TrimSpace(name[:i]) + name[j+1:]
+	}
+	name = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(name, "UNSIGNED "), " UNSIGNED"))
+	switch name {
+	case "INT", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT", "INT2", "INT4", "INT8",
+		"SERIAL", "SMALLSERIAL", "BIGSERIAL", "SERIAL2", "SERIAL4", "SERIAL8":
+		return true
+	}
+	return false
+}
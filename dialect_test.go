@@ -0,0 +1,32 @@
+package isql_test
+
+import (
+	"testing"
+
+	"github.com/0xor1/isql"
+)
+
+func TestIsIntegerType(t *testing.T) {
+	tests := map[string]bool{
+		"INT":              true,
+		"integer":          true,
+		"BIGINT UNSIGNED":  true,
+		"UNSIGNED BIGINT":  true,
+		"int(11) unsigned": true,
+		"TINYINT":          true,
+		"INT8":             true,
+		"BIGSERIAL":        true,
+		"serial4":          true,
+		"INTERVAL":         false,
+		"POINT":            false,
+		"MULTIPOINT":       false,
+		"INT4RANGE":        false,
+		"DECIMAL":          false,
+		"":                 false,
+	}
+	for name, expected := range tests {
+		if isql.IsIntegerType(name) != expected {
+			t.Errorf("%q: expected IsIntegerType to be %v", name, expected)
+		}
+	}
+}
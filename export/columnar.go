@@ -0,0 +1,153 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+// ColumnarMagic starts every columnar export.
+const ColumnarMagic = "ISQLCOL1"
+
+// Columnar writes rows in batches of opts.BatchSize rows, storing each column of a batch contiguously as Arrow
+// record batches do, and closes rows, it returns the number of rows written. Only one batch is held in memory.
+// The format is, with uvarint and varint as in encoding/binary:
+//
+//	magic    "ISQLCOL1"
+//	header   uvarint column count, then per column: uvarint name length, name, kind byte (see Kind),
+//	         uvarint database type length, database type
+//	batches  uvarint row count n > 0, then per column: uvarint length of the column's data, then the data,
+//	         a validity bitmap of (n+7)/8 bytes, bit i%8 of byte i/8 set if row i is not NULL, followed by the
+//	         value of each non NULL row
+//	end      uvarint 0
+//
+// Values are encoded by kind: int as a varint, float as 8 little endian IEEE 754 bytes, bool as 1 byte, time as a
+// varint of Unix seconds, a uvarint of nanoseconds within the second and a varint UTC offset in seconds, string
+// and bytes as a uvarint length followed by the bytes. Unix seconds cover every year of time.Time, where Unix
+// nanoseconds would overflow outside the years 1678 to 2262.
+func Columnar(ctx context.Context, w io.Writer, rows isql.Rows, opts Options) (int64, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	return run(ctx, rows, opts, &columnarEncoder{
+		w:         bufio.NewWriter(w),
+		batchSize: batchSize,
+	})
+}
+
+type columnarEncoder struct {
+	w         *bufio.Writer
+	batchSize int
+	columns   []Column
+	validity  [][]byte
+	data      []*bytes.Buffer
+	n         int
+	scratch   [binary.MaxVarintLen64]byte
+}
+
+func (e *columnarEncoder) begin(columns []Column) error {
+	e.columns = columns
+	e.validity = make([][]byte, len(columns))
+	e.data = make([]*bytes.Buffer, len(columns))
+	for i := range columns {
+		e.validity[i] = make([]byte, (e.batchSize+7)/8)
+		e.data[i] = &bytes.Buffer{}
+	}
+	e.w.WriteString(ColumnarMagic)
+	e.uvarint(e.w, uint64(len(columns)))
+	for _, c := range columns {
+		e.str(e.w, c.Name)
+		e.w.WriteByte(byte(c.Kind))
+		e.str(e.w, c.DatabaseType)
+	}
+	return nil
+}
+
+func (e *columnarEncoder) row(values []interface{}) error {
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		e.validity[i][e.n/8] |= 1 << uint(e.n%8)
+		buf := e.data[i]
+		switch x := v.(type) {
+		case int64:
+			e.varint(buf, x)
+		case float64:
+			binary.LittleEndian.PutUint64(e.scratch[:8], math.Float64bits(x))
+			buf.Write(e.scratch[:8])
+		case bool:
+			if x {
+				buf.WriteByte(1)
+			} else {
+				buf.WriteByte(0)
+			}
+		case time.Time:
+			_, offset := x.Zone()
+			e.varint(buf, x.Unix())
+			e.uvarint(buf, uint64(x.Nanosecond()))
+			e.varint(buf, int64(offset))
+		case []byte:
+			e.uvarint(buf, uint64(len(x)))
+			buf.Write(x)
+		case string:
+			e.str(buf, x)
+		}
+	}
+	e.n++
+	if e.n == e.batchSize {
+		return e.flush()
+	}
+	return nil
+}
+
+func (e *columnarEncoder) end() error {
+	if err := e.flush(); err != nil {
+		return err
+	}
+	e.uvarint(e.w, 0)
+	return e.w.Flush()
+}
+
+func (e *columnarEncoder) flush() error {
+	if e.n == 0 {
+		return nil
+	}
+	bitmapLen := (e.n + 7) / 8
+	e.uvarint(e.w, uint64(e.n))
+	for i := range e.columns {
+		e.uvarint(e.w, uint64(bitmapLen+e.data[i].Len()))
+		e.w.Write(e.validity[i][:bitmapLen])
+		e.w.Write(e.data[i].Bytes())
+		for j := range e.validity[i] {
+			e.validity[i][j] = 0
+		}
+		e.data[i].Reset()
+	}
+	e.n = 0
+	// bufio.Writer errors are sticky, checking once per batch catches any write error above
+	_, err := e.w.Write(nil)
+	return err
+}
+
+func (e *columnarEncoder) uvarint(w io.Writer, x uint64) {
+	n := binary.PutUvarint(e.scratch[:], x)
+	w.Write(e.scratch[:n])
+}
+
+func (e *columnarEncoder) varint(w io.Writer, x int64) {
+	n := binary.PutVarint(e.scratch[:], x)
+	w.Write(e.scratch[:n])
+}
+
+func (e *columnarEncoder) str(w io.Writer, s string) {
+	e.uvarint(w, uint64(len(s)))
+	io.WriteString(w, s)
+}
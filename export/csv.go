@@ -0,0 +1,50 @@
+package export
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"io"
+
+	"github.com/0xor1/isql"
+)
+
+// CSV writes a header row of rows.Columns() then a record per row and closes rows, it returns the number of rows
+// written. NULL is written as an empty field, times as RFC 3339 and bytes as standard base64.
+func CSV(ctx context.Context, w io.Writer, rows isql.Rows, opts Options) (int64, error) {
+	return run(ctx, rows, opts, &csvEncoder{
+		w: csv.NewWriter(w),
+	})
+}
+
+type csvEncoder struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (e *csvEncoder) begin(columns []Column) error {
+	e.record = make([]string, len(columns))
+	for i, c := range columns {
+		e.record[i] = c.Name
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvEncoder) row(values []interface{}) error {
+	for i, v := range values {
+		switch x := v.(type) {
+		case nil:
+			e.record[i] = ""
+		case []byte:
+			e.record[i] = base64.StdEncoding.EncodeToString(x)
+		default:
+			e.record[i] = format(x)
+		}
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvEncoder) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
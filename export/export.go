@@ -0,0 +1,304 @@
+// Package export streams query results from isql.Rows to an io.Writer as CSV, NDJSON (JSON Lines) or a
+// columnar binary format. Rows are encoded as they are read so memory use does not grow with the number of rows,
+// only the columnar format holds a batch of rows at a time.
+//
+// Values are typed from Rows.ColumnTypes, so a driver which returns an INT column as []byte, as MySQL's does
+// without interpolation, still exports it as a number.
+package export
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+const (
+	defaultProgressEvery = 10000
+	defaultBatchSize     = 1024
+)
+
+type Options struct {
+	// Progress if set is called with the number of rows written so far every ProgressEvery rows and once when
+	// the export ends, whether it succeeded or not.
+	Progress      func(rows int64)
+	ProgressEvery int64
+	// BatchSize is the number of rows per batch in the columnar format, 1024 if <= 0.
+	BatchSize int
+}
+
+// Kind is the type a column's values are exported as.
+type Kind byte
+
+const (
+	KindString Kind = iota
+	KindInt
+	KindFloat
+	KindBool
+	KindTime
+	KindBytes
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindInt:
+		return "int"
+	case KindFloat:
+		return "float"
+	case KindBool:
+		return "bool"
+	case KindTime:
+		return "time"
+	case KindBytes:
+		return "bytes"
+	default:
+		return "string"
+	}
+}
+
+type Column struct {
+	Name         string
+	DatabaseType string
+	Kind         Kind
+}
+
+// encoder writes one format, begin is called once with the columns, row for each row with values converted to
+// their column's Kind, nil, int64, float64, bool, time.Time, []byte or string, and end once after the last row.
+type encoder interface {
+	begin(columns []Column) error
+	row(values []interface{}) error
+	end() error
+}
+
+// run reads every row from rows into enc, checking ctx before each row, and closes rows.
+func run(ctx context.Context, rows isql.Rows, opts Options, enc encoder) (n int64, err error) {
+	defer rows.Close()
+	every := opts.ProgressEvery
+	if every <= 0 {
+		every = defaultProgressEvery
+	}
+	if opts.Progress != nil {
+		defer func() {
+			opts.Progress(n)
+		}()
+	}
+	columns, err := columnsOf(rows)
+	if err != nil {
+		return 0, err
+	}
+	if err := enc.begin(columns); err != nil {
+		return 0, err
+	}
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		for i := range values {
+			values[i] = nil
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return n, err
+		}
+		for i, c := range columns {
+			if values[i], err = convert(values[i], c.Kind); err != nil {
+				return n, err
+			}
+		}
+		if err := enc.row(values); err != nil {
+			return n, err
+		}
+		n++
+		if opts.Progress != nil && n%every == 0 {
+			opts.Progress(n)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return n, err
+	}
+	return n, enc.end()
+}
+
+func columnsOf(rows isql.Rows) ([]Column, error) {
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]Column, len(names))
+	for i, name := range names {
+		columns[i] = Column{
+			Name: name,
+			Kind: KindString,
+		}
+	}
+	// column types are optional, without them every column is exported as a string
+	if types, err := rows.ColumnTypes(); err == nil && len(types) == len(names) {
+		for i, ct := range types {
+			columns[i].DatabaseType = ct.DatabaseTypeName()
+			columns[i].Kind = kindOf(ct)
+		}
+	}
+	return columns, nil
+}
+
+func kindOf(ct isql.ColumnType) Kind {
+	dbType := strings.ToUpper(ct.DatabaseTypeName())
+	if strings.Contains(dbType, "UNSIGNED") && strings.Contains(dbType, "BIGINT") {
+		// values above math.MaxInt64 don't fit an int so are strings, as DECIMAL and NUMERIC are
+		return KindString
+	}
+	if t := ct.ScanType(); t != nil {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			return KindInt
+		case reflect.Uint, reflect.Uint64:
+			return KindString
+		case reflect.Float32, reflect.Float64:
+			return KindFloat
+		case reflect.Bool:
+			return KindBool
+		case reflect.String:
+			return KindString
+		}
+		if t == reflect.TypeOf(time.Time{}) {
+			return KindTime
+		}
+	}
+	switch {
+	case isql.IsIntegerType(dbType):
+		return KindInt
+	case strings.Contains(dbType, "FLOAT") || strings.Contains(dbType, "DOUBLE") || dbType == "REAL":
+		return KindFloat
+	case strings.Contains(dbType, "BOOL"):
+		return KindBool
+	case strings.Contains(dbType, "TIME") || dbType == "DATE":
+		return KindTime
+	case strings.Contains(dbType, "BLOB") || strings.Contains(dbType, "BINARY") || dbType == "BYTEA" || dbType == "IMAGE":
+		return KindBytes
+	}
+	// DECIMAL and NUMERIC are strings so no precision is lost
+	return KindString
+}
+
+// convert converts a raw driver value to the Go type of kind, text is parsed and any value is formatted for
+// KindString, a value which can't be converted is an error. MySQL's zero dates, e.g. 0000-00-00, are NULL.
+func convert(v interface{}, kind Kind) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if b, ok := v.([]byte); ok && kind != KindBytes {
+		v = string(b)
+	}
+	switch kind {
+	case KindInt:
+		switch x := v.(type) {
+		case int64:
+			return x, nil
+		case uint64:
+			if x <= math.MaxInt64 {
+				return int64(x), nil
+			}
+		case string:
+			return strconv.ParseInt(x, 10, 64)
+		case float64:
+			return int64(x), nil
+		case bool:
+			if x {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+	case KindFloat:
+		switch x := v.(type) {
+		case float64:
+			return x, nil
+		case int64:
+			return float64(x), nil
+		case string:
+			return strconv.ParseFloat(x, 64)
+		}
+	case KindBool:
+		switch x := v.(type) {
+		case bool:
+			return x, nil
+		case int64:
+			return x != 0, nil
+		case string:
+			return strconv.ParseBool(x)
+		}
+	case KindTime:
+		switch x := v.(type) {
+		case time.Time:
+			return x, nil
+		case string:
+			if isZeroDate(x) {
+				return nil, nil
+			}
+			for _, layout := range timeLayouts {
+				if t, err := time.Parse(layout, x); err == nil {
+					return t, nil
+				}
+			}
+		}
+	case KindBytes:
+		switch x := v.(type) {
+		case []byte:
+			return x, nil
+		case string:
+			return []byte(x), nil
+		}
+	}
+	if kind == KindString {
+		return format(v), nil
+	}
+	return nil, fmt.Errorf("export: can't convert %T %v to %s", v, v, kind)
+}
+
+// timeLayouts are the text forms of times drivers return when they don't parse them, e.g. MySQL's without
+// parseTime.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+	"15:04:05.999999999",
+}
+
+// isZeroDate reports whether s is one of MySQL's zero dates, e.g. 0000-00-00 or 0000-00-00 00:00:00, which no
+// time.Time represents.
+func isZeroDate(s string) bool {
+	return strings.HasPrefix(s, "0000-00-00") && strings.Trim(s, "0-: .") == ""
+}
+
+// format formats a driver value as a string, times as RFC 3339.
+func format(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case uint64:
+		return strconv.FormatUint(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(x)
+	}
+}
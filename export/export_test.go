@@ -0,0 +1,264 @@
+package export_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/export"
+	"github.com/0xor1/isql/importer"
+	"github.com/0xor1/isql/isqltest"
+)
+
+var (
+	columns = []string{"id", "big", "name", "price", "at", "data", "ok", "ttl"}
+	types   = []isql.ColumnTypeInfo{{DatabaseType: "BIGINT"}, {DatabaseType: "UNSIGNED BIGINT"}, {DatabaseType: "VARCHAR"},
+		{DatabaseType: "DECIMAL"}, {DatabaseType: "DATETIME"}, {DatabaseType: "BLOB"}, {DatabaseType: "BOOL"}, {DatabaseType: "INTERVAL"}}
+)
+
+// source returns rows of the test columns as MySQL returns them without the binary protocol, everything as text.
+func source(t *testing.T, n int) isql.Rows {
+	t.Helper()
+	set := isql.ResultSet{Columns: columns, ColumnTypes: types}
+	for i := 0; i < n; i++ {
+		at := []byte("2020-01-02 03:04:05")
+		if i%2 == 1 {
+			// MySQL's zero date has no time.Time so is NULL
+			at = []byte("0000-00-00 00:00:00")
+		}
+		set.Rows = append(set.Rows, []interface{}{[]byte("12"), []byte("18446744073709551615"), []byte(`a,"b"`), []byte("1.50"), at,
+			[]byte{1, 2}, []byte("1"), []byte("1 day")})
+	}
+	rows, err := isql.NewDataRows(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestExport(t *testing.T) {
+	tests := []struct {
+		name   string
+		export func(ctx context.Context, w io.Writer, rows isql.Rows, opts export.Options) (int64, error)
+		output string
+	}{
+		{"csv", export.CSV, "id,big,name,price,at,data,ok,ttl\n" +
+			"12,18446744073709551615,\"a,\"\"b\"\"\",1.50,2020-01-02T03:04:05Z,AQI=,true,1 day\n" +
+			"12,18446744073709551615,\"a,\"\"b\"\"\",1.50,,AQI=,true,1 day\n"},
+		{"ndjson", export.NDJSON,
+			`{"id":12,"big":"18446744073709551615","name":"a,\"b\"","price":"1.50","at":"2020-01-02T03:04:05Z","data":"AQI=","ok":true,"ttl":"1 day"}` + "\n" +
+				`{"id":12,"big":"18446744073709551615","name":"a,\"b\"","price":"1.50","at":null,"data":"AQI=","ok":true,"ttl":"1 day"}` + "\n"},
+	}
+	for _, tt := range tests {
+		b := &bytes.Buffer{}
+		n, err := tt.export(context.Background(), b, source(t, 2), export.Options{})
+		if err != nil || n != 2 {
+			t.Errorf("%s: exported %d rows with error %v", tt.name, n, err)
+			continue
+		}
+		if b.String() != tt.output {
+			t.Errorf("%s: exported\n%s\nexpected\n%s", tt.name, b.String(), tt.output)
+		}
+	}
+}
+
+func TestExportCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var progress []int64
+	if _, err := export.CSV(ctx, io.Discard, source(t, 1), export.Options{Progress: func(n int64) { progress = append(progress, n) }}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error %v, expected %v", err, context.Canceled)
+	}
+	if !reflect.DeepEqual(progress, []int64{0}) {
+		t.Fatalf("progress %v, expected a final call", progress)
+	}
+}
+
+// columnarReader decodes the columnar format into rows of int64, float64, bool, time.Time, []byte or string.
+type columnarReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (c *columnarReader) uvarint() uint64 {
+	x, err := binary.ReadUvarint(c.r)
+	if c.err == nil {
+		c.err = err
+	}
+	return x
+}
+
+func (c *columnarReader) varint() int64 {
+	x, err := binary.ReadVarint(c.r)
+	if c.err == nil {
+		c.err = err
+	}
+	return x
+}
+
+func (c *columnarReader) bytes(n uint64) []byte {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(c.r, b); c.err == nil {
+		c.err = err
+	}
+	return b
+}
+
+func (c *columnarReader) read() ([]export.Kind, [][]interface{}, error) {
+	if magic := string(c.bytes(uint64(len(export.ColumnarMagic)))); magic != export.ColumnarMagic {
+		return nil, nil, errors.New("bad magic")
+	}
+	kinds := make([]export.Kind, c.uvarint())
+	for i := range kinds {
+		c.bytes(c.uvarint())
+		kind, _ := c.r.ReadByte()
+		kinds[i] = export.Kind(kind)
+		c.bytes(c.uvarint())
+	}
+	var rows [][]interface{}
+	for n := c.uvarint(); n > 0 && c.err == nil; n = c.uvarint() {
+		batch := make([][]interface{}, n)
+		for i := range batch {
+			batch[i] = make([]interface{}, len(kinds))
+		}
+		for col, kind := range kinds {
+			c.uvarint()
+			validity := c.bytes((n + 7) / 8)
+			for i := range batch {
+				if validity[i/8]&(1<<uint(i%8)) == 0 {
+					continue
+				}
+				switch kind {
+				case export.KindInt:
+					batch[i][col] = c.varint()
+				case export.KindFloat:
+					batch[i][col] = math.Float64frombits(binary.LittleEndian.Uint64(c.bytes(8)))
+				case export.KindBool:
+					batch[i][col] = c.bytes(1)[0] == 1
+				case export.KindTime:
+					sec, nsec := c.varint(), c.uvarint()
+					batch[i][col] = time.Unix(sec, int64(nsec)).In(time.FixedZone("", int(c.varint())))
+				case export.KindBytes:
+					batch[i][col] = c.bytes(c.uvarint())
+				default:
+					batch[i][col] = string(c.bytes(c.uvarint()))
+				}
+			}
+		}
+		rows = append(rows, batch...)
+	}
+	return kinds, rows, c.err
+}
+
+func TestColumnar(t *testing.T) {
+	times := []time.Time{
+		time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(1600, 2, 3, 4, 5, 6, 7, time.FixedZone("", -3600)),
+		time.Date(2020, 1, 2, 3, 4, 5, 999999999, time.UTC),
+		time.Date(9999, 12, 31, 23, 59, 59, 0, time.FixedZone("", 5*3600)),
+	}
+	set := isql.ResultSet{
+		Columns:     []string{"i", "f", "b", "t", "x", "s"},
+		ColumnTypes: []isql.ColumnTypeInfo{{DatabaseType: "INT"}, {DatabaseType: "DOUBLE"}, {DatabaseType: "BOOLEAN"}, {DatabaseType: "TIMESTAMP"}, {DatabaseType: "BYTEA"}, {DatabaseType: "TEXT"}},
+	}
+	for i := 0; i < 2500; i++ {
+		row := []interface{}{int64(-i), float64(i) / 2, i%2 == 0, times[i%len(times)], []byte{byte(i)}, strings.Repeat("s", i%3)}
+		if i%5 == 0 {
+			row[i%len(row)] = nil
+		}
+		set.Rows = append(set.Rows, row)
+	}
+	rows, err := isql.NewDataRows(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &bytes.Buffer{}
+	var progress []int64
+	n, err := export.Columnar(context.Background(), b, rows, export.Options{BatchSize: 1000, ProgressEvery: 1000, Progress: func(n int64) { progress = append(progress, n) }})
+	if err != nil || n != 2500 || !reflect.DeepEqual(progress, []int64{1000, 2000, 2500}) {
+		t.Fatalf("exported %d rows with error %v and progress %v", n, err, progress)
+	}
+	kinds, read, err := (&columnarReader{r: bufio.NewReader(b)}).read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedKinds := []export.Kind{export.KindInt, export.KindFloat, export.KindBool, export.KindTime, export.KindBytes, export.KindString}
+	if !reflect.DeepEqual(kinds, expectedKinds) || len(read) != len(set.Rows) {
+		t.Fatalf("read %d rows of kinds %v", len(read), kinds)
+	}
+	for i, row := range read {
+		for j, v := range row {
+			expected := set.Rows[i][j]
+			if et, ok := expected.(time.Time); ok {
+				at, _ := v.(time.Time)
+				_, offset := et.Zone()
+				if _, readOffset := at.Zone(); !at.Equal(et) || readOffset != offset {
+					t.Fatalf("row %d column %d read %v, expected %v", i, j, v, expected)
+				}
+				continue
+			}
+			if !reflect.DeepEqual(v, expected) {
+				t.Fatalf("row %d column %d read %#v, expected %#v", i, j, v, expected)
+			}
+		}
+	}
+}
+
+func TestImportRoundTrip(t *testing.T) {
+	f := isqltest.NewFake("export_round_trip")
+	defer f.Close()
+	f.On(isqltest.Regex(`sqlite_master`)).WillReturnRows([]string{"name", "type", "sql"}, []interface{}{"items", "table", "CREATE TABLE items"})
+	f.On(isqltest.Regex(`table_info`)).WillReturnRows([]string{"cid", "name", "type", "notnull", "dflt_value", "pk"},
+		[]interface{}{int64(0), "id", "INTEGER", int64(1), nil, int64(1)},
+		[]interface{}{int64(1), "big", "BIGINT UNSIGNED", int64(1), nil, int64(0)},
+		[]interface{}{int64(2), "name", "VARCHAR(20)", int64(0), nil, int64(0)},
+		[]interface{}{int64(3), "price", "DECIMAL(10, 2)", int64(0), nil, int64(0)},
+		[]interface{}{int64(4), "at", "DATETIME", int64(0), nil, int64(0)},
+		[]interface{}{int64(5), "data", "BLOB", int64(0), nil, int64(0)},
+		[]interface{}{int64(6), "ok", "BOOLEAN", int64(0), nil, int64(0)},
+		[]interface{}{int64(7), "ttl", "TEXT", int64(0), nil, int64(0)})
+	f.On(isqltest.Regex(`index_list|foreign_key_list`)).WillReturnRows([]string{"seq"})
+	f.On(isqltest.Regex(`^INSERT`)).WillReturnResult(0, 2)
+	db, err := isql.NewOpener().Open(isqltest.DriverName, "export_round_trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	row := []interface{}{int64(12), uint64(18446744073709551615), `a,"b"`, "1.50", at, []byte{1, 2}, true, "1 day"}
+	expected := append(append([]interface{}{}, row...), row...)
+	expected[len(row)+4] = nil
+	tests := []struct {
+		name   string
+		export func(ctx context.Context, w io.Writer, rows isql.Rows, opts export.Options) (int64, error)
+		format importer.Format
+	}{
+		{"csv", export.CSV, importer.CSV},
+		{"ndjson", export.NDJSON, importer.NDJSON},
+	}
+	for _, tt := range tests {
+		b := &bytes.Buffer{}
+		if _, err := tt.export(context.Background(), b, source(t, 2), export.Options{}); err != nil {
+			t.Fatal(err)
+		}
+		s, err := importer.Import(context.Background(), db, b, importer.Config{Dialect: isql.SQLite, Table: "items", Format: tt.format})
+		if err != nil || s.Inserted != 2 {
+			t.Fatalf("%s: summary %+v with error %v", tt.name, s, err)
+		}
+		queries, args := f.Queries(), f.Args()
+		insert := args[len(args)-2]
+		if !strings.HasPrefix(queries[len(queries)-2], `INSERT INTO "items" ("id","big","name","price","at","data","ok","ttl") VALUES`) ||
+			!reflect.DeepEqual(insert, expected) {
+			t.Errorf("%s: inserted %s %v, expected %v", tt.name, queries[len(queries)-2], insert, expected)
+		}
+	}
+}
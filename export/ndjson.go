@@ -0,0 +1,68 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+// NDJSON writes a JSON object per row, one per line, with keys in column order and closes rows, it returns the
+// number of rows written. Times are RFC 3339 strings, bytes standard base64 strings and NaN or infinite floats
+// null.
+func NDJSON(ctx context.Context, w io.Writer, rows isql.Rows, opts Options) (int64, error) {
+	return run(ctx, rows, opts, &ndjsonEncoder{
+		w: bufio.NewWriter(w),
+	})
+}
+
+type ndjsonEncoder struct {
+	w    *bufio.Writer
+	keys [][]byte
+}
+
+func (e *ndjsonEncoder) begin(columns []Column) error {
+	e.keys = make([][]byte, len(columns))
+	for i, c := range columns {
+		key, err := json.Marshal(c.Name)
+		if err != nil {
+			return err
+		}
+		e.keys[i] = append(key, ':')
+	}
+	return nil
+}
+
+func (e *ndjsonEncoder) row(values []interface{}) error {
+	e.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		e.w.Write(e.keys[i])
+		switch x := v.(type) {
+		case float64:
+			if math.IsNaN(x) || math.IsInf(x, 0) {
+				v = nil
+			}
+		case time.Time:
+			v = x.Format(time.RFC3339Nano)
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		e.w.Write(b)
+	}
+	e.w.WriteByte('}')
+	_, err := e.w.WriteString("\n")
+	return err
+}
+
+func (e *ndjsonEncoder) end() error {
+	return e.w.Flush()
+}
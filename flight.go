@@ -0,0 +1,142 @@
+package isql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// flightGroup runs one materializing read per key at a time and shares its result with every caller waiting on
+// it. The read runs on a context detached from any one caller, it is only cancelled when every caller has given
+// up, so each caller's cancellation applies to it alone.
+type flightGroup struct {
+	mtx     sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	sets    []ResultSet
+	err     error
+}
+
+// do returns the result of fn for key and whether it was shared with an execution started by another caller.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) ([]ResultSet, error)) ([]ResultSet, bool, error) {
+	g.mtx.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	f, shared := g.flights[key]
+	if !shared {
+		execCtx, cancel := context.WithCancel(detachedContext{ctx})
+		f = &flight{
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		g.flights[key] = f
+		go func() {
+			f.sets, f.err = fn(execCtx)
+			g.forget(key, f)
+			cancel()
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	g.mtx.Unlock()
+
+	select {
+	case <-f.done:
+		return f.sets, shared, f.err
+	case <-ctx.Done():
+		g.mtx.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+		}
+		g.mtx.Unlock()
+		return nil, shared, ctx.Err()
+	}
+}
+
+func (g *flightGroup) forget(key string, f *flight) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
+
+// detachedContext keeps the values of its parent but not its deadline or cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// queryKey identifies a read by its query text and the driver value of each arg, ok is false if an arg has no
+// driver value, a read with such an arg should not be shared.
+func queryKey(query string, args []interface{}) (string, bool) {
+	b := &strings.Builder{}
+	b.WriteString(strconv.Quote(query))
+	for _, arg := range args {
+		b.WriteByte(',')
+		if err := writeArgKey(b, arg); err != nil {
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// writeArgKey writes arg by its driver value so pointers are keyed by what they point to, not their address.
+func writeArgKey(b *strings.Builder, arg interface{}) error {
+	if named, ok := arg.(sql.NamedArg); ok {
+		b.WriteString(named.Name)
+		b.WriteByte('=')
+		arg = named.Value
+	}
+	v, err := driver.DefaultParameterConverter.ConvertValue(arg)
+	if err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("nil")
+	case []byte:
+		b.WriteString("[]byte:")
+		b.WriteString(hex.EncodeToString(v))
+	case string:
+		b.WriteString("string:")
+		b.WriteString(strconv.Quote(v))
+	case time.Time:
+		b.WriteString("time:")
+		b.WriteString(v.Format(time.RFC3339Nano))
+	default:
+		fmt.Fprintf(b, "%T:%v", v, v)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package isql
+
+// The mocks in package mock are generated from the interfaces declared in this package, run go generate after
+// changing any of them, scripts/check_mocks.sh fails when the committed mocks are stale.
+
+//go:generate -command mockgen go run github.com/golang/mock/mockgen@v1.6.0
+//go:generate mockgen -source=interface.go -destination=mock/mock.go -package=mock
+//go:generate mockgen -source=batch.go -destination=mock/batch.go -package=mock
+//go:generate mockgen -source=bulkhead.go -destination=mock/bulkhead.go -package=mock -aux_files=github.com/0xor1/isql=interface.go
+//go:generate mockgen -source=cache.go -destination=mock/cache.go -package=mock -aux_files=github.com/0xor1/isql=interface.go
+//go:generate mockgen -source=circuit.go -destination=mock/circuit.go -package=mock -aux_files=github.com/0xor1/isql=interface.go
+//go:generate mockgen -source=coalesce.go -destination=mock/coalesce.go -package=mock -aux_files=github.com/0xor1/isql=interface.go
+//go:generate mockgen -source=connector.go -destination=mock/connector.go -package=mock
+//go:generate mockgen -source=credentials.go -destination=mock/credentials.go -package=mock
+//go:generate mockgen -source=dialect.go -destination=mock/dialect.go -package=mock
+//go:generate mockgen -source=shard.go -destination=mock/shard.go -package=mock -aux_files=github.com/0xor1/isql=interface.go
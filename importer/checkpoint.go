@@ -0,0 +1,65 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/0xor1/isql"
+)
+
+// Checkpoint records how many source records have been imported so an interrupted import can resume after them.
+type Checkpoint interface {
+	// Load returns the number of source records already imported, 0 if none.
+	Load(ctx context.Context) (int64, error)
+	// Save is called after each batch commits with the number of source records imported so far, including
+	// rejected ones. If the import stops between a commit and its Save the batch is repeated on resume.
+	Save(ctx context.Context, records int64) error
+}
+
+// TxCheckpoint is a Checkpoint which is saved in each batch's transaction, making resumed imports exactly once,
+// e.g. one kept in a table of the target database. SaveTx is called before the batch commits instead of Save.
+type TxCheckpoint interface {
+	Checkpoint
+	SaveTx(ctx context.Context, tx isql.Tx, records int64) error
+}
+
+// FileCheckpoint returns a Checkpoint kept in the file at path, which is replaced atomically on each save.
+func FileCheckpoint(path string) Checkpoint {
+	return &fileCheckpoint{
+		path: path,
+	}
+}
+
+type fileCheckpoint struct {
+	path string
+}
+
+func (c *fileCheckpoint) Load(ctx context.Context) (int64, error) {
+	b, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+}
+
+func (c *fileCheckpoint) Save(ctx context.Context, records int64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(strconv.FormatInt(records, 10) + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
@@ -0,0 +1,176 @@
+package importer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/schema"
+)
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindFloat
+	kindDecimal
+	kindBool
+	kindTime
+	kindBytes
+	kindJSON
+)
+
+func kindOf(c schema.Column) kind {
+	dbType := c.Type.DatabaseType
+	switch {
+	case isql.IsIntegerType(dbType):
+		return kindInt
+	case strings.Contains(dbType, "FLOAT") || strings.Contains(dbType, "DOUBLE") || dbType == "REAL":
+		return kindFloat
+	case dbType == "DECIMAL" || dbType == "NUMERIC" || strings.Contains(dbType, "MONEY"):
+		return kindDecimal
+	case strings.Contains(dbType, "BOOL") || dbType == "BIT":
+		return kindBool
+	case strings.Contains(dbType, "TIME") || dbType == "DATE":
+		return kindTime
+	case strings.Contains(dbType, "BLOB") || strings.Contains(dbType, "BINARY") || dbType == "BYTEA" || dbType == "IMAGE":
+		return kindBytes
+	case dbType == "JSON" || dbType == "JSONB":
+		return kindJSON
+	}
+	return kindString
+}
+
+// timeLayouts are the accepted text forms of times, RFC 3339 as written by the export package first.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// convert converts a source value, a string from CSV or a decoded JSON value, to the value inserted into t's
+// column. An empty CSV field is NULL unless the column is a string column, NULL is an error if the column is NOT
+// NULL and not generated.
+func convert(v interface{}, t target) (interface{}, error) {
+	c, k := t.column, t.kind
+	if s, ok := v.(string); ok && s == "" && k != kindString && k != kindBytes {
+		v = nil
+	}
+	if v == nil {
+		if !c.Type.Nullable && !t.generated {
+			return nil, fmt.Errorf("column %s is NOT NULL", c.Name)
+		}
+		return nil, nil
+	}
+	res, err := convertValue(v, k)
+	if err != nil {
+		return nil, fmt.Errorf("column %s: %s", c.Name, err)
+	}
+	if s, ok := res.(string); ok && c.Type.HasLength && c.Type.Length > 0 && int64(utf8.RuneCountInString(s)) > c.Type.Length {
+		return nil, fmt.Errorf("column %s: value is longer than %d", c.Name, c.Type.Length)
+	}
+	return res, nil
+}
+
+func convertValue(v interface{}, k kind) (interface{}, error) {
+	switch k {
+	case kindInt:
+		switch x := v.(type) {
+		case string:
+			return parseInt(strings.TrimSpace(x))
+		case json.Number:
+			return parseInt(x.String())
+		}
+	case kindFloat:
+		switch x := v.(type) {
+		case string:
+			return strconv.ParseFloat(strings.TrimSpace(x), 64)
+		case json.Number:
+			return x.Float64()
+		}
+	case kindDecimal:
+		// decimals are passed as text so no precision is lost, they are only checked to be numbers
+		var s string
+		switch x := v.(type) {
+		case string:
+			s = strings.TrimSpace(x)
+		case json.Number:
+			s = x.String()
+		}
+		if s != "" {
+			if _, err := strconv.ParseFloat(s, 64); err != nil {
+				return nil, fmt.Errorf("invalid decimal %q", s)
+			}
+			return s, nil
+		}
+	case kindBool:
+		switch x := v.(type) {
+		case string:
+			return strconv.ParseBool(strings.TrimSpace(x))
+		case bool:
+			return x, nil
+		case json.Number:
+			return strconv.ParseBool(x.String())
+		}
+	case kindTime:
+		if s, ok := v.(string); ok {
+			for _, layout := range timeLayouts {
+				if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+					return t, nil
+				}
+			}
+			return nil, fmt.Errorf("invalid time %q", s)
+		}
+	case kindBytes:
+		// bytes are base64 as written by the export package
+		if s, ok := v.(string); ok {
+			return base64.StdEncoding.DecodeString(s)
+		}
+	case kindJSON:
+		if s, ok := v.(string); ok {
+			if !json.Valid([]byte(s)) {
+				return nil, fmt.Errorf("invalid json")
+			}
+			return s, nil
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	default:
+		switch x := v.(type) {
+		case string:
+			return x, nil
+		case json.Number:
+			return x.String(), nil
+		case bool:
+			return strconv.FormatBool(x), nil
+		}
+	}
+	return nil, fmt.Errorf("can't convert %T to %s", v, kindName(k))
+}
+
+// parseInt parses s as an int64 or, if it is larger, a uint64 for unsigned BIGINT columns, which the export
+// package writes as strings.
+func parseInt(s string) (interface{}, error) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err == nil {
+		return i, nil
+	}
+	if u, uErr := strconv.ParseUint(s, 10, 64); uErr == nil {
+		return u, nil
+	}
+	return nil, err
+}
+
+func kindName(k kind) string {
+	return [...]string{"string", "int", "float", "decimal", "bool", "time", "bytes", "json"}[k]
+}
@@ -0,0 +1,487 @@
+// Package importer loads CSV or NDJSON (JSON Lines) into a table. Fields are mapped onto the table's columns,
+// which are introspected with the schema package, converted to each column's type and inserted with
+// isql.BatchInsert in batches, each in its own transaction. Bad records can abort the import, be skipped or be
+// written to a dead letter writer, and a Checkpoint lets an interrupted import resume where it stopped.
+//
+// CSV and NDJSON written by the export package import as is, times are RFC 3339 and bytes base64.
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/schema"
+)
+
+const defaultBatchSize = 1000
+
+var ErrTooManyRejected = errors.New("importer: too many rejected records")
+
+type Format int
+
+const (
+	CSV Format = iota
+	NDJSON
+)
+
+// OnError is what happens to a record which can't be parsed, converted or inserted.
+type OnError int
+
+const (
+	// Abort stops the import with a *RecordError, batches already committed stay committed. A batch which fails to
+	// insert is retried row by row to find the record to blame, committing the rows before it.
+	Abort OnError = iota
+	// Skip counts the record as rejected and carries on.
+	Skip
+	// DeadLetter is Skip which also writes the record to Config.DeadLetter.
+	DeadLetter
+)
+
+type Config struct {
+	Dialect isql.Dialect
+	// Schema is the target table's schema, empty for the current one.
+	Schema string
+	Table  string
+	Format Format
+	// CSVHeader names the fields of CSV which has no header row, if nil the first row is the header.
+	CSVHeader []string
+	// CSVComma is the field delimiter, ',' if 0.
+	CSVComma rune
+	// Mapping maps source field names to column names, only mapped fields are imported. If nil fields are
+	// matched to columns by case insensitive name and every field must match one unless IgnoreUnknown is set,
+	// NDJSON then targets every column. Columns a record has no field for are left out of its INSERT so they
+	// get their default or NULL, as are NOT NULL columns the database may generate, those with a default or a
+	// single integer primary key, whose field is NULL.
+	Mapping       map[string]string
+	IgnoreUnknown bool
+	// BatchSize is the number of records per batch and transaction, 1000 if <= 0.
+	BatchSize int
+	TxOptions *sql.TxOptions
+	OnError   OnError
+	// MaxRejected if > 0 aborts the import with ErrTooManyRejected once more records than it are rejected.
+	MaxRejected int64
+	// DeadLetter receives a JSON line per rejected record when OnError is DeadLetter, with the 1 based record
+	// number, the error and the record as read, e.g. {"record":7,"error":"column id: ...","data":["x","y"]}.
+	DeadLetter io.Writer
+	// Checkpoint if set is loaded to skip records imported by a previous run and saved after each batch.
+	Checkpoint Checkpoint
+	// Progress if set is called after each batch.
+	Progress func(Summary)
+}
+
+type Summary struct {
+	// Read is the number of records read from the source, including skipped ones.
+	Read     int64
+	Inserted int64
+	Rejected int64
+	// Skipped is the number of records skipped as already imported according to the Checkpoint.
+	Skipped int64
+}
+
+// RecordError is returned when a record is rejected and OnError is Abort.
+type RecordError struct {
+	// Record is the 1 based number of the record in the source.
+	Record int64
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("importer: record %d: %s", e.Record, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Import reads every record from r into the table described by cfg and returns a summary of what it did, which
+// is valid even when an error is returned.
+func Import(ctx context.Context, db isql.DB, r io.Reader, cfg Config) (Summary, error) {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.OnError == DeadLetter && cfg.DeadLetter == nil {
+		return Summary{}, errors.New("importer: DeadLetter requires Config.DeadLetter")
+	}
+	table, err := schema.ReadTable(ctx, db, cfg.Dialect, cfg.Schema, cfg.Table)
+	if err != nil {
+		return Summary{}, err
+	}
+	var src source
+	if cfg.Format == CSV {
+		if src, err = newCSVSource(r, cfg.CSVHeader, cfg.CSVComma); err != nil {
+			return Summary{}, err
+		}
+	} else {
+		src = newNDJSONSource(r)
+	}
+	im := &importer{
+		ctx: ctx,
+		db:  db,
+		cfg: cfg,
+	}
+	if err := im.mapColumns(table, src.fieldNames()); err != nil {
+		return Summary{}, err
+	}
+	if cfg.Checkpoint != nil {
+		if im.saved, err = cfg.Checkpoint.Load(ctx); err != nil {
+			return Summary{}, err
+		}
+	}
+	err = im.run(src)
+	return im.summary, err
+}
+
+type importer struct {
+	ctx       context.Context
+	db        isql.DB
+	cfg       Config
+	tableName string
+	columns   []string
+	targets   []target
+	// fields maps source field names to indexes in columns and targets, -1 for ignored fields.
+	fields  map[string]int
+	summary Summary
+	batch   []pendingRow
+	// consumed is the number of source records dealt with, saved the number recorded by the checkpoint.
+	consumed int64
+	saved    int64
+}
+
+type target struct {
+	column schema.Column
+	kind   kind
+	// generated is set for columns the database may fill in when they are left out of the INSERT.
+	generated bool
+}
+
+type pendingRow struct {
+	record int64
+	// values are the values of the columns not omitted, omit is nil if no column is.
+	values []interface{}
+	omit   []bool
+	raw    interface{}
+}
+
+// mapColumns resolves the columns to insert and checks every NOT NULL column which is not generated is among
+// them.
+func (im *importer) mapColumns(table *schema.Table, fieldNames []string) error {
+	im.tableName = table.Name
+	if im.cfg.Schema != "" {
+		im.tableName = im.cfg.Schema + "." + table.Name
+	}
+	lookup := func(name string) *schema.Column {
+		for i := range table.Columns {
+			if strings.EqualFold(table.Columns[i].Name, name) {
+				return &table.Columns[i]
+			}
+		}
+		return nil
+	}
+	im.fields = map[string]int{}
+	add := func(field string, c *schema.Column) {
+		for i, existing := range im.columns {
+			if existing == c.Name {
+				im.fields[field] = i
+				return
+			}
+		}
+		im.fields[field] = len(im.columns)
+		im.columns = append(im.columns, c.Name)
+		im.targets = append(im.targets, target{
+			column:    *c,
+			kind:      kindOf(*c),
+			generated: generated(table, *c),
+		})
+	}
+	switch {
+	case im.cfg.Mapping != nil:
+		fields := make([]string, 0, len(im.cfg.Mapping))
+		for field := range im.cfg.Mapping {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			c := lookup(im.cfg.Mapping[field])
+			if c == nil {
+				return fmt.Errorf("importer: field %s is mapped to %s which is not a column of %s", field, im.cfg.Mapping[field], table.Name)
+			}
+			add(field, c)
+		}
+	case fieldNames != nil:
+		for _, field := range fieldNames {
+			c := lookup(field)
+			if c == nil {
+				if !im.cfg.IgnoreUnknown {
+					return fmt.Errorf("importer: field %s is not a column of %s", field, table.Name)
+				}
+				im.fields[field] = -1
+				continue
+			}
+			add(field, c)
+		}
+	default:
+		for i := range table.Columns {
+			add(table.Columns[i].Name, &table.Columns[i])
+		}
+	}
+	if len(im.columns) == 0 {
+		return fmt.Errorf("importer: no fields map to a column of %s", table.Name)
+	}
+	for _, c := range table.Columns {
+		if c.Type.Nullable || generated(table, c) {
+			continue
+		}
+		if !im.hasColumn(c.Name) {
+			return fmt.Errorf("importer: NOT NULL column %s of %s has no field", c.Name, table.Name)
+		}
+	}
+	return nil
+}
+
+// generated reports whether the database may fill in c when it is left out of an INSERT, because it has a
+// default or is the table's only primary key column and an integer, which SQLite's rowid, MySQL's AUTO_INCREMENT
+// and SQL Server's IDENTITY generate without the catalog reporting a default.
+func generated(table *schema.Table, c schema.Column) bool {
+	if c.HasDefault {
+		return true
+	}
+	return len(table.PrimaryKey) == 1 && strings.EqualFold(table.PrimaryKey[0], c.Name) && isql.IsIntegerType(c.Type.DatabaseType)
+}
+
+func (im *importer) hasColumn(name string) bool {
+	for _, c := range im.columns {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (im *importer) run(src source) error {
+	for {
+		if err := im.ctx.Err(); err != nil {
+			return err
+		}
+		rec, err := src.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		im.summary.Read++
+		if im.summary.Read <= im.saved {
+			im.summary.Skipped++
+			im.consumed = im.summary.Read
+			continue
+		}
+		values, omit, err := im.values(rec)
+		if err != nil {
+			if err := im.reject(im.summary.Read, rec.raw, err); err != nil {
+				return err
+			}
+		} else {
+			im.batch = append(im.batch, pendingRow{
+				record: im.summary.Read,
+				values: values,
+				omit:   omit,
+				raw:    rec.raw,
+			})
+		}
+		im.consumed = im.summary.Read
+		if len(im.batch) == im.cfg.BatchSize {
+			if err := im.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return im.flush()
+}
+
+// values converts rec to a value per column which is not omitted, omit is nil unless a column has no field in
+// rec or is a generated column whose field is NULL.
+func (im *importer) values(rec record) (values []interface{}, omit []bool, err error) {
+	if rec.err != nil {
+		return nil, nil, rec.err
+	}
+	raw := make([]interface{}, len(im.columns))
+	present := make([]bool, len(im.columns))
+	for field, v := range rec.fields {
+		i, ok := im.fields[field]
+		if !ok && im.cfg.Mapping == nil {
+			// NDJSON fields are only known per record
+			for name, idx := range im.fields {
+				if strings.EqualFold(name, field) {
+					i, ok = idx, true
+				}
+			}
+		}
+		if !ok {
+			if im.cfg.Mapping == nil && !im.cfg.IgnoreUnknown {
+				return nil, nil, fmt.Errorf("field %s is not a column", field)
+			}
+			continue
+		}
+		if i >= 0 {
+			raw[i] = v
+			present[i] = true
+		}
+	}
+	values = make([]interface{}, 0, len(im.columns))
+	for i, t := range im.targets {
+		v := interface{}(nil)
+		if present[i] {
+			if v, err = convert(raw[i], t); err != nil {
+				return nil, nil, err
+			}
+		}
+		if !present[i] || v == nil && !t.column.Type.Nullable {
+			if omit == nil {
+				omit = make([]bool, len(im.columns))
+			}
+			omit[i] = true
+			continue
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		return nil, nil, errors.New("no field maps to a column")
+	}
+	return values, omit, nil
+}
+
+func (im *importer) reject(record int64, raw interface{}, err error) error {
+	if im.cfg.OnError == Abort {
+		return &RecordError{
+			Record: record,
+			Err:    err,
+		}
+	}
+	im.summary.Rejected++
+	if im.cfg.OnError == DeadLetter {
+		line, mErr := json.Marshal(struct {
+			Record int64       `json:"record"`
+			Error  string      `json:"error"`
+			Data   interface{} `json:"data"`
+		}{record, err.Error(), raw})
+		if mErr != nil {
+			return mErr
+		}
+		if _, wErr := im.cfg.DeadLetter.Write(append(line, '\n')); wErr != nil {
+			return wErr
+		}
+	}
+	if im.cfg.MaxRejected > 0 && im.summary.Rejected > im.cfg.MaxRejected {
+		return ErrTooManyRejected
+	}
+	return nil
+}
+
+// flush inserts the batch in a transaction which also saves the checkpoint. If the insert fails each row is retried
+// in its own transaction so only the rows which fail are rejected.
+func (im *importer) flush() error {
+	if len(im.batch) == 0 && im.consumed == im.saved {
+		return nil
+	}
+	batch := im.batch
+	im.batch = im.batch[:0]
+	inserted, committed, err := im.insert(batch, im.consumed)
+	if err == nil {
+		im.summary.Inserted += inserted
+	} else {
+		if committed || len(batch) == 0 || im.ctx.Err() != nil {
+			return err
+		}
+		for i, row := range batch {
+			// the last row also records any rejected records after it
+			records := row.record
+			if i == len(batch)-1 {
+				records = im.consumed
+			}
+			n, committed, err := im.insert(batch[i:i+1], records)
+			if committed && err != nil {
+				return err
+			}
+			if err != nil {
+				if err := im.reject(row.record, row.raw, err); err != nil {
+					return err
+				}
+				continue
+			}
+			im.summary.Inserted += n
+		}
+		// a rejected last row leaves the records after the last committed one unsaved, save them on their own so a
+		// resumed import does not reject them again
+		if im.saved < im.consumed {
+			if _, _, err := im.insert(nil, im.consumed); err != nil {
+				return err
+			}
+		}
+	}
+	if im.cfg.Progress != nil {
+		im.cfg.Progress(im.summary)
+	}
+	return nil
+}
+
+// insert inserts rows in a transaction and saves records as the checkpoint, committed reports whether the rows
+// were committed, in which case an error is from saving the checkpoint afterwards. Consecutive rows which omit
+// the same columns are inserted together.
+func (im *importer) insert(rows []pendingRow, records int64) (inserted int64, committed bool, err error) {
+	tx, err := im.db.BeginTx(im.ctx, im.cfg.TxOptions)
+	if err != nil {
+		return 0, false, err
+	}
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && reflect.DeepEqual(rows[end].omit, rows[start].omit) {
+			end++
+		}
+		columns := im.columns
+		if omit := rows[start].omit; omit != nil {
+			columns = make([]string, 0, len(im.columns))
+			for i, column := range im.columns {
+				if !omit[i] {
+					columns = append(columns, column)
+				}
+			}
+		}
+		values := make([][]interface{}, 0, end-start)
+		for _, row := range rows[start:end] {
+			values = append(values, row.values)
+		}
+		n, err := isql.BatchInsert(im.ctx, tx, im.cfg.Dialect, im.tableName, columns, isql.NewSliceRowSource(values))
+		if err != nil {
+			tx.Rollback()
+			return 0, false, err
+		}
+		inserted += n
+		start = end
+	}
+	txCheckpoint, isTx := im.cfg.Checkpoint.(TxCheckpoint)
+	if isTx {
+		if err := txCheckpoint.SaveTx(im.ctx, tx, records); err != nil {
+			tx.Rollback()
+			return 0, false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+	im.saved = records
+	if im.cfg.Checkpoint != nil && !isTx {
+		if err := im.cfg.Checkpoint.Save(im.ctx, records); err != nil {
+			im.summary.Inserted += inserted
+			return inserted, true, err
+		}
+	}
+	return inserted, true, nil
+}
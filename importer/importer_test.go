@@ -0,0 +1,193 @@
+package importer_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/importer"
+	"github.com/0xor1/isql/isqltest"
+)
+
+// open returns a Fake with a users table, id is an integer primary key SQLite generates and score has a default.
+func open(t *testing.T, name string) (isqltest.Fake, isql.DB) {
+	t.Helper()
+	f := isqltest.NewFake(name)
+	t.Cleanup(f.Close)
+	f.On(isqltest.Regex(`sqlite_master`)).WillReturnRows([]string{"name", "type", "sql"}, []interface{}{"users", "table", "CREATE TABLE users"})
+	f.On(isqltest.Regex(`table_info`)).WillReturnRows([]string{"cid", "name", "type", "notnull", "dflt_value", "pk"},
+		[]interface{}{int64(0), "id", "INTEGER", int64(1), nil, int64(1)},
+		[]interface{}{int64(1), "name", "VARCHAR(5)", int64(0), nil, int64(0)},
+		[]interface{}{int64(2), "at", "DATETIME", int64(0), nil, int64(0)},
+		[]interface{}{int64(3), "score", "DECIMAL(5, 2)", int64(1), "0", int64(0)},
+		[]interface{}{int64(4), "org", "BIGINT UNSIGNED", int64(1), nil, int64(0)})
+	f.On(isqltest.Regex(`index_list|foreign_key_list`)).WillReturnRows([]string{"seq"})
+	// multi row inserts fail so batches are retried row by row, and a score without an id fails so a single row
+	// can be rejected by the database
+	f.On(isqltest.Regex(`\),\(`)).WillReturnError(errors.New("constraint failed"))
+	f.On(isqltest.Exact(`INSERT INTO "users" ("name","score","org") VALUES (?,?,?)`)).WillReturnError(errors.New("check failed"))
+	f.On(isqltest.Regex(`^INSERT`)).WillReturnResult(0, 1)
+	db, err := isql.NewOpener().Open(isqltest.DriverName, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return f, db
+}
+
+type insert struct {
+	query string
+	args  []interface{}
+}
+
+// inserts returns the successful single row inserts run against f.
+func inserts(f isqltest.Fake) []insert {
+	var res []insert
+	args := f.Args()
+	for i, q := range f.Queries() {
+		if strings.HasPrefix(q, "INSERT") && !strings.Contains(q, "),(") {
+			res = append(res, insert{q, args[i]})
+		}
+	}
+	return res
+}
+
+func TestImport(t *testing.T) {
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string
+		in      string
+		cfg     importer.Config
+		summary importer.Summary
+		inserts []insert
+	}{
+		{"csv", "id,name,at,org\n1,a,2020-01-02T03:04:05Z,18446744073709551615\nx,b,,1\n3,toolong,,1\n,d,2020-01-02,1\n",
+			importer.Config{OnError: importer.Skip, BatchSize: 10},
+			importer.Summary{Read: 4, Inserted: 2, Rejected: 2},
+			[]insert{
+				{`INSERT INTO "users" ("id","name","at","org") VALUES (?,?,?,?)`, []interface{}{int64(1), "a", at, uint64(18446744073709551615)}},
+				// the empty id is left out so SQLite generates it
+				{`INSERT INTO "users" ("name","at","org") VALUES (?,?,?)`, []interface{}{"d", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), int64(1)}},
+			}},
+		{"ndjson", `{"name":"a","org":1}` + "\n\n" + `{"ID":2,"name":null,"score":1.5,"org":2}` + "\n" + `{"id":null,"org":3}` + "\n" + `{"org":null}` + "\n",
+			importer.Config{Format: importer.NDJSON, OnError: importer.Skip},
+			importer.Summary{Read: 4, Inserted: 3, Rejected: 1},
+			[]insert{
+				{`INSERT INTO "users" ("name","org") VALUES (?,?)`, []interface{}{"a", int64(1)}},
+				{`INSERT INTO "users" ("id","name","score","org") VALUES (?,?,?,?)`, []interface{}{int64(2), nil, "1.5", int64(2)}},
+				{`INSERT INTO "users" ("org") VALUES (?)`, []interface{}{int64(3)}},
+			}},
+		{"mapping", `{"user":"a","o":1,"extra":true}` + "\n",
+			importer.Config{Format: importer.NDJSON, Mapping: map[string]string{"user": "name", "o": "org"}},
+			importer.Summary{Read: 1, Inserted: 1},
+			[]insert{{`INSERT INTO "users" ("org","name") VALUES (?,?)`, []interface{}{int64(1), "a"}}}},
+		{"ignore unknown", "name,org,extra\na,1,x\n",
+			importer.Config{IgnoreUnknown: true},
+			importer.Summary{Read: 1, Inserted: 1},
+			[]insert{{`INSERT INTO "users" ("name","org") VALUES (?,?)`, []interface{}{"a", int64(1)}}}},
+	}
+	for _, tt := range tests {
+		f, db := open(t, "importer")
+		tt.cfg.Dialect, tt.cfg.Table = isql.SQLite, "users"
+		s, err := importer.Import(context.Background(), db, strings.NewReader(tt.in), tt.cfg)
+		if err != nil || s != tt.summary {
+			t.Errorf("%s: summary %+v with error %v, expected %+v", tt.name, s, err, tt.summary)
+			continue
+		}
+		if ins := inserts(f); !reflect.DeepEqual(ins, tt.inserts) {
+			t.Errorf("%s: inserted %v, expected %v", tt.name, ins, tt.inserts)
+		}
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		cfg  importer.Config
+		err  string
+	}{
+		{"missing column", "name\na\n", importer.Config{}, "importer: NOT NULL column org of users has no field"},
+		{"unknown field", "name,org,extra\na,1,x\n", importer.Config{}, "importer: field extra is not a column of users"},
+		{"bad mapping", "a\n1\n", importer.Config{Mapping: map[string]string{"a": "b"}}, "importer: field a is mapped to b which is not a column of users"},
+		{"record", "name,org\na,1\nb,\n", importer.Config{}, "importer: record 2: column org is NOT NULL"},
+		{"insert", "name,score,org\na,,1\nb,1,2\n", importer.Config{BatchSize: 2}, "importer: record 2: check failed"},
+		{"no columns", "{}\n", importer.Config{Format: importer.NDJSON}, "importer: record 1: no field maps to a column"},
+		{"too many rejected", "name,org\na,x\nb,y\n", importer.Config{OnError: importer.Skip, MaxRejected: 1}, importer.ErrTooManyRejected.Error()},
+		{"dead letter writer", "name,org\n", importer.Config{OnError: importer.DeadLetter}, "importer: DeadLetter requires Config.DeadLetter"},
+	}
+	for _, tt := range tests {
+		_, db := open(t, "importer_errors")
+		tt.cfg.Dialect, tt.cfg.Table = isql.SQLite, "users"
+		if _, err := importer.Import(context.Background(), db, strings.NewReader(tt.in), tt.cfg); err == nil || err.Error() != tt.err {
+			t.Errorf("%s: error %v, expected %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestImportCheckpoint(t *testing.T) {
+	f, db := open(t, "importer_checkpoint")
+	dl := &bytes.Buffer{}
+	cp := importer.FileCheckpoint(filepath.Join(t.TempDir(), "checkpoint"))
+	in := "name,org\na,1\nb,x\nc,3\n"
+	cfg := importer.Config{Dialect: isql.SQLite, Table: "users", OnError: importer.DeadLetter, DeadLetter: dl, BatchSize: 1, Checkpoint: cp}
+	s, err := importer.Import(context.Background(), db, strings.NewReader(in), cfg)
+	if err != nil || s != (importer.Summary{Read: 3, Inserted: 2, Rejected: 1}) {
+		t.Fatalf("summary %+v with error %v", s, err)
+	}
+	if expected := `{"record":2,"error":"column org: strconv.ParseInt: parsing \"x\": invalid syntax","data":["b","x"]}` + "\n"; dl.String() != expected {
+		t.Fatalf("dead letters %s, expected %s", dl.String(), expected)
+	}
+	if n, err := cp.Load(context.Background()); err != nil || n != 3 {
+		t.Fatalf("checkpoint %d with error %v", n, err)
+	}
+	s, err = importer.Import(context.Background(), db, strings.NewReader(in+"d,4\n"), cfg)
+	if err != nil || s != (importer.Summary{Read: 4, Inserted: 1, Skipped: 3}) {
+		t.Fatalf("summary %+v with error %v, expected the imported records to be skipped", s, err)
+	}
+	if ins := inserts(f); len(ins) != 3 || ins[2].args[0] != "d" {
+		t.Fatalf("inserted %v", ins)
+	}
+}
+
+func TestImportRejectedTail(t *testing.T) {
+	_, db := open(t, "importer_rejected_tail")
+	in := "name,score,org\na,,1\nb,1,2\n"
+	cp := importer.FileCheckpoint(filepath.Join(t.TempDir(), "abort"))
+	cfg := importer.Config{Dialect: isql.SQLite, Table: "users", BatchSize: 2, Checkpoint: cp}
+	var recordErr *importer.RecordError
+	s, err := importer.Import(context.Background(), db, strings.NewReader(in), cfg)
+	if !errors.As(err, &recordErr) || recordErr.Record != 2 || s != (importer.Summary{Read: 2, Inserted: 1}) {
+		t.Fatalf("summary %+v with error %v, expected record 2 to abort the import after record 1 is inserted", s, err)
+	}
+	if n, err := cp.Load(context.Background()); err != nil || n != 1 {
+		t.Fatalf("checkpoint %d with error %v, expected the import to resume at the aborting record", n, err)
+	}
+
+	// the last row of the batch is rejected, the checkpoint must still cover it so a resumed import doesn't
+	// dead letter it again
+	dl := &bytes.Buffer{}
+	cfg.OnError, cfg.DeadLetter, cfg.Checkpoint = importer.DeadLetter, dl, importer.FileCheckpoint(filepath.Join(t.TempDir(), "dead_letter"))
+	s, err = importer.Import(context.Background(), db, strings.NewReader(in), cfg)
+	if err != nil || s != (importer.Summary{Read: 2, Inserted: 1, Rejected: 1}) {
+		t.Fatalf("summary %+v with error %v", s, err)
+	}
+	if n, err := cfg.Checkpoint.Load(context.Background()); err != nil || n != 2 {
+		t.Fatalf("checkpoint %d with error %v, expected the rejected last record to be saved", n, err)
+	}
+	s, err = importer.Import(context.Background(), db, strings.NewReader(in+"c,,3\n"), cfg)
+	if err != nil || s != (importer.Summary{Read: 3, Inserted: 1, Skipped: 2}) {
+		t.Fatalf("summary %+v with error %v, expected the rejected record to be skipped", s, err)
+	}
+	if lines := strings.Count(dl.String(), "\n"); lines != 1 {
+		t.Fatalf("dead letters %s, expected record 2 to be written once", dl.String())
+	}
+}
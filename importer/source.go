@@ -0,0 +1,128 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// record is one source record, fields are strings from CSV and decoded JSON values from NDJSON, raw is the
+// record as read for dead lettering.
+type record struct {
+	fields map[string]interface{}
+	raw    interface{}
+	err    error
+}
+
+type source interface {
+	// fieldNames returns the fields every record has, nil if records vary.
+	fieldNames() []string
+	// next returns the next record, one with err set if it could not be parsed, or io.EOF.
+	next() (record, error)
+}
+
+type csvSource struct {
+	r      *csv.Reader
+	header []string
+}
+
+func newCSVSource(r io.Reader, header []string, comma rune) (*csvSource, error) {
+	s := &csvSource{
+		r:      csv.NewReader(r),
+		header: header,
+	}
+	if comma != 0 {
+		s.r.Comma = comma
+	}
+	s.r.ReuseRecord = true
+	if s.header == nil {
+		h, err := s.r.Read()
+		if err == io.EOF {
+			return nil, errors.New("importer: csv has no header")
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.header = append([]string{}, h...)
+	}
+	s.r.FieldsPerRecord = len(s.header)
+	return s, nil
+}
+
+func (s *csvSource) fieldNames() []string {
+	return s.header
+}
+
+func (s *csvSource) next() (record, error) {
+	fields, err := s.r.Read()
+	if err == io.EOF {
+		return record{}, io.EOF
+	}
+	raw := append([]string{}, fields...)
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return record{
+			raw: raw,
+			err: err,
+		}, nil
+	}
+	if err != nil {
+		return record{}, err
+	}
+	rec := record{
+		fields: make(map[string]interface{}, len(fields)),
+		raw:    raw,
+	}
+	for i, name := range s.header {
+		rec.fields[name] = fields[i]
+	}
+	return rec, nil
+}
+
+type ndjsonSource struct {
+	r *bufio.Reader
+}
+
+func newNDJSONSource(r io.Reader) *ndjsonSource {
+	return &ndjsonSource{
+		r: bufio.NewReader(r),
+	}
+}
+
+func (s *ndjsonSource) fieldNames() []string {
+	return nil
+}
+
+func (s *ndjsonSource) next() (record, error) {
+	for {
+		line, err := s.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return record{}, err
+		}
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) == 0 {
+			if err == io.EOF {
+				return record{}, io.EOF
+			}
+			// blank lines are not records
+			continue
+		}
+		rec := record{
+			raw: json.RawMessage(append([]byte{}, trimmed...)),
+		}
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.UseNumber()
+		if decErr := dec.Decode(&rec.fields); decErr != nil {
+			rec.fields = nil
+			rec.raw = string(trimmed)
+			rec.err = fmt.Errorf("invalid json: %s", decErr)
+		} else if rec.fields == nil {
+			rec.err = errors.New("json record is not an object")
+		}
+		return rec, nil
+	}
+}
@@ -9,28 +9,35 @@ import (
 	"time"
 )
 
-func NewOpener() Opener {
-	return &opener{}
+func NewOpener(opts ...Option) Opener {
+	return &opener{
+		opts: opts,
+	}
 }
 
 type Opener interface {
 	Open(driverName, dataSourceName string) (DB, error)
+	OpenConnector(connector driver.Connector) (DB, error)
+	OpenConfig(cfg Config) (DB, error)
 }
 
-func NewDB(db *sql.DB) DB {
+func NewDB(db *sql.DB, opts ...Option) DB {
 	if db == nil {
 		return nil
 	}
-	return &dbWrapper{
-		db: db,
-	}
+	return newDB(db, newOptions(opts))
 }
 
+type Option func(*options)
+
 type DB interface {
 	DBCore
 	Begin() (Tx, error)
 	BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error)
 	Close() error
+	// Conn pins a single connection from the pool, e.g. to hold a session level lock across transactions, it must
+	// be closed to return the connection.
+	Conn(ctx context.Context) (PinnedConn, error)
 	Driver() driver.Driver
 	Exec(query string, args ...interface{}) (sql.Result, error)
 	Ping() error
@@ -39,15 +46,23 @@ type DB interface {
 	PrepareContext(ctx context.Context, query string) (Stmt, error)
 	Query(query string, args ...interface{}) (Rows, error)
 	QueryRow(query string, args ...interface{}) Row
+	SetConnMaxIdleTime(d time.Duration)
 	SetConnMaxLifetime(d time.Duration)
 	SetMaxIdleConns(n int)
 	SetMaxOpenConns(n int)
 	Stats() sql.DBStats
+	StmtCacheStats() StmtCacheStats
 }
 
 func NewReplicaSet(driverName, primaryDataSourceName string, slaveDataSourceNames ...string) (ReplicaSet, error) {
 	op := &opener{}
-	primary, err := op.Open(driverName, primaryDataSourceName)
+	return NewReplicaSetFromOpeners(op, op, driverName, primaryDataSourceName, slaveDataSourceNames...)
+}
+
+// NewReplicaSetFromOpeners opens the primary with primaryOpener and every slave with slaveOpener so they can be
+// given different options, e.g. different WithOnConnect callbacks.
+func NewReplicaSetFromOpeners(primaryOpener, slaveOpener Opener, driverName, primaryDataSourceName string, slaveDataSourceNames ...string) (ReplicaSet, error) {
+	primary, err := primaryOpener.Open(driverName, primaryDataSourceName)
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +71,7 @@ func NewReplicaSet(driverName, primaryDataSourceName string, slaveDataSourceName
 		slaves:  make([]DBCore, 0, len(slaveDataSourceNames)),
 	}
 	for _, slaveDataSourceName := range slaveDataSourceNames {
-		slave, err := op.Open(driverName, slaveDataSourceName)
+		slave, err := slaveOpener.Open(driverName, slaveDataSourceName)
 		if err != nil {
 			return nil, err
 		}
@@ -65,6 +80,35 @@ func NewReplicaSet(driverName, primaryDataSourceName string, slaveDataSourceName
 	return rs, nil
 }
 
+// NewReplicaSetFromConnectors opens every member with opener from its own connector, e.g. a CredentialConnector
+// per member so each picks up rotated credentials.
+func NewReplicaSetFromConnectors(opener Opener, primary driver.Connector, slaves ...driver.Connector) (ReplicaSet, error) {
+	primaryDB, err := opener.OpenConnector(primary)
+	if err != nil {
+		return nil, err
+	}
+	rs := &replicaSet{
+		primary: primaryDB,
+		slaves:  make([]DBCore, 0, len(slaves)),
+	}
+	for _, slave := range slaves {
+		slaveDB, err := opener.OpenConnector(slave)
+		if err != nil {
+			return nil, err
+		}
+		rs.slaves = append(rs.slaves, slaveDB)
+	}
+	return rs, nil
+}
+
+// NewReplicaSetFromMembers allows members to be decorated before being grouped into a ReplicaSet.
+func NewReplicaSetFromMembers(primary DBCore, slaves ...DBCore) ReplicaSet {
+	return &replicaSet{
+		primary: primary,
+		slaves:  slaves,
+	}
+}
+
 func MustNewReplicaSet(driverName, primaryDataSourceName string, slaveDataSourceNames ...string) ReplicaSet {
 	rs, err := NewReplicaSet(driverName, primaryDataSourceName, slaveDataSourceNames...)
 	panic.IfNotNil(err)
@@ -102,6 +146,7 @@ func NewRows(rows *sql.Rows) Rows {
 	}
 	return &rowsWrapper{
 		rows: rows,
+		leak: trackLeak("Rows"),
 	}
 }
 
@@ -121,6 +166,7 @@ func NewStmt(stmt *sql.Stmt) Stmt {
 	}
 	return &stmtWrapper{
 		stmt: stmt,
+		leak: trackLeak("Stmt"),
 	}
 }
 
@@ -139,7 +185,8 @@ func NewTx(tx *sql.Tx) Tx {
 		return nil
 	}
 	return &txWrapper{
-		tx: tx,
+		tx:   tx,
+		leak: trackLeak("Tx"),
 	}
 }
 
@@ -156,6 +203,24 @@ type Tx interface {
 	StmtContext(ctx context.Context, stmt *sql.Stmt) Stmt
 }
 
+func NewPinnedConn(conn *sql.Conn) PinnedConn {
+	if conn == nil {
+		return nil
+	}
+	return &pinnedConnWrapper{
+		conn: conn,
+		leak: trackLeak("PinnedConn"),
+	}
+}
+
+type PinnedConn interface {
+	DBCore
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error)
+	Close() error
+	PingContext(ctx context.Context) error
+	PrepareContext(ctx context.Context, query string) (Stmt, error)
+}
+
 func NewColumnType(columnType *sql.ColumnType) ColumnType {
 	if columnType == nil {
 		return nil
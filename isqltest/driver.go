@@ -0,0 +1,166 @@
+package isqltest
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+)
+
+type fakeDriver struct {
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	f, err := connect(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{
+		fake: f,
+	}, nil
+}
+
+type conn struct {
+	fake *fake
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{
+		conn:  c,
+		query: query,
+	}, nil
+}
+
+// CheckNamedValue passes uint64 args as is, as drivers for databases with unsigned types do, and leaves every
+// other arg to the default conversion.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.(uint64); ok {
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.fake.log("BEGIN")
+	return &tx{
+		fake: c.fake,
+	}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, err := c.fake.match(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return e.result()
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	e, err := c.fake.match(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return e.rowSet()
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, namedValues(args))
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+type tx struct {
+	fake *fake
+}
+
+func (t *tx) Commit() error {
+	t.fake.log("COMMIT")
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	t.fake.log("ROLLBACK")
+	return nil
+}
+
+type rows struct {
+	columns     []string
+	columnTypes []string
+	values      [][]driver.Value
+	idx         int
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	if index < len(r.columnTypes) {
+		return r.columnTypes[index]
+	}
+	return ""
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.idx])
+	r.idx++
+	return nil
+}
+
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r *result) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+func (r *result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
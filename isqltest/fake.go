@@ -0,0 +1,394 @@
+// Package isqltest registers a fake "database/sql/driver" which serves canned results for declared
+// query matchers, so code using isql can be exercised end to end without a real database.
+package isqltest
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const DriverName = "isqlfake"
+
+var ErrAuthFailed = errors.New("isqltest: password authentication failed")
+
+func init() {
+	sql.Register(DriverName, &fakeDriver{})
+}
+
+// NewFake registers a new Fake under name, replacing any existing Fake with the same name, open it with
+// isql.NewOpener().Open(isqltest.DriverName, name).
+func NewFake(name string) Fake {
+	f := &fake{
+		name: name,
+	}
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
+	registry[name] = f
+	return f
+}
+
+type Fake interface {
+	Name() string
+	// On declares a canned response for queries matching m, the first declared matching expectation wins.
+	On(m Matcher) Expectation
+	// Queries returns every query executed against the Fake in order, including BEGIN, COMMIT and ROLLBACK.
+	Queries() []string
+	// Args returns the driver values of the args of every query in the same order as Queries, nil for queries
+	// without args.
+	Args() [][]interface{}
+	// RequirePassword makes new connections fail with ErrAuthFailed unless they are opened with the data source name
+	// name?password=<password>, connections which are already open are unaffected.
+	RequirePassword(password string)
+	// Connects returns the number of connections successfully opened to the Fake.
+	Connects() int
+	// Close unregisters the Fake, new connections to it will fail.
+	Close()
+}
+
+type Expectation interface {
+	WillReturnRows(columns []string, rows ...[]interface{}) Expectation
+	WithColumnTypes(databaseTypeNames ...string) Expectation
+	WillReturnResult(lastInsertID, rowsAffected int64) Expectation
+	WillReturnError(err error) Expectation
+}
+
+type Matcher interface {
+	Match(query string) bool
+	String() string
+}
+
+func Exact(query string) Matcher {
+	return &exactMatcher{
+		query: query,
+	}
+}
+
+func Regex(pattern string) Matcher {
+	return &regexMatcher{
+		re: regexp.MustCompile(pattern),
+	}
+}
+
+// Fingerprint matches queries which are identical to query once literals, placeholders, whitespace
+// and case have been normalized.
+func Fingerprint(query string) Matcher {
+	return &fingerprintMatcher{
+		query:       query,
+		fingerprint: QueryFingerprint(query),
+	}
+}
+
+var (
+	registryMtx sync.Mutex
+	registry    = map[string]*fake{}
+)
+
+func lookup(name string) (*fake, error) {
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
+	f, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("isqltest: no fake registered with name %q", name)
+	}
+	return f, nil
+}
+
+// connect resolves a data source name of the form name[?password=<password>] to its Fake.
+func connect(dataSourceName string) (*fake, error) {
+	name, query := dataSourceName, ""
+	if i := strings.IndexByte(dataSourceName, '?'); i >= 0 {
+		name, query = dataSourceName[:i], dataSourceName[i+1:]
+	}
+	f, err := lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.password != "" && params.Get("password") != f.password {
+		return nil, ErrAuthFailed
+	}
+	f.connects++
+	return f, nil
+}
+
+type fake struct {
+	name         string
+	mtx          sync.Mutex
+	expectations []*expectation
+	queries      []string
+	args         [][]interface{}
+	password     string
+	connects     int
+}
+
+func (f *fake) Name() string {
+	return f.name
+}
+
+func (f *fake) On(m Matcher) Expectation {
+	e := &expectation{
+		matcher: m,
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.expectations = append(f.expectations, e)
+	return e
+}
+
+func (f *fake) Queries() []string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return append([]string(nil), f.queries...)
+}
+
+func (f *fake) Args() [][]interface{} {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return append([][]interface{}(nil), f.args...)
+}
+
+func (f *fake) RequirePassword(password string) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.password = password
+}
+
+func (f *fake) Connects() int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.connects
+}
+
+func (f *fake) Close() {
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
+	if registry[f.name] == f {
+		delete(registry, f.name)
+	}
+}
+
+func (f *fake) log(query string) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.queries = append(f.queries, query)
+	f.args = append(f.args, nil)
+}
+
+func (f *fake) match(query string, args []driver.NamedValue) (*expectation, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.queries = append(f.queries, query)
+	var values []interface{}
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+	f.args = append(f.args, values)
+	for _, e := range f.expectations {
+		if e.matcher.Match(query) {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("isqltest: fake %q has no expectation matching query %q", f.name, query)
+}
+
+type expectation struct {
+	matcher      Matcher
+	mtx          sync.Mutex
+	columns      []string
+	columnTypes  []string
+	rows         [][]driver.Value
+	lastInsertID int64
+	rowsAffected int64
+	err          error
+}
+
+func (e *expectation) WillReturnRows(columns []string, rows ...[]interface{}) Expectation {
+	values := make([][]driver.Value, 0, len(rows))
+	var err error
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			err = fmt.Errorf("isqltest: row %d has %d values, expected %d", i, len(row), len(columns))
+			break
+		}
+		vs := make([]driver.Value, len(row))
+		for j, v := range row {
+			if vs[j], err = driver.DefaultParameterConverter.ConvertValue(v); err != nil {
+				err = fmt.Errorf("isqltest: row %d column %q: %s", i, columns[j], err)
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+		values = append(values, vs)
+	}
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.columns = columns
+	e.rows = values
+	if err != nil {
+		e.err = err
+	}
+	return e
+}
+
+func (e *expectation) WithColumnTypes(databaseTypeNames ...string) Expectation {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.columnTypes = databaseTypeNames
+	return e
+}
+
+func (e *expectation) WillReturnResult(lastInsertID, rowsAffected int64) Expectation {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.lastInsertID = lastInsertID
+	e.rowsAffected = rowsAffected
+	return e
+}
+
+func (e *expectation) WillReturnError(err error) Expectation {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.err = err
+	return e
+}
+
+func (e *expectation) result() (driver.Result, error) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &result{
+		lastInsertID: e.lastInsertID,
+		rowsAffected: e.rowsAffected,
+	}, nil
+}
+
+func (e *expectation) rowSet() (driver.Rows, error) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &rows{
+		columns:     e.columns,
+		columnTypes: e.columnTypes,
+		values:      e.rows,
+	}, nil
+}
+
+type exactMatcher struct {
+	query string
+}
+
+func (m *exactMatcher) Match(query string) bool {
+	return m.query == query
+}
+
+func (m *exactMatcher) String() string {
+	return fmt.Sprintf("exact(%q)", m.query)
+}
+
+type regexMatcher struct {
+	re *regexp.Regexp
+}
+
+func (m *regexMatcher) Match(query string) bool {
+	return m.re.MatchString(query)
+}
+
+func (m *regexMatcher) String() string {
+	return fmt.Sprintf("regex(%q)", m.re.String())
+}
+
+type fingerprintMatcher struct {
+	query       string
+	fingerprint string
+}
+
+func (m *fingerprintMatcher) Match(query string) bool {
+	return m.fingerprint == QueryFingerprint(query)
+}
+
+func (m *fingerprintMatcher) String() string {
+	return fmt.Sprintf("fingerprint(%q)", m.fingerprint)
+}
+
+// QueryFingerprint normalizes query by lower casing it, collapsing whitespace, dropping a trailing
+// semicolon and replacing string and numeric literals and bind placeholders ($1, ?, @p1, :name) with ?.
+func QueryFingerprint(query string) string {
+	var buf strings.Builder
+	space := false
+	var last byte
+	write := func(s string) {
+		// whitespace is only significant between two word like tokens
+		if space && isWord(last) && isWord(s[0]) {
+			buf.WriteByte(' ')
+		}
+		space = false
+		last = s[len(s)-1]
+		buf.WriteString(s)
+	}
+	q := strings.TrimSpace(query)
+	q = strings.TrimSuffix(q, ";")
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+		case c == '\'':
+			for i++; i < len(q); i++ {
+				if q[i] == '\'' {
+					if i+1 < len(q) && q[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			write("?")
+		case isDigit(c) && !prevIsIdent(q, i):
+			for i+1 < len(q) && (isDigit(q[i+1]) || q[i+1] == '.') {
+				i++
+			}
+			write("?")
+		case (c == '$' || c == ':' || c == '@') && i+1 < len(q) && isIdent(q[i+1]) && !(c == ':' && i > 0 && q[i-1] == ':'):
+			for i+1 < len(q) && isIdent(q[i+1]) {
+				i++
+			}
+			write("?")
+		default:
+			write(strings.ToLower(string(c)))
+		}
+	}
+	return buf.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isWord(c byte) bool {
+	return c == '?' || isIdent(c)
+}
+
+func prevIsIdent(q string, i int) bool {
+	return i > 0 && isIdent(q[i-1])
+}
@@ -0,0 +1,147 @@
+package isqltest_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/isqltest"
+)
+
+func TestQueryFingerprint(t *testing.T) {
+	tests := []struct {
+		query, expected string
+	}{
+		{"SELECT id FROM t WHERE id = $1", "select id from t where id=?"},
+		{"select  id\n\tfrom t where id = 5;", "select id from t where id=?"},
+		{"SELECT 'a''b', x1 FROM t", "select ?,x1 from t"},
+		{"SELECT a::int FROM t WHERE b IN (:foo, @p2, ?, 1.5)", "select a::int from t where b in(?,?,?,?)"},
+	}
+	for _, tt := range tests {
+		if fp := isqltest.QueryFingerprint(tt.query); fp != tt.expected {
+			t.Errorf("QueryFingerprint(%q) = %q, expected %q", tt.query, fp, tt.expected)
+		}
+	}
+}
+
+func TestMatchers(t *testing.T) {
+	tests := []struct {
+		m     isqltest.Matcher
+		query string
+		match bool
+	}{
+		{isqltest.Exact("SELECT 1"), "SELECT 1", true},
+		{isqltest.Exact("SELECT 1"), "select 1", false},
+		{isqltest.Regex(`^INSERT INTO t\b`), "INSERT INTO t VALUES (1)", true},
+		{isqltest.Regex(`^INSERT INTO t\b`), "INSERT INTO tt VALUES (1)", false},
+		{isqltest.Fingerprint("SELECT * FROM t WHERE id = $1"), "select * from t where id = ?", true},
+		{isqltest.Fingerprint("SELECT * FROM t WHERE id = $1"), "select * from t where name = ?", false},
+	}
+	for _, tt := range tests {
+		if match := tt.m.Match(tt.query); match != tt.match {
+			t.Errorf("%s.Match(%q) = %t, expected %t", tt.m, tt.query, match, tt.match)
+		}
+	}
+}
+
+func TestFake(t *testing.T) {
+	f := isqltest.NewFake("fake")
+	defer f.Close()
+	boom := errors.New("boom")
+	f.On(isqltest.Fingerprint("SELECT id, name FROM users WHERE id = $1")).
+		WillReturnRows([]string{"id", "name"}, []interface{}{1, "a"}, []interface{}{2, []byte("b")}).
+		WithColumnTypes("INT", "TEXT")
+	f.On(isqltest.Regex(`^INSERT`)).WillReturnResult(7, 3)
+	f.On(isqltest.Exact("boom")).WillReturnError(boom)
+	db, err := isql.NewOpener().Open(isqltest.DriverName, "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	rows, err := db.QueryContext(ctx, "select id, name from users where id = ?", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil || types[0].DatabaseTypeName() != "INT" || types[1].DatabaseTypeName() != "TEXT" {
+		t.Fatal("column types not returned", err)
+	}
+	var names []string
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Fatalf("read %v", names)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := tx.ExecContext(ctx, "INSERT INTO t VALUES (1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := res.LastInsertId(); id != 7 {
+		t.Fatalf("last insert id %d", id)
+	}
+	if n, _ := res.RowsAffected(); n != 3 {
+		t.Fatalf("rows affected %d", n)
+	}
+	tx.Commit()
+
+	if _, err := db.ExecContext(ctx, "boom"); err != boom {
+		t.Fatalf("error %v, expected boom", err)
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM t"); err == nil || !strings.Contains(err.Error(), "no expectation matching") {
+		t.Fatalf("unmatched query error %v", err)
+	}
+	expected := []string{"select id, name from users where id = ?", "BEGIN", "INSERT INTO t VALUES (1)", "COMMIT", "boom", "DELETE FROM t"}
+	if queries := f.Queries(); !reflect.DeepEqual(queries, expected) {
+		t.Fatalf("queries %q, expected %q", queries, expected)
+	}
+	if args := f.Args(); len(args) != len(expected) || !reflect.DeepEqual(args[0], []interface{}{int64(5)}) || args[1] != nil || args[2] != nil {
+		t.Fatalf("args %v, expected only the first query to have an arg", args)
+	}
+}
+
+func TestFakeRowsErrors(t *testing.T) {
+	f := isqltest.NewFake("fake_rows")
+	defer f.Close()
+	f.On(isqltest.Exact("short")).WillReturnRows([]string{"a", "b"}, []interface{}{1})
+	f.On(isqltest.Exact("unsupported")).WillReturnRows([]string{"a"}, []interface{}{struct{}{}})
+	db, err := isql.NewOpener().Open(isqltest.DriverName, "fake_rows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, query := range []string{"short", "unsupported"} {
+		if _, err := db.QueryContext(context.Background(), query); err == nil {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+}
+
+func TestFakeClosed(t *testing.T) {
+	f := isqltest.NewFake("fake_closed")
+	f.Close()
+	db, err := isql.NewOpener().Open(isqltest.DriverName, "fake_closed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err == nil {
+		t.Fatal("expected a closed fake to refuse connections")
+	}
+}
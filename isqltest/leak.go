@@ -0,0 +1,22 @@
+package isqltest
+
+import (
+	"testing"
+
+	"github.com/0xor1/isql"
+)
+
+// CheckLeaks turns on isql leak detection and fails t when it finishes if any PinnedConn, Rows, Stmt or Tx created
+// during the test are still open. Handles created by other tests running in parallel are reported too.
+func CheckLeaks(t testing.TB) {
+	t.Helper()
+	isql.SetLeakDetection(true)
+	since := isql.LeakSeq()
+	t.Cleanup(func() {
+		for _, leak := range isql.LeakReport(0) {
+			if leak.Seq > since {
+				t.Errorf("isqltest: leaked %s", leak)
+			}
+		}
+	})
+}
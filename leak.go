@@ -0,0 +1,124 @@
+package isql
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SetLeakDetection turns leak detection on or off, while on every PinnedConn, Rows, Stmt and Tx created by this
+// package records the stack trace it was created from until it is closed, committed or rolled back. When off the only
+// cost is an atomic load per handle created.
+func SetLeakDetection(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&leakDetection, v)
+}
+
+func LeakDetectionEnabled() bool {
+	return atomic.LoadInt32(&leakDetection) == 1
+}
+
+// LeakReport returns every tracked handle which has been open for at least olderThan, oldest first.
+func LeakReport(olderThan time.Duration) []Leak {
+	now := time.Now()
+	leaksMtx.Lock()
+	res := make([]Leak, 0, len(leaks))
+	for h := range leaks {
+		if now.Sub(h.created) >= olderThan {
+			res = append(res, Leak{
+				Kind:    h.kind,
+				Seq:     h.seq,
+				Created: h.created,
+				pcs:     h.pcs,
+			})
+		}
+	}
+	leaksMtx.Unlock()
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Seq < res[j].Seq
+	})
+	return res
+}
+
+// LeakSeq returns the sequence number of the most recently tracked handle, it can be compared to Leak.Seq to find
+// handles created after a point in time.
+func LeakSeq() uint64 {
+	leaksMtx.Lock()
+	defer leaksMtx.Unlock()
+	return leakSeq
+}
+
+type Leak struct {
+	// Kind is one of PinnedConn, Rows, Stmt or Tx.
+	Kind    string
+	Seq     uint64
+	Created time.Time
+	pcs     []uintptr
+}
+
+func (l Leak) Stack() string {
+	buf := &strings.Builder{}
+	frames := runtime.CallersFrames(l.pcs)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(buf, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
+	}
+	return buf.String()
+}
+
+func (l Leak) String() string {
+	return fmt.Sprintf("%s opened at %s still open, created from:\n%s", l.Kind, l.Created.Format(time.RFC3339Nano), l.Stack())
+}
+
+var (
+	leakDetection int32
+	leaksMtx      sync.Mutex
+	leakSeq       uint64
+	leaks         = map[*leakHandle]struct{}{}
+)
+
+type leakHandle struct {
+	kind    string
+	seq     uint64
+	created time.Time
+	pcs     []uintptr
+}
+
+// trackLeak returns nil when leak detection is off, release is safe to call on a nil handle.
+func trackLeak(kind string) *leakHandle {
+	if atomic.LoadInt32(&leakDetection) == 0 {
+		return nil
+	}
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	h := &leakHandle{
+		kind:    kind,
+		created: time.Now(),
+		pcs:     pcs[:n],
+	}
+	leaksMtx.Lock()
+	defer leaksMtx.Unlock()
+	leakSeq++
+	h.seq = leakSeq
+	leaks[h] = struct{}{}
+	return h
+}
+
+func (h *leakHandle) release() {
+	if h == nil {
+		return
+	}
+	leaksMtx.Lock()
+	defer leaksMtx.Unlock()
+	delete(leaks, h)
+}
@@ -0,0 +1,180 @@
+package isql_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/isqltest"
+)
+
+func openLeaks(since uint64) []string {
+	var kinds []string
+	for _, l := range isql.LeakReport(0) {
+		if l.Seq > since {
+			kinds = append(kinds, l.Kind)
+		}
+	}
+	return kinds
+}
+
+func TestLeakDetection(t *testing.T) {
+	f := isqltest.NewFake("leaks")
+	defer f.Close()
+	f.On(isqltest.Regex(``)).WillReturnRows([]string{"a"}, []interface{}{1})
+	db, err := isql.NewOpener().Open(isqltest.DriverName, "leaks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	untracked, err := db.Query("SELECT untracked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer untracked.Close()
+	isql.SetLeakDetection(true)
+	defer isql.SetLeakDetection(false)
+	since := isql.LeakSeq()
+
+	rows, err := db.Query("SELECT open")
+	if err != nil {
+		t.Fatal(err)
+	}
+	drained, err := db.Query("SELECT drained")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for drained.Next() {
+	}
+	stmt, err := db.Prepare("SELECT stmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a int
+	if err := conn.QueryRowContext(context.Background(), "SELECT conn").Scan(&a); err != nil || a != 1 {
+		t.Fatalf("scanned %d with error %v", a, err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kinds := strings.Join(openLeaks(since), ","); kinds != "Rows,Stmt,PinnedConn,Tx" {
+		t.Fatalf("open handles %s, expected Rows,Stmt,PinnedConn,Tx", kinds)
+	}
+	leaks := isql.LeakReport(0)
+	if l := leaks[len(leaks)-1]; !strings.Contains(l.Stack(), "TestLeakDetection") || !strings.Contains(l.String(), "Tx opened at") {
+		t.Fatalf("leak does not report where it was created:\n%s", l)
+	}
+	if len(isql.LeakReport(time.Hour)) != 0 {
+		t.Fatal("expected no handles open for an hour")
+	}
+
+	rows.Close()
+	stmt.Close()
+	conn.Close()
+	tx.Rollback()
+	if kinds := openLeaks(since); len(kinds) != 0 {
+		t.Fatalf("handles %v still tracked after closing", kinds)
+	}
+}
+
+func TestLeakResultSets(t *testing.T) {
+	var ctxs []context.Context
+	db, err := isql.NewOpener(isql.WithTimeouts(isql.Timeouts{Read: time.Minute})).OpenConnector(ctxConnector{&ctxs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	isql.SetLeakDetection(true)
+	defer isql.SetLeakDetection(false)
+	since := isql.LeakSeq()
+
+	rows, err := db.Query("SELECT a; SELECT b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := ctxs[len(ctxs)-1]
+	for rows.Next() {
+	}
+	// another result set is pending, rows abandoned now are still open
+	if kinds := openLeaks(since); len(kinds) != 1 || ctx.Err() != nil {
+		t.Fatalf("handles %v with error %v, expected the rows to be tracked until every result set is read", kinds, ctx.Err())
+	}
+	if !rows.NextResultSet() {
+		t.Fatal("expected a second result set")
+	}
+	for rows.Next() {
+	}
+	if kinds := openLeaks(since); len(kinds) != 0 || ctx.Err() != context.Canceled {
+		t.Fatalf("handles %v with error %v, expected the rows to be released once the last result set is read", kinds, ctx.Err())
+	}
+
+	rows, err = db.Query("SELECT a; SELECT b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.NextResultSet() {
+	}
+	if kinds := openLeaks(since); len(kinds) != 0 {
+		t.Fatalf("handles %v still tracked after NextResultSet returned false", kinds)
+	}
+}
+
+func TestCheckLeaks(t *testing.T) {
+	f := isqltest.NewFake("check_leaks")
+	defer f.Close()
+	f.On(isqltest.Regex(``)).WillReturnRows([]string{"a"}, []interface{}{1})
+	db, err := isql.NewOpener().Open(isqltest.DriverName, "check_leaks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	defer isql.SetLeakDetection(false)
+	var leaked isql.Rows
+	ok := t.Run("leaky", func(t *testing.T) {
+		r := &reporter{TB: t}
+		isqltest.CheckLeaks(r)
+		if leaked, err = db.Query("SELECT a"); err != nil {
+			t.Fatal(err)
+		}
+		r.cleanup()
+		if len(r.errors) != 1 || !strings.Contains(r.errors[0], "leaked Rows") {
+			t.Fatalf("reported %q, expected one leaked Rows", r.errors)
+		}
+	})
+	leaked.Close()
+	if !ok {
+		t.Fatal("leaky subtest failed")
+	}
+}
+
+// reporter captures the errors and cleanups of a testing.TB so a test can check what a helper reports.
+type reporter struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *reporter) Helper() {}
+
+func (r *reporter) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *reporter) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+func (r *reporter) cleanup() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
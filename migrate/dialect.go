@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/0xor1/isql"
+)
+
+func createTableQuery(d isql.Dialect, table string) string {
+	quoted := d.QuoteIdent(table)
+	switch d.Name() {
+	case "sqlserver":
+		return "IF OBJECT_ID(N'" + quoted + "', N'U') IS NULL CREATE TABLE " + quoted +
+			" (version BIGINT NOT NULL PRIMARY KEY, name NVARCHAR(255) NOT NULL, checksum CHAR(64) NOT NULL, applied_at DATETIME2 NOT NULL)"
+	case "mysql":
+		return "CREATE TABLE IF NOT EXISTS " + quoted +
+			" (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum CHAR(64) NOT NULL, applied_at DATETIME(6) NOT NULL)"
+	default:
+		return "CREATE TABLE IF NOT EXISTS " + quoted +
+			" (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum CHAR(64) NOT NULL, applied_at TIMESTAMP NOT NULL)"
+	}
+}
+
+// tableExistsQuery returns a query, and its args, counting the tables named table, which is looked up in the
+// current schema unless it is qualified with one.
+func tableExistsQuery(d isql.Dialect, table string) (string, []interface{}) {
+	schema, name := "", table
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		schema, name = table[:i], table[i+1:]
+	}
+	if d.Name() == "sqlite" {
+		master := "sqlite_master"
+		if schema != "" {
+			master = d.QuoteIdent(schema) + ".sqlite_master"
+		}
+		return "SELECT COUNT(*) FROM " + master + " WHERE type = 'table' AND name = " + d.Placeholder(1), []interface{}{name}
+	}
+	query := "SELECT COUNT(*) FROM information_schema.tables WHERE table_name = " + d.Placeholder(1) + " AND table_schema = "
+	if schema != "" {
+		return query + d.Placeholder(2), []interface{}{name, schema}
+	}
+	switch d.Name() {
+	case "mysql":
+		return query + "DATABASE()", []interface{}{name}
+	case "sqlserver":
+		return query + "SCHEMA_NAME()", []interface{}{name}
+	default:
+		return query + "current_schema()", []interface{}{name}
+	}
+}
+
+// transactionalDDL reports whether DDL can be rolled back, MySQL commits implicitly before and after most DDL.
+func transactionalDDL(d isql.Dialect) bool {
+	return d.Name() != "mysql"
+}
+
+// lock takes the migration lock on the session of conn, so it is held across the Tx of each step, the returned func
+// releases it. SQLite needs no lock as it only allows one writer at a time.
+func (m *migrator) lock(ctx context.Context, conn isql.PinnedConn) (func() error, error) {
+	noop := func() error {
+		return nil
+	}
+	// the lock is released with a fresh context so a cancelled ctx doesn't return the connection to the pool still
+	// holding it
+	release := func(query string, args ...interface{}) func() error {
+		return func() error {
+			_, err := conn.ExecContext(context.Background(), query, args...)
+			return err
+		}
+	}
+	name := "isql_migrate:" + m.cfg.Table
+	switch m.cfg.Dialect.Name() {
+	case "postgres":
+		h := fnv.New64a()
+		h.Write([]byte(name))
+		key := strconv.FormatInt(int64(h.Sum64()), 10)
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock("+key+")"); err != nil {
+			return noop, err
+		}
+		return release("SELECT pg_advisory_unlock(" + key + ")"), nil
+	case "sqlserver":
+		var res int
+		err := conn.QueryRowContext(ctx, "DECLARE @res INT; EXEC @res = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = @p2; SELECT @res",
+			name, m.cfg.LockTimeout.Milliseconds()).Scan(&res)
+		if err != nil {
+			return noop, err
+		}
+		if res < 0 {
+			return noop, fmt.Errorf("migrate: sp_getapplock failed with %d", res)
+		}
+		return release("EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'", name), nil
+	case "mysql":
+		var res *int64
+		err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, int64(m.cfg.LockTimeout.Seconds())).Scan(&res)
+		if err != nil {
+			return noop, err
+		}
+		if res == nil || *res != 1 {
+			return noop, fmt.Errorf("migrate: timed out waiting for lock %s", name)
+		}
+		return release("SELECT RELEASE_LOCK(?)", name), nil
+	default:
+		return noop, nil
+	}
+}
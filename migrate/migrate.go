@@ -0,0 +1,478 @@
+// Package migrate applies versioned up and down SQL migrations read from an fs.FS, recording each one applied in
+// a history table along with a checksum of its up script.
+//
+// Migration files are named <version>_<name>.up.sql and <version>_<name>.down.sql, e.g. 0001_create_users.up.sql,
+// down scripts are optional. Each script is executed with a single Exec so a script with several statements needs
+// a driver which supports that.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+// ErrPrimaryNotDB is returned by NewForReplicaSet if the primary of the set is not an isql.DB, e.g. because it
+// has been decorated.
+var ErrPrimaryNotDB = errors.New("migrate: replica set primary is not an isql.DB")
+
+type Config struct {
+	// Dialect selects the history table DDL, placeholders and lock, required.
+	Dialect isql.Dialect
+	// Table is the name of the history table, default "isql_migrations".
+	Table string
+	// DryRun makes Up and To return the steps they would run without running them or creating the history table.
+	DryRun bool
+	// LockTimeout is how long to wait for the migration lock on MySQL and SQL Server, default 1m, on Postgres the
+	// wait is bounded by the context.
+	LockTimeout time.Duration
+}
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// Checksum is the hex encoded sha256 of Up.
+	Checksum string
+}
+
+type Direction string
+
+const (
+	DirectionUp   Direction = "up"
+	DirectionDown Direction = "down"
+)
+
+type Step struct {
+	Version   int64
+	Name      string
+	Direction Direction
+	SQL       string
+}
+
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// ChecksumMismatch is true if the up script has changed since it was applied.
+	ChecksumMismatch bool
+	// Missing is true if the migration is recorded as applied but has no files.
+	Missing bool
+}
+
+type ChecksumError struct {
+	Version  int64
+	Name     string
+	Recorded string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("migrate: migration %d %s has changed since it was applied, recorded checksum %s, actual %s", e.Version, e.Name, e.Recorded, e.Actual)
+}
+
+type Migrator interface {
+	// Up applies every pending migration in version order.
+	Up(ctx context.Context) ([]Step, error)
+	// To applies or reverts migrations until version is the latest applied, 0 reverts every migration. Steps run in
+	// order while holding the migration lock, each in its own Tx where DDL is transactional, on MySQL DDL commits
+	// implicitly so steps run outside a Tx. A failed step stops the run, the steps before it stay applied and are
+	// returned with the error.
+	To(ctx context.Context, version int64) ([]Step, error)
+	// Status lists every migration, from files or history, in version order, without creating the history table.
+	Status(ctx context.Context) ([]Status, error)
+}
+
+// Load reads the migrations in the root directory of fsys, use fs.Sub for a subdirectory.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		fileName := entry.Name()
+		var direction Direction
+		var base string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction, base = DirectionUp, strings.TrimSuffix(fileName, ".up.sql")
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction, base = DirectionDown, strings.TrimSuffix(fileName, ".down.sql")
+		default:
+			continue
+		}
+		parts := strings.SplitN(base, "_", 2)
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migrate: %s does not start with a positive version number", fileName)
+		}
+		name := ""
+		if len(parts) == 2 {
+			name = parts[1]
+		}
+		data, err := fs.ReadFile(fsys, path.Join(".", fileName))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{
+				Version: version,
+				Name:    name,
+			}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migrate: version %d is used by both %s and %s", version, m.Name, name)
+		}
+		if direction == DirectionUp {
+			if m.Up != "" {
+				return nil, fmt.Errorf("migrate: version %d has more than one up script", version)
+			}
+			m.Up = string(data)
+			m.Checksum = checksum(m.Up)
+		} else {
+			if m.Down != "" {
+				return nil, fmt.Errorf("migrate: version %d has more than one down script", version)
+			}
+			m.Down = string(data)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migrate: version %d %s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// New returns a Migrator which applies the migrations in fsys to db.
+func New(db isql.DB, fsys fs.FS, cfg Config) (Migrator, error) {
+	if cfg.Dialect == nil {
+		return nil, errors.New("migrate: Config.Dialect is required")
+	}
+	if cfg.Table == "" {
+		cfg.Table = "isql_migrations"
+	}
+	if cfg.LockTimeout <= 0 {
+		cfg.LockTimeout = time.Minute
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &migrator{
+		db:         db,
+		cfg:        cfg,
+		migrations: migrations,
+	}, nil
+}
+
+// NewForReplicaSet returns a Migrator which only ever runs against the primary of rs.
+func NewForReplicaSet(rs isql.ReplicaSet, fsys fs.FS, cfg Config) (Migrator, error) {
+	db, ok := rs.Primary().(isql.DB)
+	if !ok {
+		return nil, ErrPrimaryNotDB
+	}
+	return New(db, fsys, cfg)
+}
+
+type migrator struct {
+	db         isql.DB
+	cfg        Config
+	migrations []Migration
+}
+
+type record struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+func (m *migrator) Up(ctx context.Context) ([]Step, error) {
+	if len(m.migrations) == 0 {
+		return nil, nil
+	}
+	return m.To(ctx, m.migrations[len(m.migrations)-1].Version)
+}
+
+func (m *migrator) To(ctx context.Context, version int64) (steps []Step, err error) {
+	if m.cfg.DryRun {
+		history, err := m.readHistory(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return m.plan(history, version)
+	}
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	unlock, err := m.lock(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	// the lock is only released once the last step has committed, so a concurrent migrator never reads a history
+	// missing a step taken under it
+	defer func() {
+		if unlockErr := unlock(); unlockErr != nil && err == nil {
+			err = unlockErr
+		}
+	}()
+	// history is read under the lock so steps taken by a concurrent migrator are not repeated
+	history, err := m.history(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	planned, err := m.plan(history, version)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range planned {
+		if err := m.apply(ctx, conn, step); err != nil {
+			return steps, fmt.Errorf("migrate: %s %d %s: %v", step.Direction, step.Version, step.Name, err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func (m *migrator) Status(ctx context.Context) ([]Status, error) {
+	history, err := m.readHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Status, 0, len(m.migrations))
+	seen := map[int64]bool{}
+	for _, mig := range m.migrations {
+		seen[mig.Version] = true
+		s := Status{
+			Version: mig.Version,
+			Name:    mig.Name,
+		}
+		if r, ok := history[mig.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = r.appliedAt
+			s.ChecksumMismatch = r.checksum != mig.Checksum
+		}
+		res = append(res, s)
+	}
+	for version, r := range history {
+		if !seen[version] {
+			res = append(res, Status{
+				Version:   version,
+				Name:      r.name,
+				Applied:   true,
+				AppliedAt: r.appliedAt,
+				Missing:   true,
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Version < res[j].Version
+	})
+	return res, nil
+}
+
+// plan returns the steps to take from history to target, refusing to run if an applied up script has changed.
+func (m *migrator) plan(history map[int64]*record, target int64) ([]Step, error) {
+	if target != 0 && m.find(target) == nil {
+		if _, ok := history[target]; !ok {
+			return nil, fmt.Errorf("migrate: unknown target version %d", target)
+		}
+	}
+	var steps []Step
+	for _, mig := range m.migrations {
+		r, applied := history[mig.Version]
+		if applied && r.checksum != mig.Checksum {
+			return nil, &ChecksumError{
+				Version:  mig.Version,
+				Name:     mig.Name,
+				Recorded: r.checksum,
+				Actual:   mig.Checksum,
+			}
+		}
+		if !applied && mig.Version <= target {
+			steps = append(steps, Step{
+				Version:   mig.Version,
+				Name:      mig.Name,
+				Direction: DirectionUp,
+				SQL:       mig.Up,
+			})
+		}
+	}
+	applied := make([]int64, 0, len(history))
+	for version := range history {
+		if version > target {
+			applied = append(applied, version)
+		}
+	}
+	sort.Slice(applied, func(i, j int) bool {
+		return applied[i] > applied[j]
+	})
+	for _, version := range applied {
+		mig := m.find(version)
+		if mig == nil {
+			return nil, fmt.Errorf("migrate: cannot revert version %d %s as its files are missing", version, history[version].name)
+		}
+		if mig.Down == "" {
+			return nil, fmt.Errorf("migrate: cannot revert version %d %s as it has no down script", version, mig.Name)
+		}
+		steps = append(steps, Step{
+			Version:   mig.Version,
+			Name:      mig.Name,
+			Direction: DirectionDown,
+			SQL:       mig.Down,
+		})
+	}
+	return steps, nil
+}
+
+func (m *migrator) find(version int64) *Migration {
+	idx := sort.Search(len(m.migrations), func(i int) bool {
+		return m.migrations[i].Version >= version
+	})
+	if idx < len(m.migrations) && m.migrations[idx].Version == version {
+		return &m.migrations[idx]
+	}
+	return nil
+}
+
+// apply runs step in its own Tx where the dialect's DDL is transactional, so a failed step leaves no trace.
+func (m *migrator) apply(ctx context.Context, conn isql.PinnedConn, step Step) error {
+	if !transactionalDDL(m.cfg.Dialect) {
+		return m.run(ctx, conn, step)
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := m.run(ctx, tx, step); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// run executes step and records it in the history table.
+func (m *migrator) run(ctx context.Context, db isql.DBCore, step Step) error {
+	if _, err := db.ExecContext(ctx, step.SQL); err != nil {
+		return err
+	}
+	var err error
+	if step.Direction == DirectionUp {
+		mig := m.find(step.Version)
+		_, err = db.ExecContext(ctx, m.insertQuery(), step.Version, step.Name, mig.Checksum, time.Now().UTC())
+	} else {
+		_, err = db.ExecContext(ctx, m.deleteQuery(), step.Version)
+	}
+	return err
+}
+
+func (m *migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, createTableQuery(m.cfg.Dialect, m.cfg.Table))
+	return err
+}
+
+// readHistory returns the history without creating the table, which is treated as empty if it does not exist.
+func (m *migrator) readHistory(ctx context.Context) (map[int64]*record, error) {
+	query, args := tableExistsQuery(m.cfg.Dialect, m.cfg.Table)
+	var n int64
+	if err := m.db.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return map[int64]*record{}, nil
+	}
+	return m.history(ctx, m.db)
+}
+
+func (m *migrator) history(ctx context.Context, db isql.DBCore) (map[int64]*record, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM "+m.table()+" ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	history := map[int64]*record{}
+	for rows.Next() {
+		r := &record{}
+		if err := rows.Scan(&r.version, &r.name, &r.checksum, &timeValue{&r.appliedAt}); err != nil {
+			return nil, err
+		}
+		history[r.version] = r
+	}
+	return history, rows.Err()
+}
+
+func (m *migrator) insertQuery() string {
+	d := m.cfg.Dialect
+	return "INSERT INTO " + m.table() + " (version, name, checksum, applied_at) VALUES (" +
+		d.Placeholder(1) + ", " + d.Placeholder(2) + ", " + d.Placeholder(3) + ", " + d.Placeholder(4) + ")"
+}
+
+func (m *migrator) deleteQuery() string {
+	return "DELETE FROM " + m.table() + " WHERE version = " + m.cfg.Dialect.Placeholder(1)
+}
+
+func (m *migrator) table() string {
+	return m.cfg.Dialect.QuoteIdent(m.cfg.Table)
+}
+
+func checksum(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+// timeValue scans a timestamp returned as a time.Time or as text, e.g. by MySQL without parseTime=true.
+type timeValue struct {
+	t *time.Time
+}
+
+var timeLayouts = []string{"2006-01-02 15:04:05.999999999", time.RFC3339Nano, "2006-01-02T15:04:05.999999999"}
+
+func (v *timeValue) Scan(src interface{}) error {
+	var text string
+	switch src := src.(type) {
+	case time.Time:
+		*v.t = src
+		return nil
+	case string:
+		text = src
+	case []byte:
+		text = string(src)
+	default:
+		return fmt.Errorf("migrate: cannot scan %T into applied_at", src)
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			*v.t = t
+			return nil
+		}
+	}
+	return fmt.Errorf("migrate: cannot parse applied_at %q", text)
+}
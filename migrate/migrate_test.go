@@ -0,0 +1,273 @@
+package migrate_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/isqltest"
+	"github.com/0xor1/isql/migrate"
+)
+
+const (
+	usersUp   = "CREATE TABLE users (id INT)"
+	usersDown = "DROP TABLE users"
+	postsUp   = "CREATE TABLE posts (id INT)"
+	postsDown = "DROP TABLE posts"
+
+	existsQuery  = "^SELECT COUNT\\(\\*\\) FROM information_schema.tables"
+	historyQuery = "^SELECT version, name, checksum, applied_at"
+)
+
+var historyColumns = []string{"version", "name", "checksum", "applied_at"}
+
+func migrations() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_users.up.sql":   {Data: []byte(usersUp)},
+		"0001_users.down.sql": {Data: []byte(usersDown)},
+		"0002_posts.up.sql":   {Data: []byte(postsUp)},
+		"0002_posts.down.sql": {Data: []byte(postsDown)},
+		"readme.md":           {Data: []byte("not a migration")},
+	}
+}
+
+func checksum(t *testing.T, fsys fstest.MapFS, version int64) string {
+	t.Helper()
+	ms, err := migrate.Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range ms {
+		if m.Version == version {
+			return m.Checksum
+		}
+	}
+	t.Fatalf("no migration %d", version)
+	return ""
+}
+
+func open(t *testing.T, name string) (isqltest.Fake, isql.DB) {
+	t.Helper()
+	f := isqltest.NewFake(name)
+	t.Cleanup(f.Close)
+	db, err := isql.NewOpener().Open(isqltest.DriverName, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return f, db
+}
+
+func TestUp(t *testing.T) {
+	fsys := migrations()
+	f, db := open(t, "migrate_up")
+	// applied_at is returned as text, as MySQL does without parseTime=true
+	f.On(isqltest.Regex(historyQuery)).WillReturnRows(historyColumns,
+		[]interface{}{int64(1), "users", checksum(t, fsys, 1), []byte("2024-01-02 03:04:05.123456")})
+	f.On(isqltest.Regex(".")).WillReturnResult(0, 1)
+	m, err := migrate.New(db, fsys, migrate.Config{Dialect: isql.Postgres})
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := m.Up(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []migrate.Step{{Version: 2, Name: "posts", Direction: migrate.DirectionUp, SQL: postsUp}}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Fatalf("steps %+v, expected %+v", steps, expected)
+	}
+	queries := f.Queries()
+	expectedQueries := []string{`SELECT pg_advisory_lock(4207428981920457803)`, `SELECT version, name, checksum, applied_at FROM "isql_migrations" ORDER BY version`,
+		"BEGIN", postsUp, `INSERT INTO "isql_migrations" (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)`, "COMMIT",
+		`SELECT pg_advisory_unlock(4207428981920457803)`}
+	if !reflect.DeepEqual(queries[1:], expectedQueries) {
+		t.Fatalf("queries %q, expected the history to be read once under the lock then the step applied in its own Tx before the lock is released", queries[1:])
+	}
+}
+
+func TestMySQLLock(t *testing.T) {
+	fsys := migrations()
+	f, db := open(t, "migrate_mysql")
+	f.On(isqltest.Regex("^SELECT GET_LOCK")).WillReturnRows([]string{"res"}, []interface{}{int64(1)})
+	f.On(isqltest.Regex(historyQuery)).WillReturnRows(historyColumns)
+	f.On(isqltest.Regex(".")).WillReturnResult(0, 1)
+	m, err := migrate.New(db, fsys, migrate.Config{Dialect: isql.MySQL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := m.Up(context.Background())
+	if err != nil || len(steps) != 2 {
+		t.Fatalf("steps %+v with error %v", steps, err)
+	}
+	// DDL commits implicitly on MySQL so steps run outside a Tx, the lock is released once every step has run
+	queries := f.Queries()
+	expected := []string{"SELECT GET_LOCK(?, ?)", "SELECT version, name, checksum, applied_at FROM `isql_migrations` ORDER BY version",
+		usersUp, "INSERT INTO `isql_migrations` (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+		postsUp, "INSERT INTO `isql_migrations` (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+		"SELECT RELEASE_LOCK(?)"}
+	if !reflect.DeepEqual(queries[1:], expected) {
+		t.Fatalf("queries %q, expected %q", queries[1:], expected)
+	}
+}
+
+func TestTo(t *testing.T) {
+	fsys := migrations()
+	history := func(f isqltest.Fake) {
+		f.On(isqltest.Regex(historyQuery)).WillReturnRows(historyColumns,
+			[]interface{}{int64(1), "users", checksum(t, fsys, 1), time.Unix(1, 0)},
+			[]interface{}{int64(2), "posts", checksum(t, fsys, 2), time.Unix(2, 0)})
+	}
+	tests := []struct {
+		name    string
+		version int64
+		fsys    fstest.MapFS
+		steps   []migrate.Step
+		err     string
+	}{
+		{"current", 2, fsys, nil, ""},
+		{"down one", 1, fsys, []migrate.Step{{Version: 2, Name: "posts", Direction: migrate.DirectionDown, SQL: postsDown}}, ""},
+		{"down all", 0, fsys, []migrate.Step{
+			{Version: 2, Name: "posts", Direction: migrate.DirectionDown, SQL: postsDown},
+			{Version: 1, Name: "users", Direction: migrate.DirectionDown, SQL: usersDown},
+		}, ""},
+		{"unknown", 3, fsys, nil, "migrate: unknown target version 3"},
+		{"no down script", 0, fstest.MapFS{"0001_users.up.sql": fsys["0001_users.up.sql"], "0002_posts.up.sql": fsys["0002_posts.up.sql"]},
+			nil, "migrate: cannot revert version 2 posts as it has no down script"},
+		{"missing files", 0, fstest.MapFS{"0001_users.up.sql": fsys["0001_users.up.sql"], "0001_users.down.sql": fsys["0001_users.down.sql"]},
+			nil, "migrate: cannot revert version 2 posts as its files are missing"},
+	}
+	for _, tt := range tests {
+		f, db := open(t, "migrate_to")
+		history(f)
+		f.On(isqltest.Regex(".")).WillReturnResult(0, 1)
+		m, err := migrate.New(db, tt.fsys, migrate.Config{Dialect: isql.SQLite})
+		if err != nil {
+			t.Fatal(err)
+		}
+		steps, err := m.To(context.Background(), tt.version)
+		if (err != nil || tt.err != "") && (err == nil || err.Error() != tt.err) {
+			t.Errorf("%s: error %v, expected %q", tt.name, err, tt.err)
+			continue
+		}
+		if !reflect.DeepEqual(steps, tt.steps) {
+			t.Errorf("%s: steps %+v, expected %+v", tt.name, steps, tt.steps)
+		}
+	}
+}
+
+func TestStepFails(t *testing.T) {
+	fsys := migrations()
+	tests := []struct {
+		dialect isql.Dialect
+		queries []string
+	}{
+		{isql.SQLite, []string{`SELECT version, name, checksum, applied_at FROM "isql_migrations" ORDER BY version`,
+			"BEGIN", usersUp, `INSERT INTO "isql_migrations" (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)`, "COMMIT",
+			"BEGIN", postsUp, "ROLLBACK"}},
+		{isql.MySQL, []string{"SELECT GET_LOCK(?, ?)", "SELECT version, name, checksum, applied_at FROM `isql_migrations` ORDER BY version",
+			usersUp, "INSERT INTO `isql_migrations` (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)", postsUp,
+			"SELECT RELEASE_LOCK(?)"}},
+	}
+	for _, tt := range tests {
+		f, db := open(t, "migrate_fails_"+tt.dialect.Name())
+		boom := errors.New("boom")
+		f.On(isqltest.Regex("^SELECT GET_LOCK")).WillReturnRows([]string{"res"}, []interface{}{int64(1)})
+		f.On(isqltest.Regex(historyQuery)).WillReturnRows(historyColumns)
+		f.On(isqltest.Exact(postsUp)).WillReturnError(boom)
+		f.On(isqltest.Regex(".")).WillReturnResult(0, 1)
+		m, err := migrate.New(db, fsys, migrate.Config{Dialect: tt.dialect})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the step before the failed one has committed so it is returned with the error
+		steps, err := m.Up(context.Background())
+		expected := []migrate.Step{{Version: 1, Name: "users", Direction: migrate.DirectionUp, SQL: usersUp}}
+		if err == nil || err.Error() != "migrate: up 2 posts: boom" || !reflect.DeepEqual(steps, expected) {
+			t.Fatalf("%s: steps %+v with error %v", tt.dialect.Name(), steps, err)
+		}
+		if queries := f.Queries(); !reflect.DeepEqual(queries[1:], tt.queries) {
+			t.Fatalf("%s: queries %q, expected %q", tt.dialect.Name(), queries[1:], tt.queries)
+		}
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	f, db := open(t, "migrate_dry_run")
+	f.On(isqltest.Regex(existsQuery)).WillReturnRows([]string{"count"}, []interface{}{int64(0)})
+	m, err := migrate.New(db, migrations(), migrate.Config{Dialect: isql.Postgres, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := m.Up(context.Background())
+	if err != nil || len(steps) != 2 {
+		t.Fatalf("steps %+v with error %v", steps, err)
+	}
+	expected := []string{"SELECT COUNT(*) FROM information_schema.tables WHERE table_name = $1 AND table_schema = current_schema()"}
+	if queries := f.Queries(); !reflect.DeepEqual(queries, expected) {
+		t.Fatalf("queries %q, expected only %q", queries, expected)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	fsys := migrations()
+	f, db := open(t, "migrate_status")
+	f.On(isqltest.Regex(existsQuery)).WillReturnRows([]string{"count"}, []interface{}{int64(1)})
+	f.On(isqltest.Regex(historyQuery)).WillReturnRows(historyColumns,
+		[]interface{}{int64(1), "users", "changed", "2024-01-02T03:04:05Z"},
+		[]interface{}{int64(3), "gone", "x", time.Unix(3, 0).UTC()})
+	f.On(isqltest.Regex("sp_getapplock")).WillReturnRows([]string{"res"}, []interface{}{int64(0)})
+	f.On(isqltest.Regex(".")).WillReturnResult(0, 1)
+	m, err := migrate.New(db, fsys, migrate.Config{Dialect: isql.SQLServer, Table: "dbo.migrations"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []migrate.Status{
+		{Version: 1, Name: "users", Applied: true, AppliedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), ChecksumMismatch: true},
+		{Version: 2, Name: "posts"},
+		{Version: 3, Name: "gone", Applied: true, AppliedAt: time.Unix(3, 0).UTC(), Missing: true},
+	}
+	if !reflect.DeepEqual(status, expected) {
+		t.Fatalf("status %+v, expected %+v", status, expected)
+	}
+	if queries := f.Queries(); queries[0] != "SELECT COUNT(*) FROM information_schema.tables WHERE table_name = @p1 AND table_schema = @p2" {
+		t.Fatalf("queries %q, expected the schema qualified table to be looked up", queries)
+	}
+
+	var checksumErr *migrate.ChecksumError
+	if _, err := m.Up(context.Background()); !errors.As(err, &checksumErr) || checksumErr.Version != 1 {
+		t.Fatalf("error %v, expected a checksum error", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		err  string
+	}{
+		{"bad version", fstest.MapFS{"x_users.up.sql": {}}, "migrate: x_users.up.sql does not start with a positive version number"},
+		{"zero version", fstest.MapFS{"0_users.up.sql": {}}, "migrate: 0_users.up.sql does not start with a positive version number"},
+		{"name clash", fstest.MapFS{"1_a.up.sql": {Data: []byte("a")}, "1_b.up.sql": {Data: []byte("b")}}, "migrate: version 1 is used by both a and b"},
+		{"no up", fstest.MapFS{"1_a.down.sql": {Data: []byte("a")}}, "migrate: version 1 a has no up script"},
+	}
+	for _, tt := range tests {
+		if _, err := migrate.Load(tt.fsys); err == nil || err.Error() != tt.err {
+			t.Errorf("%s: error %v, expected %q", tt.name, err, tt.err)
+		}
+	}
+	ms, err := migrate.Load(migrations())
+	if err != nil || len(ms) != 2 || ms[0].Version != 1 || ms[1].Down != postsDown {
+		t.Fatalf("loaded %+v with error %v", ms, err)
+	}
+}
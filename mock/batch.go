@@ -0,0 +1,76 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: batch.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockRowSource is a mock of RowSource interface.
+type MockRowSource struct {
+	ctrl     *gomock.Controller
+	recorder *MockRowSourceMockRecorder
+}
+
+// MockRowSourceMockRecorder is the mock recorder for MockRowSource.
+type MockRowSourceMockRecorder struct {
+	mock *MockRowSource
+}
+
+// NewMockRowSource creates a new mock instance.
+func NewMockRowSource(ctrl *gomock.Controller) *MockRowSource {
+	mock := &MockRowSource{ctrl: ctrl}
+	mock.recorder = &MockRowSourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRowSource) EXPECT() *MockRowSourceMockRecorder {
+	return m.recorder
+}
+
+// Err mocks base method.
+func (m *MockRowSource) Err() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Err")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Err indicates an expected call of Err.
+func (mr *MockRowSourceMockRecorder) Err() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Err", reflect.TypeOf((*MockRowSource)(nil).Err))
+}
+
+// Next mocks base method.
+func (m *MockRowSource) Next() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Next")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Next indicates an expected call of Next.
+func (mr *MockRowSourceMockRecorder) Next() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Next", reflect.TypeOf((*MockRowSource)(nil).Next))
+}
+
+// Values mocks base method.
+func (m *MockRowSource) Values() []interface{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Values")
+	ret0, _ := ret[0].([]interface{})
+	return ret0
+}
+
+// Values indicates an expected call of Values.
+func (mr *MockRowSourceMockRecorder) Values() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Values", reflect.TypeOf((*MockRowSource)(nil).Values))
+}
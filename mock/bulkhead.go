@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: bulkhead.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	sql "database/sql"
+	reflect "reflect"
+
+	isql "github.com/0xor1/isql"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockBulkhead is a mock of Bulkhead interface.
+type MockBulkhead struct {
+	ctrl     *gomock.Controller
+	recorder *MockBulkheadMockRecorder
+}
+
+// MockBulkheadMockRecorder is the mock recorder for MockBulkhead.
+type MockBulkheadMockRecorder struct {
+	mock *MockBulkhead
+}
+
+// NewMockBulkhead creates a new mock instance.
+func NewMockBulkhead(ctrl *gomock.Controller) *MockBulkhead {
+	mock := &MockBulkhead{ctrl: ctrl}
+	mock.recorder = &MockBulkheadMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBulkhead) EXPECT() *MockBulkheadMockRecorder {
+	return m.recorder
+}
+
+// ExecContext mocks base method.
+func (m *MockBulkhead) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext.
+func (mr *MockBulkheadMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockBulkhead)(nil).ExecContext), varargs...)
+}
+
+// QueryContext mocks base method.
+func (m *MockBulkhead) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(isql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext.
+func (mr *MockBulkheadMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockBulkhead)(nil).QueryContext), varargs...)
+}
+
+// QueryRowContext mocks base method.
+func (m *MockBulkhead) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
+	ret0, _ := ret[0].(isql.Row)
+	return ret0
+}
+
+// QueryRowContext indicates an expected call of QueryRowContext.
+func (mr *MockBulkheadMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockBulkhead)(nil).QueryRowContext), varargs...)
+}
+
+// Stats mocks base method.
+func (m *MockBulkhead) Stats() isql.BulkheadStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(isql.BulkheadStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockBulkheadMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockBulkhead)(nil).Stats))
+}
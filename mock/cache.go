@@ -0,0 +1,209 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: cache.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	sql "database/sql"
+	reflect "reflect"
+	time "time"
+
+	isql "github.com/0xor1/isql"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCacheStore is a mock of CacheStore interface.
+type MockCacheStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheStoreMockRecorder
+}
+
+// MockCacheStoreMockRecorder is the mock recorder for MockCacheStore.
+type MockCacheStoreMockRecorder struct {
+	mock *MockCacheStore
+}
+
+// NewMockCacheStore creates a new mock instance.
+func NewMockCacheStore(ctrl *gomock.Controller) *MockCacheStore {
+	mock := &MockCacheStore{ctrl: ctrl}
+	mock.recorder = &MockCacheStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheStore) EXPECT() *MockCacheStoreMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockCacheStore) Get(key string) ([]isql.ResultSet, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", key)
+	ret0, _ := ret[0].([]isql.ResultSet)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockCacheStoreMockRecorder) Get(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCacheStore)(nil).Get), key)
+}
+
+// InvalidateTags mocks base method.
+func (m *MockCacheStore) InvalidateTags(tags ...string) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range tags {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "InvalidateTags", varargs...)
+}
+
+// InvalidateTags indicates an expected call of InvalidateTags.
+func (mr *MockCacheStoreMockRecorder) InvalidateTags(tags ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateTags", reflect.TypeOf((*MockCacheStore)(nil).InvalidateTags), tags...)
+}
+
+// Set mocks base method.
+func (m *MockCacheStore) Set(key string, sets []isql.ResultSet, ttl time.Duration, tags []string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Set", key, sets, ttl, tags)
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockCacheStoreMockRecorder) Set(key, sets, ttl, tags interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCacheStore)(nil).Set), key, sets, ttl, tags)
+}
+
+// MockCache is a mock of Cache interface.
+type MockCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheMockRecorder
+}
+
+// MockCacheMockRecorder is the mock recorder for MockCache.
+type MockCacheMockRecorder struct {
+	mock *MockCache
+}
+
+// NewMockCache creates a new mock instance.
+func NewMockCache(ctrl *gomock.Controller) *MockCache {
+	mock := &MockCache{ctrl: ctrl}
+	mock.recorder = &MockCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCache) EXPECT() *MockCacheMockRecorder {
+	return m.recorder
+}
+
+// ExecContext mocks base method.
+func (m *MockCache) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext.
+func (mr *MockCacheMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockCache)(nil).ExecContext), varargs...)
+}
+
+// InvalidateTables mocks base method.
+func (m *MockCache) InvalidateTables(tables ...string) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range tables {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "InvalidateTables", varargs...)
+}
+
+// InvalidateTables indicates an expected call of InvalidateTables.
+func (mr *MockCacheMockRecorder) InvalidateTables(tables ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateTables", reflect.TypeOf((*MockCache)(nil).InvalidateTables), tables...)
+}
+
+// InvalidateTags mocks base method.
+func (m *MockCache) InvalidateTags(tags ...string) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range tags {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "InvalidateTags", varargs...)
+}
+
+// InvalidateTags indicates an expected call of InvalidateTags.
+func (mr *MockCacheMockRecorder) InvalidateTags(tags ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateTags", reflect.TypeOf((*MockCache)(nil).InvalidateTags), tags...)
+}
+
+// QueryContext mocks base method.
+func (m *MockCache) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(isql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext.
+func (mr *MockCacheMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockCache)(nil).QueryContext), varargs...)
+}
+
+// QueryRowContext mocks base method.
+func (m *MockCache) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
+	ret0, _ := ret[0].(isql.Row)
+	return ret0
+}
+
+// QueryRowContext indicates an expected call of QueryRowContext.
+func (mr *MockCacheMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockCache)(nil).QueryRowContext), varargs...)
+}
+
+// Stats mocks base method.
+func (m *MockCache) Stats() isql.CacheStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(isql.CacheStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockCacheMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockCache)(nil).Stats))
+}
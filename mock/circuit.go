@@ -0,0 +1,124 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: circuit.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	sql "database/sql"
+	reflect "reflect"
+
+	isql "github.com/0xor1/isql"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCircuitBreaker is a mock of CircuitBreaker interface.
+type MockCircuitBreaker struct {
+	ctrl     *gomock.Controller
+	recorder *MockCircuitBreakerMockRecorder
+}
+
+// MockCircuitBreakerMockRecorder is the mock recorder for MockCircuitBreaker.
+type MockCircuitBreakerMockRecorder struct {
+	mock *MockCircuitBreaker
+}
+
+// NewMockCircuitBreaker creates a new mock instance.
+func NewMockCircuitBreaker(ctrl *gomock.Controller) *MockCircuitBreaker {
+	mock := &MockCircuitBreaker{ctrl: ctrl}
+	mock.recorder = &MockCircuitBreakerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCircuitBreaker) EXPECT() *MockCircuitBreakerMockRecorder {
+	return m.recorder
+}
+
+// Available mocks base method.
+func (m *MockCircuitBreaker) Available() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Available")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Available indicates an expected call of Available.
+func (mr *MockCircuitBreakerMockRecorder) Available() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Available", reflect.TypeOf((*MockCircuitBreaker)(nil).Available))
+}
+
+// ExecContext mocks base method.
+func (m *MockCircuitBreaker) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext.
+func (mr *MockCircuitBreakerMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockCircuitBreaker)(nil).ExecContext), varargs...)
+}
+
+// QueryContext mocks base method.
+func (m *MockCircuitBreaker) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(isql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext.
+func (mr *MockCircuitBreakerMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockCircuitBreaker)(nil).QueryContext), varargs...)
+}
+
+// QueryRowContext mocks base method.
+func (m *MockCircuitBreaker) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
+	ret0, _ := ret[0].(isql.Row)
+	return ret0
+}
+
+// QueryRowContext indicates an expected call of QueryRowContext.
+func (mr *MockCircuitBreakerMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockCircuitBreaker)(nil).QueryRowContext), varargs...)
+}
+
+// State mocks base method.
+func (m *MockCircuitBreaker) State() isql.CircuitState {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "State")
+	ret0, _ := ret[0].(isql.CircuitState)
+	return ret0
+}
+
+// State indicates an expected call of State.
+func (mr *MockCircuitBreakerMockRecorder) State() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockCircuitBreaker)(nil).State))
+}
@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: coalesce.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	sql "database/sql"
+	reflect "reflect"
+
+	isql "github.com/0xor1/isql"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCoalescer is a mock of Coalescer interface.
+type MockCoalescer struct {
+	ctrl     *gomock.Controller
+	recorder *MockCoalescerMockRecorder
+}
+
+// MockCoalescerMockRecorder is the mock recorder for MockCoalescer.
+type MockCoalescerMockRecorder struct {
+	mock *MockCoalescer
+}
+
+// NewMockCoalescer creates a new mock instance.
+func NewMockCoalescer(ctrl *gomock.Controller) *MockCoalescer {
+	mock := &MockCoalescer{ctrl: ctrl}
+	mock.recorder = &MockCoalescerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCoalescer) EXPECT() *MockCoalescerMockRecorder {
+	return m.recorder
+}
+
+// ExecContext mocks base method.
+func (m *MockCoalescer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext.
+func (mr *MockCoalescerMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockCoalescer)(nil).ExecContext), varargs...)
+}
+
+// QueryContext mocks base method.
+func (m *MockCoalescer) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(isql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext.
+func (mr *MockCoalescerMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockCoalescer)(nil).QueryContext), varargs...)
+}
+
+// QueryRowContext mocks base method.
+func (m *MockCoalescer) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
+	ret0, _ := ret[0].(isql.Row)
+	return ret0
+}
+
+// QueryRowContext indicates an expected call of QueryRowContext.
+func (mr *MockCoalescerMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockCoalescer)(nil).QueryRowContext), varargs...)
+}
+
+// Stats mocks base method.
+func (m *MockCoalescer) Stats() isql.CoalescerStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(isql.CoalescerStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockCoalescerMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockCoalescer)(nil).Stats))
+}
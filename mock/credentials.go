@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: credentials.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	driver "database/sql/driver"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCredentialProvider is a mock of CredentialProvider interface.
+type MockCredentialProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockCredentialProviderMockRecorder
+}
+
+// MockCredentialProviderMockRecorder is the mock recorder for MockCredentialProvider.
+type MockCredentialProviderMockRecorder struct {
+	mock *MockCredentialProvider
+}
+
+// NewMockCredentialProvider creates a new mock instance.
+func NewMockCredentialProvider(ctrl *gomock.Controller) *MockCredentialProvider {
+	mock := &MockCredentialProvider{ctrl: ctrl}
+	mock.recorder = &MockCredentialProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCredentialProvider) EXPECT() *MockCredentialProviderMockRecorder {
+	return m.recorder
+}
+
+// DataSourceName mocks base method.
+func (m *MockCredentialProvider) DataSourceName(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DataSourceName", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DataSourceName indicates an expected call of DataSourceName.
+func (mr *MockCredentialProviderMockRecorder) DataSourceName(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DataSourceName", reflect.TypeOf((*MockCredentialProvider)(nil).DataSourceName), ctx)
+}
+
+// MockCredentialConnector is a mock of CredentialConnector interface.
+type MockCredentialConnector struct {
+	ctrl     *gomock.Controller
+	recorder *MockCredentialConnectorMockRecorder
+}
+
+// MockCredentialConnectorMockRecorder is the mock recorder for MockCredentialConnector.
+type MockCredentialConnectorMockRecorder struct {
+	mock *MockCredentialConnector
+}
+
+// NewMockCredentialConnector creates a new mock instance.
+func NewMockCredentialConnector(ctrl *gomock.Controller) *MockCredentialConnector {
+	mock := &MockCredentialConnector{ctrl: ctrl}
+	mock.recorder = &MockCredentialConnectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCredentialConnector) EXPECT() *MockCredentialConnectorMockRecorder {
+	return m.recorder
+}
+
+// Connect mocks base method.
+func (m *MockCredentialConnector) Connect(arg0 context.Context) (driver.Conn, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Connect", arg0)
+	ret0, _ := ret[0].(driver.Conn)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Connect indicates an expected call of Connect.
+func (mr *MockCredentialConnectorMockRecorder) Connect(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockCredentialConnector)(nil).Connect), arg0)
+}
+
+// Driver mocks base method.
+func (m *MockCredentialConnector) Driver() driver.Driver {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Driver")
+	ret0, _ := ret[0].(driver.Driver)
+	return ret0
+}
+
+// Driver indicates an expected call of Driver.
+func (mr *MockCredentialConnectorMockRecorder) Driver() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Driver", reflect.TypeOf((*MockCredentialConnector)(nil).Driver))
+}
+
+// Rotate mocks base method.
+func (m *MockCredentialConnector) Rotate() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Rotate")
+}
+
+// Rotate indicates an expected call of Rotate.
+func (mr *MockCredentialConnectorMockRecorder) Rotate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockCredentialConnector)(nil).Rotate))
+}
@@ -0,0 +1,104 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: dialect.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockDialect is a mock of Dialect interface.
+type MockDialect struct {
+	ctrl     *gomock.Controller
+	recorder *MockDialectMockRecorder
+}
+
+// MockDialectMockRecorder is the mock recorder for MockDialect.
+type MockDialectMockRecorder struct {
+	mock *MockDialect
+}
+
+// NewMockDialect creates a new mock instance.
+func NewMockDialect(ctrl *gomock.Controller) *MockDialect {
+	mock := &MockDialect{ctrl: ctrl}
+	mock.recorder = &MockDialectMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDialect) EXPECT() *MockDialectMockRecorder {
+	return m.recorder
+}
+
+// MaxParams mocks base method.
+func (m *MockDialect) MaxParams() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxParams")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// MaxParams indicates an expected call of MaxParams.
+func (mr *MockDialectMockRecorder) MaxParams() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxParams", reflect.TypeOf((*MockDialect)(nil).MaxParams))
+}
+
+// MaxRows mocks base method.
+func (m *MockDialect) MaxRows() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxRows")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// MaxRows indicates an expected call of MaxRows.
+func (mr *MockDialectMockRecorder) MaxRows() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxRows", reflect.TypeOf((*MockDialect)(nil).MaxRows))
+}
+
+// Name mocks base method.
+func (m *MockDialect) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockDialectMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockDialect)(nil).Name))
+}
+
+// Placeholder mocks base method.
+func (m *MockDialect) Placeholder(n int) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Placeholder", n)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Placeholder indicates an expected call of Placeholder.
+func (mr *MockDialectMockRecorder) Placeholder(n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Placeholder", reflect.TypeOf((*MockDialect)(nil).Placeholder), n)
+}
+
+// QuoteIdent mocks base method.
+func (m *MockDialect) QuoteIdent(ident string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuoteIdent", ident)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// QuoteIdent indicates an expected call of QuoteIdent.
+func (mr *MockDialectMockRecorder) QuoteIdent(ident interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuoteIdent", reflect.TypeOf((*MockDialect)(nil).QuoteIdent), ident)
+}
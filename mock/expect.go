@@ -0,0 +1,211 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/0xor1/isql"
+	"github.com/golang/mock/gomock"
+)
+
+// Expect returns a fluent builder of query level expectations on db which must be a *MockDB, *MockDBCore,
+// *MockTx, *MockReplicaSet or a mock of a DBCore decorator such as *MockCircuitBreaker. Expectations declared
+// through the same Expecter must be met in the order they are declared, so keep the Expecter to declare a
+// sequence, unmet expectations are reported by the gomock.Controller on Finish.
+func Expect(db interface{}) *Expecter {
+	var r recorder
+	switch m := db.(type) {
+	case *MockDB:
+		r = recorder{m.EXPECT().ExecContext, m.EXPECT().QueryContext, m.EXPECT().QueryRowContext}
+	case *MockDBCore:
+		r = recorder{m.EXPECT().ExecContext, m.EXPECT().QueryContext, m.EXPECT().QueryRowContext}
+	case *MockTx:
+		r = recorder{m.EXPECT().ExecContext, m.EXPECT().QueryContext, m.EXPECT().QueryRowContext}
+	case *MockReplicaSet:
+		r = recorder{m.EXPECT().ExecContext, m.EXPECT().QueryContext, m.EXPECT().QueryRowContext}
+	case *MockBulkhead:
+		r = recorder{m.EXPECT().ExecContext, m.EXPECT().QueryContext, m.EXPECT().QueryRowContext}
+	case *MockCache:
+		r = recorder{m.EXPECT().ExecContext, m.EXPECT().QueryContext, m.EXPECT().QueryRowContext}
+	case *MockCircuitBreaker:
+		r = recorder{m.EXPECT().ExecContext, m.EXPECT().QueryContext, m.EXPECT().QueryRowContext}
+	case *MockCoalescer:
+		r = recorder{m.EXPECT().ExecContext, m.EXPECT().QueryContext, m.EXPECT().QueryRowContext}
+	case *MockShardSet:
+		r = recorder{m.EXPECT().ExecContext, m.EXPECT().QueryContext, m.EXPECT().QueryRowContext}
+	default:
+		panic(fmt.Sprintf("mock: Expect does not support %T", db))
+	}
+	return &Expecter{
+		db:       db,
+		recorder: r,
+	}
+}
+
+type Expecter struct {
+	db       interface{}
+	recorder recorder
+	mtx      sync.Mutex
+	last     *gomock.Call
+}
+
+func (e *Expecter) Query(query string) *QueryExpectation {
+	return &QueryExpectation{
+		expecter: e,
+		query:    query,
+	}
+}
+
+func (e *Expecter) QueryRow(query string) *QueryExpectation {
+	return &QueryExpectation{
+		expecter: e,
+		query:    query,
+		row:      true,
+	}
+}
+
+func (e *Expecter) Exec(query string) *ExecExpectation {
+	return &ExecExpectation{
+		expecter: e,
+		query:    query,
+	}
+}
+
+// inOrder registers c to be called after the previous expectation declared through e.
+func (e *Expecter) inOrder(c *gomock.Call) *gomock.Call {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.last != nil {
+		c.After(e.last)
+	}
+	e.last = c
+	return c
+}
+
+type QueryExpectation struct {
+	expecter *Expecter
+	query    string
+	args     []interface{}
+	row      bool
+}
+
+// WithArgs sets the expected query args, each arg may be a gomock.Matcher, it must be called before WillReturnRows
+// or WillReturnError, if it is not called the query is expected to have no args.
+func (q *QueryExpectation) WithArgs(args ...interface{}) *QueryExpectation {
+	q.args = args
+	return q
+}
+
+func (q *QueryExpectation) WillReturnRows(rows *Rows) *gomock.Call {
+	if q.row {
+		return q.register(func(ctx context.Context, query string, args ...interface{}) isql.Row {
+			return rows.row()
+		})
+	}
+	return q.register(func(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+		return rows.rows()
+	})
+}
+
+func (q *QueryExpectation) WillReturnError(err error) *gomock.Call {
+	if q.row {
+		return q.register(func(ctx context.Context, query string, args ...interface{}) isql.Row {
+			return &errRow{err: err}
+		})
+	}
+	return q.register(func(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+		return nil, err
+	})
+}
+
+func (q *QueryExpectation) register(f interface{}) *gomock.Call {
+	record := q.expecter.recorder.queryContext
+	if q.row {
+		record = q.expecter.recorder.queryRowContext
+	}
+	return q.expecter.inOrder(record(gomock.Any(), q.query, q.args...).DoAndReturn(f))
+}
+
+type ExecExpectation struct {
+	expecter *Expecter
+	query    string
+	args     []interface{}
+}
+
+// WithArgs sets the expected exec args, each arg may be a gomock.Matcher, it must be called before WillReturnResult
+// or WillReturnError, if it is not called the exec is expected to have no args.
+func (e *ExecExpectation) WithArgs(args ...interface{}) *ExecExpectation {
+	e.args = args
+	return e
+}
+
+func (e *ExecExpectation) WillReturnResult(lastInsertID, rowsAffected int64) *gomock.Call {
+	return e.register(isql.NewResult(lastInsertID, rowsAffected), nil)
+}
+
+func (e *ExecExpectation) WillReturnError(err error) *gomock.Call {
+	return e.register(nil, err)
+}
+
+func (e *ExecExpectation) register(res sql.Result, err error) *gomock.Call {
+	return e.expecter.inOrder(e.expecter.recorder.execContext(gomock.Any(), e.query, e.args...).Return(res, err))
+}
+
+// NewRows returns a builder of canned rows which scan into dest pointers with the same conversions as "database/sql".
+func NewRows(columns ...string) *Rows {
+	return &Rows{
+		columns: columns,
+	}
+}
+
+type Rows struct {
+	columns []string
+	values  [][]interface{}
+	err     error
+	errAt   int
+}
+
+func (r *Rows) AddRow(values ...interface{}) *Rows {
+	r.values = append(r.values, values)
+	return r
+}
+
+// RowError makes Next fail with err when advancing to the row at index row.
+func (r *Rows) RowError(row int, err error) *Rows {
+	r.errAt = row
+	r.err = err
+	return r
+}
+
+func (r *Rows) resultSet() isql.ResultSet {
+	return isql.ResultSet{
+		Columns: r.columns,
+		Rows:    r.values,
+		Err:     r.err,
+		ErrAt:   r.errAt,
+	}
+}
+
+func (r *Rows) rows() (isql.Rows, error) {
+	return isql.NewDataRows(r.resultSet())
+}
+
+func (r *Rows) row() isql.Row {
+	return isql.NewDataRow(r.resultSet())
+}
+
+type recorder struct {
+	execContext     func(ctx, query interface{}, args ...interface{}) *gomock.Call
+	queryContext    func(ctx, query interface{}, args ...interface{}) *gomock.Call
+	queryRowContext func(ctx, query interface{}, args ...interface{}) *gomock.Call
+}
+
+type errRow struct {
+	err error
+}
+
+func (r *errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
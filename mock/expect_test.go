@@ -0,0 +1,115 @@
+package mock_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/0xor1/isql/mock"
+	"github.com/golang/mock/gomock"
+)
+
+// fatalReporter panics on Fatalf so a test can recover from an unexpected call gomock reports.
+type fatalReporter struct {
+	t *testing.T
+}
+
+func (r *fatalReporter) Errorf(format string, args ...interface{}) {
+	r.t.Errorf(format, args...)
+}
+
+func (r *fatalReporter) Fatalf(format string, args ...interface{}) {
+	panic(fmt.Sprintf(format, args...))
+}
+
+func TestExpect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := mock.NewMockDB(ctrl)
+	boom := errors.New("boom")
+	e := mock.Expect(db)
+	e.Query("SELECT id, name FROM u").WithArgs(1).WillReturnRows(mock.NewRows("id", "name").AddRow(1, "a").AddRow(2, []byte("b")))
+	e.Exec("DELETE FROM u").WillReturnResult(0, 4)
+	e.QueryRow("SELECT x").WillReturnRows(mock.NewRows("x"))
+	e.QueryRow("SELECT y").WillReturnError(boom)
+	ctx := context.Background()
+
+	rows, err := db.QueryContext(ctx, "SELECT id, name FROM u", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if strings.Join(names, ",") != "a,b" {
+		t.Fatalf("read %v", names)
+	}
+	res, err := db.ExecContext(ctx, "DELETE FROM u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 4 {
+		t.Fatalf("rows affected %d", n)
+	}
+	var x int
+	if err := db.QueryRowContext(ctx, "SELECT x").Scan(&x); err == nil {
+		t.Fatal("expected no rows")
+	}
+	if err := db.QueryRowContext(ctx, "SELECT y").Scan(&x); err != boom {
+		t.Fatalf("error %v, expected boom", err)
+	}
+}
+
+func TestExpectOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		same     bool
+		order    []string
+		expected bool
+	}{
+		{"same expecter in order", true, []string{"A", "B"}, true},
+		{"same expecter out of order", true, []string{"B", "A"}, false},
+		{"separate expecters out of order", false, []string{"B", "A"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(&fatalReporter{t: t})
+			db := mock.NewMockDBCore(ctrl)
+			e := mock.Expect(db)
+			e.Exec("A").WillReturnResult(0, 1)
+			if !tt.same {
+				e = mock.Expect(db)
+			}
+			e.Exec("B").WillReturnResult(0, 1)
+			ok := func() (ok bool) {
+				defer func() {
+					ok = recover() == nil
+				}()
+				for _, query := range tt.order {
+					db.ExecContext(context.Background(), query)
+				}
+				return
+			}()
+			if ok != tt.expected {
+				t.Fatalf("calls succeeded %t, expected %t", ok, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExpectUnsupported(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Expect to panic")
+		}
+	}()
+	mock.Expect(struct{}{})
+}
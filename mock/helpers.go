@@ -0,0 +1,85 @@
+package mock
+
+import (
+	"database/sql"
+
+	"github.com/0xor1/isql"
+	"github.com/golang/mock/gomock"
+)
+
+// NewMockRowsFrom returns a MockRows which serves rows, Next, Scan, Columns, ColumnTypes, Err and NextResultSet
+// may be called any number of times and Close must be called at least once.
+func NewMockRowsFrom(ctrl *gomock.Controller, rows *Rows) *MockRows {
+	data, err := rows.rows()
+	if err != nil {
+		ctrl.T.Fatalf("mock: %s", err)
+	}
+	m := NewMockRows(ctrl)
+	m.EXPECT().Next().DoAndReturn(data.Next).AnyTimes()
+	m.EXPECT().Scan(gomock.Any()).DoAndReturn(data.Scan).AnyTimes()
+	m.EXPECT().Columns().DoAndReturn(data.Columns).AnyTimes()
+	m.EXPECT().ColumnTypes().DoAndReturn(data.ColumnTypes).AnyTimes()
+	m.EXPECT().Err().DoAndReturn(data.Err).AnyTimes()
+	m.EXPECT().NextResultSet().DoAndReturn(data.NextResultSet).AnyTimes()
+	m.EXPECT().Close().DoAndReturn(data.Close).MinTimes(1)
+	return m
+}
+
+// NewMockRowFrom returns a MockRow which must be scanned once, it scans the first row of rows or returns
+// sql.ErrNoRows if there is none.
+func NewMockRowFrom(ctrl *gomock.Controller, rows *Rows) *MockRow {
+	m := NewMockRow(ctrl)
+	m.EXPECT().Scan(gomock.Any()).DoAndReturn(rows.row().Scan)
+	return m
+}
+
+// NewMockRowError returns a MockRow which must be scanned once and returns err.
+func NewMockRowError(ctrl *gomock.Controller, err error) *MockRow {
+	m := NewMockRow(ctrl)
+	m.EXPECT().Scan(gomock.Any()).Return(err)
+	return m
+}
+
+// NewMockDBQuery returns a MockDB which expects QueryContext to be called once with query and args and returns a
+// MockRows serving rows. query may be a string or a gomock.Matcher such as QueryEq, if no args are given the query
+// is expected to have none.
+func NewMockDBQuery(ctrl *gomock.Controller, query interface{}, rows *Rows, args ...interface{}) (*MockDB, *MockRows) {
+	db := NewMockDB(ctrl)
+	mockRows := NewMockRowsFrom(ctrl, rows)
+	db.EXPECT().QueryContext(gomock.Any(), query, args...).Return(mockRows, nil)
+	return db, mockRows
+}
+
+// NewMockDBQueryRow returns a MockDB which expects QueryRowContext to be called once with query and args and
+// returns a MockRow scanning the first row of rows, see NewMockDBQuery for query and args.
+func NewMockDBQueryRow(ctrl *gomock.Controller, query interface{}, rows *Rows, args ...interface{}) (*MockDB, *MockRow) {
+	db := NewMockDB(ctrl)
+	row := NewMockRowFrom(ctrl, rows)
+	db.EXPECT().QueryRowContext(gomock.Any(), query, args...).Return(row)
+	return db, row
+}
+
+// NewMockDBExec returns a MockDB which expects ExecContext to be called once with query and args and returns a
+// result of lastInsertID and rowsAffected, see NewMockDBQuery for query and args.
+func NewMockDBExec(ctrl *gomock.Controller, query interface{}, lastInsertID, rowsAffected int64, args ...interface{}) *MockDB {
+	db := NewMockDB(ctrl)
+	db.EXPECT().ExecContext(gomock.Any(), query, args...).Return(isql.NewResult(lastInsertID, rowsAffected), nil)
+	return db
+}
+
+// NewMockDBTx returns a MockDB which expects BeginTx to be called once and returns the MockTx, which expects
+// Commit to be called once if commit is true or Rollback otherwise. Rollback may also be called any number of
+// times after Commit, as it is by the common defer tx.Rollback() pattern. Expectations for the statements run in
+// the transaction are declared on the returned MockTx, e.g. with Expect(tx).
+func NewMockDBTx(ctrl *gomock.Controller, commit bool) (*MockDB, *MockTx) {
+	db := NewMockDB(ctrl)
+	tx := NewMockTx(ctrl)
+	db.EXPECT().BeginTx(gomock.Any(), gomock.Any()).Return(tx, nil)
+	if commit {
+		committed := tx.EXPECT().Commit().Return(nil)
+		tx.EXPECT().Rollback().Return(sql.ErrTxDone).After(committed).AnyTimes()
+	} else {
+		tx.EXPECT().Rollback().Return(nil)
+	}
+	return db, tx
+}
@@ -0,0 +1,234 @@
+package mock_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/mock"
+	"github.com/golang/mock/gomock"
+)
+
+// captureReporter records what gomock reports and panics on Fatalf, as gomock expects Fatalf not to return.
+type captureReporter struct {
+	errors []string
+}
+
+func (r *captureReporter) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *captureReporter) Fatalf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+	panic(r.errors[len(r.errors)-1])
+}
+
+// reported runs f then finishes ctrl, returning whether gomock reported a failure to r.
+func reported(r *captureReporter, ctrl *gomock.Controller, f func()) bool {
+	func() {
+		defer func() {
+			recover()
+		}()
+		f()
+		ctrl.Finish()
+	}()
+	return len(r.errors) > 0
+}
+
+func readIDs(t *testing.T, rows isql.Rows) []int64 {
+	t.Helper()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func TestNewMockRowsFrom(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	rows := mock.NewMockRowsFrom(ctrl, mock.NewRows("id").AddRow(1).AddRow(int64(2)))
+	if columns, err := rows.Columns(); err != nil || !reflect.DeepEqual(columns, []string{"id"}) {
+		t.Fatalf("columns %v with error %v", columns, err)
+	}
+	if ids := readIDs(t, rows); !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Fatalf("ids %v", ids)
+	}
+	if rows.NextResultSet() {
+		t.Fatal("expected one result set")
+	}
+	rows.Close()
+	rows.Close()
+
+	boom := errors.New("boom")
+	rows = mock.NewMockRowsFrom(ctrl, mock.NewRows("id").AddRow(1).AddRow(2).RowError(1, boom))
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != boom {
+		t.Fatalf("error %v, expected %v", err, boom)
+	}
+	rows.Close()
+
+	r := &captureReporter{}
+	unclosed := gomock.NewController(r)
+	if !reported(r, unclosed, func() {
+		mock.NewMockRowsFrom(unclosed, mock.NewRows("id"))
+	}) {
+		t.Fatal("expected rows which are never closed to be reported")
+	}
+}
+
+func TestNewMockDBQuery(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	db, rows := mock.NewMockDBQuery(ctrl, mock.QueryEq("SELECT id FROM t WHERE a = ?"), mock.NewRows("id").AddRow(1), mock.Arg(5))
+	got, err := db.QueryContext(ctx, "SELECT id\n\tFROM t WHERE a = ?", int64(5))
+	if err != nil || got != rows {
+		t.Fatalf("rows %v with error %v, expected the returned MockRows", got, err)
+	}
+	if ids := readIDs(t, got); !reflect.DeepEqual(ids, []int64{1}) {
+		t.Fatalf("ids %v", ids)
+	}
+	got.Close()
+
+	tests := []struct {
+		name string
+		call func(db *mock.MockDB)
+	}{
+		{"other query", func(db *mock.MockDB) { db.QueryContext(ctx, "SELECT b FROM t", 5) }},
+		{"other args", func(db *mock.MockDB) { db.QueryContext(ctx, "SELECT id FROM t", 6) }},
+		{"extra args", func(db *mock.MockDB) { db.QueryContext(ctx, "SELECT id FROM t", 5, 6) }},
+		{"not called", func(db *mock.MockDB) {}},
+	}
+	for _, tt := range tests {
+		r := &captureReporter{}
+		ctrl := gomock.NewController(r)
+		db, _ := mock.NewMockDBQuery(ctrl, "SELECT id FROM t", mock.NewRows("id"), 5)
+		if !reported(r, ctrl, func() { tt.call(db) }) {
+			t.Errorf("%s: expected a failure to be reported", tt.name)
+		}
+	}
+}
+
+func TestNewMockDBQueryRow(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	db, _ := mock.NewMockDBQueryRow(ctrl, "SELECT name FROM t WHERE id = ?", mock.NewRows("name").AddRow("a").AddRow("b"), 1)
+	var name string
+	if err := db.QueryRowContext(ctx, "SELECT name FROM t WHERE id = ?", 1).Scan(&name); err != nil || name != "a" {
+		t.Fatalf("scanned %q with error %v, expected the first row", name, err)
+	}
+	db, _ = mock.NewMockDBQueryRow(ctrl, mock.QueryRegex("^SELECT name"), mock.NewRows("name"))
+	if err := db.QueryRowContext(ctx, "SELECT name FROM t").Scan(&name); err != sql.ErrNoRows {
+		t.Fatalf("error %v, expected %v", err, sql.ErrNoRows)
+	}
+
+	r := &captureReporter{}
+	unscanned := gomock.NewController(r)
+	if !reported(r, unscanned, func() {
+		db, _ := mock.NewMockDBQueryRow(unscanned, "SELECT name FROM t", mock.NewRows("name").AddRow("a"))
+		db.QueryRowContext(ctx, "SELECT name FROM t")
+	}) {
+		t.Fatal("expected a row which is never scanned to be reported")
+	}
+}
+
+func TestNewMockDBExec(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := mock.NewMockDBExec(ctrl, mock.QueryEq("UPDATE t SET a = ?"), 3, 2, mock.Args("x"))
+	res, err := db.ExecContext(context.Background(), "UPDATE t  SET a = ?;", "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	n, _ := res.RowsAffected()
+	if id != 3 || n != 2 {
+		t.Fatalf("last insert id %d and rows affected %d", id, n)
+	}
+
+	r := &captureReporter{}
+	twice := gomock.NewController(r)
+	if !reported(r, twice, func() {
+		db := mock.NewMockDBExec(twice, "UPDATE t", 0, 1)
+		db.ExecContext(context.Background(), "UPDATE t")
+		db.ExecContext(context.Background(), "UPDATE t")
+	}) {
+		t.Fatal("expected a second exec to be reported")
+	}
+}
+
+func TestNewMockDBTx(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		commit bool
+		run    func(db *mock.MockDB) error
+		fails  bool
+	}{
+		{"commit", true, func(db *mock.MockDB) error {
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+			return tx.Commit()
+		}, false},
+		{"rollback", false, func(db *mock.MockDB) error {
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			return tx.Rollback()
+		}, false},
+		{"rollback instead of commit", true, func(db *mock.MockDB) error {
+			tx, _ := db.BeginTx(ctx, nil)
+			return tx.Rollback()
+		}, true},
+		{"commit instead of rollback", false, func(db *mock.MockDB) error {
+			tx, _ := db.BeginTx(ctx, nil)
+			return tx.Commit()
+		}, true},
+		{"never begun", true, func(db *mock.MockDB) error {
+			return nil
+		}, true},
+	}
+	for _, tt := range tests {
+		r := &captureReporter{}
+		ctrl := gomock.NewController(r)
+		var err error
+		fails := reported(r, ctrl, func() {
+			db, _ := mock.NewMockDBTx(ctrl, tt.commit)
+			err = tt.run(db)
+		})
+		if fails != tt.fails || err != nil {
+			t.Errorf("%s: reported %q with error %v, expected a failure %t", tt.name, r.errors, err, tt.fails)
+		}
+	}
+
+	ctrl := gomock.NewController(t)
+	db, tx := mock.NewMockDBTx(ctrl, true)
+	tx.EXPECT().ExecContext(ctx, "UPDATE t").Return(isql.NewResult(0, 1), nil)
+	begun, err := db.BeginTx(ctx, nil)
+	if err != nil || begun != tx {
+		t.Fatalf("tx %v with error %v, expected the returned MockTx", begun, err)
+	}
+	if _, err := begun.ExecContext(ctx, "UPDATE t"); err != nil {
+		t.Fatal(err)
+	}
+	if err := begun.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := begun.Rollback(); err != sql.ErrTxDone {
+		t.Fatalf("error %v, expected a rollback after commit to return %v", err, sql.ErrTxDone)
+	}
+}
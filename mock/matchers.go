@@ -0,0 +1,148 @@
+package mock
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+// QueryEq matches a query which equals query once runs of whitespace have been collapsed to a single space and
+// leading and trailing whitespace and a trailing semicolon have been dropped, so multi line queries can be
+// expected without copying their exact layout, e.g. db.EXPECT().QueryContext(gomock.Any(), mock.QueryEq("SELECT id FROM t")).
+func QueryEq(query string) gomock.Matcher {
+	return &queryEqMatcher{
+		query: normalizeQuery(query),
+	}
+}
+
+// QueryRegex matches a query which contains a match of pattern, it panics if pattern does not compile.
+func QueryRegex(pattern string) gomock.Matcher {
+	return &queryRegexMatcher{
+		re: regexp.MustCompile(pattern),
+	}
+}
+
+// Arg matches an argument which equals v once both have been converted to driver values the way "database/sql"
+// converts arguments, so Arg(1) matches int64(1) and Arg(sql.Named("id", 1)) matches sql.Named("id", int64(1)).
+// If v is a gomock.Matcher it is used as is.
+func Arg(v interface{}) gomock.Matcher {
+	if m, ok := v.(gomock.Matcher); ok {
+		return m
+	}
+	return &argMatcher{
+		v: v,
+	}
+}
+
+// Args matches the whole of a variadic args list, each element of vs is matched with Arg, pass it as the only
+// args matcher, e.g. db.EXPECT().ExecContext(gomock.Any(), mock.QueryEq(query), mock.Args(1, "a")).
+func Args(vs ...interface{}) gomock.Matcher {
+	ms := make([]gomock.Matcher, 0, len(vs))
+	for _, v := range vs {
+		ms = append(ms, Arg(v))
+	}
+	return &argsMatcher{
+		ms: ms,
+	}
+}
+
+type queryEqMatcher struct {
+	query string
+}
+
+func (m *queryEqMatcher) Matches(x interface{}) bool {
+	query, ok := x.(string)
+	return ok && normalizeQuery(query) == m.query
+}
+
+func (m *queryEqMatcher) String() string {
+	return fmt.Sprintf("is query %q", m.query)
+}
+
+type queryRegexMatcher struct {
+	re *regexp.Regexp
+}
+
+func (m *queryRegexMatcher) Matches(x interface{}) bool {
+	query, ok := x.(string)
+	return ok && m.re.MatchString(query)
+}
+
+func (m *queryRegexMatcher) String() string {
+	return fmt.Sprintf("is query matching %q", m.re.String())
+}
+
+type argMatcher struct {
+	v interface{}
+}
+
+func (m *argMatcher) Matches(x interface{}) bool {
+	want, err := argValue(m.v)
+	if err != nil {
+		return false
+	}
+	got, err := argValue(x)
+	if err != nil {
+		return false
+	}
+	if wantTime, ok := want.(time.Time); ok {
+		gotTime, ok := got.(time.Time)
+		return ok && wantTime.Equal(gotTime)
+	}
+	return reflect.DeepEqual(want, got)
+}
+
+func (m *argMatcher) String() string {
+	return fmt.Sprintf("is arg %v (%T)", m.v, m.v)
+}
+
+type argsMatcher struct {
+	ms []gomock.Matcher
+}
+
+func (m *argsMatcher) Matches(x interface{}) bool {
+	args, ok := x.([]interface{})
+	if !ok {
+		return false
+	}
+	if len(args) != len(m.ms) {
+		return false
+	}
+	for i, arg := range args {
+		if !m.ms[i].Matches(arg) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *argsMatcher) String() string {
+	parts := make([]string, 0, len(m.ms))
+	for _, am := range m.ms {
+		parts = append(parts, am.String())
+	}
+	return "args [" + strings.Join(parts, ", ") + "]"
+}
+
+// argValue converts an argument to the value a driver would receive, keeping the name of sql.NamedArgs.
+func argValue(v interface{}) (interface{}, error) {
+	if named, ok := v.(sql.NamedArg); ok {
+		value, err := argValue(named.Value)
+		if err != nil {
+			return nil, err
+		}
+		return sql.Named(named.Name, value), nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+// normalizeQuery collapses whitespace and drops a trailing semicolon.
+func normalizeQuery(query string) string {
+	return strings.TrimSpace(strings.TrimSuffix(strings.Join(strings.Fields(query), " "), ";"))
+}
@@ -0,0 +1,74 @@
+package mock_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql/mock"
+	"github.com/golang/mock/gomock"
+)
+
+func TestMatchers(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	id := 1
+	tests := []struct {
+		name    string
+		m       gomock.Matcher
+		matches []interface{}
+		misses  []interface{}
+		str     string
+	}{
+		{"query eq", mock.QueryEq("SELECT id\n\tFROM t;"),
+			[]interface{}{"SELECT id FROM t", "  SELECT  id FROM t ; "},
+			[]interface{}{"SELECT id FROM u", "SELECT idFROM t", 1},
+			`is query "SELECT id FROM t"`},
+		{"query regex", mock.QueryRegex("^SELECT .* FROM t$"),
+			[]interface{}{"SELECT id FROM t", "SELECT a, b FROM t"},
+			[]interface{}{"SELECT id FROM t2", "DELETE FROM t", 1},
+			`is query matching "^SELECT .* FROM t$"`},
+		{"arg", mock.Arg(1),
+			[]interface{}{1, int64(1), int32(1), uint8(1), &id},
+			[]interface{}{2, "1", 1.5, nil, struct{}{}},
+			"is arg 1 (int)"},
+		{"named arg", mock.Arg(sql.Named("id", 1)),
+			[]interface{}{sql.Named("id", int64(1))},
+			[]interface{}{sql.Named("other", 1), sql.Named("id", 2), 1},
+			"is arg {{} id 1} (sql.NamedArg)"},
+		{"time arg", mock.Arg(at),
+			[]interface{}{at, at.In(time.FixedZone("x", 3600))},
+			[]interface{}{at.Add(time.Second), at.String()},
+			"is arg 2024-01-02 03:04:05 +0000 UTC (time.Time)"},
+		{"unconvertible arg", mock.Arg(struct{}{}),
+			nil,
+			[]interface{}{struct{}{}},
+			"is arg {} (struct {})"},
+		{"matcher arg", mock.Arg(gomock.Nil()),
+			[]interface{}{nil},
+			[]interface{}{1},
+			"is nil"},
+		{"args", mock.Args(1, "a", gomock.Any()),
+			[]interface{}{[]interface{}{int64(1), "a", nil}, []interface{}{1, "a", 2}},
+			[]interface{}{[]interface{}{1, "a"}, []interface{}{1, "b", 2}, []interface{}{1, "a", 2, 3}, 1},
+			"args [is arg 1 (int), is arg a (string), is anything]"},
+		{"no args", mock.Args(),
+			[]interface{}{[]interface{}{}},
+			[]interface{}{[]interface{}{1}},
+			"args []"},
+	}
+	for _, tt := range tests {
+		for _, x := range tt.matches {
+			if !tt.m.Matches(x) {
+				t.Errorf("%s: expected %v (%T) to match", tt.name, x, x)
+			}
+		}
+		for _, x := range tt.misses {
+			if tt.m.Matches(x) {
+				t.Errorf("%s: expected %v (%T) not to match", tt.name, x, x)
+			}
+		}
+		if s := tt.m.String(); s != tt.str {
+			t.Errorf("%s: String() %q, expected %q", tt.name, s, tt.str)
+		}
+	}
+}
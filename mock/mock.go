@@ -8,176 +8,180 @@ import (
 	context "context"
 	sql "database/sql"
 	driver "database/sql/driver"
-	isql "github.com/0xor1/isql"
-	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
 	time "time"
+
+	isql "github.com/0xor1/isql"
+	gomock "github.com/golang/mock/gomock"
 )
 
-// MockOpener is a mock of Opener interface
+// MockOpener is a mock of Opener interface.
 type MockOpener struct {
 	ctrl     *gomock.Controller
 	recorder *MockOpenerMockRecorder
 }
 
-// MockOpenerMockRecorder is the mock recorder for MockOpener
+// MockOpenerMockRecorder is the mock recorder for MockOpener.
 type MockOpenerMockRecorder struct {
 	mock *MockOpener
 }
 
-// NewMockOpener creates a new mock instance
+// NewMockOpener creates a new mock instance.
 func NewMockOpener(ctrl *gomock.Controller) *MockOpener {
 	mock := &MockOpener{ctrl: ctrl}
 	mock.recorder = &MockOpenerMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockOpener) EXPECT() *MockOpenerMockRecorder {
 	return m.recorder
 }
 
-// Open mocks base method
+// Open mocks base method.
 func (m *MockOpener) Open(driverName, dataSourceName string) (isql.DB, error) {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Open", driverName, dataSourceName)
 	ret0, _ := ret[0].(isql.DB)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Open indicates an expected call of Open
+// Open indicates an expected call of Open.
 func (mr *MockOpenerMockRecorder) Open(driverName, dataSourceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockOpener)(nil).Open), driverName, dataSourceName)
 }
 
-// MockDB is a mock of DB interface
+// OpenConfig mocks base method.
+func (m *MockOpener) OpenConfig(cfg isql.Config) (isql.DB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenConfig", cfg)
+	ret0, _ := ret[0].(isql.DB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenConfig indicates an expected call of OpenConfig.
+func (mr *MockOpenerMockRecorder) OpenConfig(cfg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenConfig", reflect.TypeOf((*MockOpener)(nil).OpenConfig), cfg)
+}
+
+// OpenConnector mocks base method.
+func (m *MockOpener) OpenConnector(connector driver.Connector) (isql.DB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenConnector", connector)
+	ret0, _ := ret[0].(isql.DB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenConnector indicates an expected call of OpenConnector.
+func (mr *MockOpenerMockRecorder) OpenConnector(connector interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenConnector", reflect.TypeOf((*MockOpener)(nil).OpenConnector), connector)
+}
+
+// MockDB is a mock of DB interface.
 type MockDB struct {
 	ctrl     *gomock.Controller
 	recorder *MockDBMockRecorder
 }
 
-// MockDBMockRecorder is the mock recorder for MockDB
+// MockDBMockRecorder is the mock recorder for MockDB.
 type MockDBMockRecorder struct {
 	mock *MockDB
 }
 
-// NewMockDB creates a new mock instance
+// NewMockDB creates a new mock instance.
 func NewMockDB(ctrl *gomock.Controller) *MockDB {
 	mock := &MockDB{ctrl: ctrl}
 	mock.recorder = &MockDBMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockDB) EXPECT() *MockDBMockRecorder {
 	return m.recorder
 }
 
-// ExecContext mocks base method
-func (m *MockDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	varargs := []interface{}{ctx, query}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "ExecContext", varargs...)
-	ret0, _ := ret[0].(sql.Result)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// ExecContext indicates an expected call of ExecContext
-func (mr *MockDBMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
-	varargs := append([]interface{}{ctx, query}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockDB)(nil).ExecContext), varargs...)
-}
-
-// QueryContext mocks base method
-func (m *MockDB) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
-	varargs := []interface{}{ctx, query}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "QueryContext", varargs...)
-	ret0, _ := ret[0].(isql.Rows)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// QueryContext indicates an expected call of QueryContext
-func (mr *MockDBMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
-	varargs := append([]interface{}{ctx, query}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockDB)(nil).QueryContext), varargs...)
-}
-
-// QueryRowContext mocks base method
-func (m *MockDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
-	varargs := []interface{}{ctx, query}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
-	ret0, _ := ret[0].(isql.Row)
-	return ret0
-}
-
-// QueryRowContext indicates an expected call of QueryRowContext
-func (mr *MockDBMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
-	varargs := append([]interface{}{ctx, query}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockDB)(nil).QueryRowContext), varargs...)
-}
-
-// Begin mocks base method
+// Begin mocks base method.
 func (m *MockDB) Begin() (isql.Tx, error) {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Begin")
 	ret0, _ := ret[0].(isql.Tx)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Begin indicates an expected call of Begin
+// Begin indicates an expected call of Begin.
 func (mr *MockDBMockRecorder) Begin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockDB)(nil).Begin))
 }
 
-// BeginTx mocks base method
+// BeginTx mocks base method.
 func (m *MockDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (isql.Tx, error) {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "BeginTx", ctx, opts)
 	ret0, _ := ret[0].(isql.Tx)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// BeginTx indicates an expected call of BeginTx
+// BeginTx indicates an expected call of BeginTx.
 func (mr *MockDBMockRecorder) BeginTx(ctx, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTx", reflect.TypeOf((*MockDB)(nil).BeginTx), ctx, opts)
 }
 
-// Close mocks base method
+// Close mocks base method.
 func (m *MockDB) Close() error {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Close")
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Close indicates an expected call of Close
+// Close indicates an expected call of Close.
 func (mr *MockDBMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockDB)(nil).Close))
 }
 
-// Driver mocks base method
+// Conn mocks base method.
+func (m *MockDB) Conn(ctx context.Context) (isql.PinnedConn, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Conn", ctx)
+	ret0, _ := ret[0].(isql.PinnedConn)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Conn indicates an expected call of Conn.
+func (mr *MockDBMockRecorder) Conn(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Conn", reflect.TypeOf((*MockDB)(nil).Conn), ctx)
+}
+
+// Driver mocks base method.
 func (m *MockDB) Driver() driver.Driver {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Driver")
 	ret0, _ := ret[0].(driver.Driver)
 	return ret0
 }
 
-// Driver indicates an expected call of Driver
+// Driver indicates an expected call of Driver.
 func (mr *MockDBMockRecorder) Driver() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Driver", reflect.TypeOf((*MockDB)(nil).Driver))
 }
 
-// Exec mocks base method
+// Exec mocks base method.
 func (m *MockDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{query}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -188,64 +192,94 @@ func (m *MockDB) Exec(query string, args ...interface{}) (sql.Result, error) {
 	return ret0, ret1
 }
 
-// Exec indicates an expected call of Exec
+// Exec indicates an expected call of Exec.
 func (mr *MockDBMockRecorder) Exec(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{query}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockDB)(nil).Exec), varargs...)
 }
 
-// Ping mocks base method
+// ExecContext mocks base method.
+func (m *MockDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext.
+func (mr *MockDBMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockDB)(nil).ExecContext), varargs...)
+}
+
+// Ping mocks base method.
 func (m *MockDB) Ping() error {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Ping")
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Ping indicates an expected call of Ping
+// Ping indicates an expected call of Ping.
 func (mr *MockDBMockRecorder) Ping() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockDB)(nil).Ping))
 }
 
-// PingContext mocks base method
+// PingContext mocks base method.
 func (m *MockDB) PingContext(ctx context.Context) error {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "PingContext", ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// PingContext indicates an expected call of PingContext
+// PingContext indicates an expected call of PingContext.
 func (mr *MockDBMockRecorder) PingContext(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PingContext", reflect.TypeOf((*MockDB)(nil).PingContext), ctx)
 }
 
-// Prepare mocks base method
+// Prepare mocks base method.
 func (m *MockDB) Prepare(query string) (isql.Stmt, error) {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Prepare", query)
 	ret0, _ := ret[0].(isql.Stmt)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Prepare indicates an expected call of Prepare
+// Prepare indicates an expected call of Prepare.
 func (mr *MockDBMockRecorder) Prepare(query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prepare", reflect.TypeOf((*MockDB)(nil).Prepare), query)
 }
 
-// PrepareContext mocks base method
+// PrepareContext mocks base method.
 func (m *MockDB) PrepareContext(ctx context.Context, query string) (isql.Stmt, error) {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "PrepareContext", ctx, query)
 	ret0, _ := ret[0].(isql.Stmt)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// PrepareContext indicates an expected call of PrepareContext
+// PrepareContext indicates an expected call of PrepareContext.
 func (mr *MockDBMockRecorder) PrepareContext(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareContext", reflect.TypeOf((*MockDB)(nil).PrepareContext), ctx, query)
 }
 
-// Query mocks base method
+// Query mocks base method.
 func (m *MockDB) Query(query string, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{query}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -256,14 +290,36 @@ func (m *MockDB) Query(query string, args ...interface{}) (isql.Rows, error) {
 	return ret0, ret1
 }
 
-// Query indicates an expected call of Query
+// Query indicates an expected call of Query.
 func (mr *MockDBMockRecorder) Query(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{query}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockDB)(nil).Query), varargs...)
 }
 
-// QueryRow mocks base method
+// QueryContext mocks base method.
+func (m *MockDB) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(isql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext.
+func (mr *MockDBMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockDB)(nil).QueryContext), varargs...)
+}
+
+// QueryRow mocks base method.
 func (m *MockDB) QueryRow(query string, args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{query}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -273,79 +329,134 @@ func (m *MockDB) QueryRow(query string, args ...interface{}) isql.Row {
 	return ret0
 }
 
-// QueryRow indicates an expected call of QueryRow
+// QueryRow indicates an expected call of QueryRow.
 func (mr *MockDBMockRecorder) QueryRow(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{query}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRow", reflect.TypeOf((*MockDB)(nil).QueryRow), varargs...)
 }
 
-// SetConnMaxLifetime mocks base method
+// QueryRowContext mocks base method.
+func (m *MockDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
+	ret0, _ := ret[0].(isql.Row)
+	return ret0
+}
+
+// QueryRowContext indicates an expected call of QueryRowContext.
+func (mr *MockDBMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockDB)(nil).QueryRowContext), varargs...)
+}
+
+// SetConnMaxIdleTime mocks base method.
+func (m *MockDB) SetConnMaxIdleTime(d time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetConnMaxIdleTime", d)
+}
+
+// SetConnMaxIdleTime indicates an expected call of SetConnMaxIdleTime.
+func (mr *MockDBMockRecorder) SetConnMaxIdleTime(d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConnMaxIdleTime", reflect.TypeOf((*MockDB)(nil).SetConnMaxIdleTime), d)
+}
+
+// SetConnMaxLifetime mocks base method.
 func (m *MockDB) SetConnMaxLifetime(d time.Duration) {
+	m.ctrl.T.Helper()
 	m.ctrl.Call(m, "SetConnMaxLifetime", d)
 }
 
-// SetConnMaxLifetime indicates an expected call of SetConnMaxLifetime
+// SetConnMaxLifetime indicates an expected call of SetConnMaxLifetime.
 func (mr *MockDBMockRecorder) SetConnMaxLifetime(d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConnMaxLifetime", reflect.TypeOf((*MockDB)(nil).SetConnMaxLifetime), d)
 }
 
-// SetMaxIdleConns mocks base method
+// SetMaxIdleConns mocks base method.
 func (m *MockDB) SetMaxIdleConns(n int) {
+	m.ctrl.T.Helper()
 	m.ctrl.Call(m, "SetMaxIdleConns", n)
 }
 
-// SetMaxIdleConns indicates an expected call of SetMaxIdleConns
+// SetMaxIdleConns indicates an expected call of SetMaxIdleConns.
 func (mr *MockDBMockRecorder) SetMaxIdleConns(n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxIdleConns", reflect.TypeOf((*MockDB)(nil).SetMaxIdleConns), n)
 }
 
-// SetMaxOpenConns mocks base method
+// SetMaxOpenConns mocks base method.
 func (m *MockDB) SetMaxOpenConns(n int) {
+	m.ctrl.T.Helper()
 	m.ctrl.Call(m, "SetMaxOpenConns", n)
 }
 
-// SetMaxOpenConns indicates an expected call of SetMaxOpenConns
+// SetMaxOpenConns indicates an expected call of SetMaxOpenConns.
 func (mr *MockDBMockRecorder) SetMaxOpenConns(n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxOpenConns", reflect.TypeOf((*MockDB)(nil).SetMaxOpenConns), n)
 }
 
-// Stats mocks base method
+// Stats mocks base method.
 func (m *MockDB) Stats() sql.DBStats {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Stats")
 	ret0, _ := ret[0].(sql.DBStats)
 	return ret0
 }
 
-// Stats indicates an expected call of Stats
+// Stats indicates an expected call of Stats.
 func (mr *MockDBMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockDB)(nil).Stats))
 }
 
-// MockDBCore is a mock of DBCore interface
+// StmtCacheStats mocks base method.
+func (m *MockDB) StmtCacheStats() isql.StmtCacheStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StmtCacheStats")
+	ret0, _ := ret[0].(isql.StmtCacheStats)
+	return ret0
+}
+
+// StmtCacheStats indicates an expected call of StmtCacheStats.
+func (mr *MockDBMockRecorder) StmtCacheStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StmtCacheStats", reflect.TypeOf((*MockDB)(nil).StmtCacheStats))
+}
+
+// MockDBCore is a mock of DBCore interface.
 type MockDBCore struct {
 	ctrl     *gomock.Controller
 	recorder *MockDBCoreMockRecorder
 }
 
-// MockDBCoreMockRecorder is the mock recorder for MockDBCore
+// MockDBCoreMockRecorder is the mock recorder for MockDBCore.
 type MockDBCoreMockRecorder struct {
 	mock *MockDBCore
 }
 
-// NewMockDBCore creates a new mock instance
+// NewMockDBCore creates a new mock instance.
 func NewMockDBCore(ctrl *gomock.Controller) *MockDBCore {
 	mock := &MockDBCore{ctrl: ctrl}
 	mock.recorder = &MockDBCoreMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockDBCore) EXPECT() *MockDBCoreMockRecorder {
 	return m.recorder
 }
 
-// ExecContext mocks base method
+// ExecContext mocks base method.
 func (m *MockDBCore) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{ctx, query}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -356,14 +467,16 @@ func (m *MockDBCore) ExecContext(ctx context.Context, query string, args ...inte
 	return ret0, ret1
 }
 
-// ExecContext indicates an expected call of ExecContext
+// ExecContext indicates an expected call of ExecContext.
 func (mr *MockDBCoreMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{ctx, query}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockDBCore)(nil).ExecContext), varargs...)
 }
 
-// QueryContext mocks base method
+// QueryContext mocks base method.
 func (m *MockDBCore) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{ctx, query}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -374,14 +487,16 @@ func (m *MockDBCore) QueryContext(ctx context.Context, query string, args ...int
 	return ret0, ret1
 }
 
-// QueryContext indicates an expected call of QueryContext
+// QueryContext indicates an expected call of QueryContext.
 func (mr *MockDBCoreMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{ctx, query}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockDBCore)(nil).QueryContext), varargs...)
 }
 
-// QueryRowContext mocks base method
+// QueryRowContext mocks base method.
 func (m *MockDBCore) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{ctx, query}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -391,37 +506,39 @@ func (m *MockDBCore) QueryRowContext(ctx context.Context, query string, args ...
 	return ret0
 }
 
-// QueryRowContext indicates an expected call of QueryRowContext
+// QueryRowContext indicates an expected call of QueryRowContext.
 func (mr *MockDBCoreMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{ctx, query}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockDBCore)(nil).QueryRowContext), varargs...)
 }
 
-// MockReplicaSet is a mock of ReplicaSet interface
+// MockReplicaSet is a mock of ReplicaSet interface.
 type MockReplicaSet struct {
 	ctrl     *gomock.Controller
 	recorder *MockReplicaSetMockRecorder
 }
 
-// MockReplicaSetMockRecorder is the mock recorder for MockReplicaSet
+// MockReplicaSetMockRecorder is the mock recorder for MockReplicaSet.
 type MockReplicaSetMockRecorder struct {
 	mock *MockReplicaSet
 }
 
-// NewMockReplicaSet creates a new mock instance
+// NewMockReplicaSet creates a new mock instance.
 func NewMockReplicaSet(ctrl *gomock.Controller) *MockReplicaSet {
 	mock := &MockReplicaSet{ctrl: ctrl}
 	mock.recorder = &MockReplicaSetMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockReplicaSet) EXPECT() *MockReplicaSetMockRecorder {
 	return m.recorder
 }
 
-// ExecContext mocks base method
+// ExecContext mocks base method.
 func (m *MockReplicaSet) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{ctx, query}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -432,14 +549,30 @@ func (m *MockReplicaSet) ExecContext(ctx context.Context, query string, args ...
 	return ret0, ret1
 }
 
-// ExecContext indicates an expected call of ExecContext
+// ExecContext indicates an expected call of ExecContext.
 func (mr *MockReplicaSetMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{ctx, query}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockReplicaSet)(nil).ExecContext), varargs...)
 }
 
-// QueryContext mocks base method
+// Primary mocks base method.
+func (m *MockReplicaSet) Primary() isql.DBCore {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Primary")
+	ret0, _ := ret[0].(isql.DBCore)
+	return ret0
+}
+
+// Primary indicates an expected call of Primary.
+func (mr *MockReplicaSetMockRecorder) Primary() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Primary", reflect.TypeOf((*MockReplicaSet)(nil).Primary))
+}
+
+// QueryContext mocks base method.
 func (m *MockReplicaSet) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{ctx, query}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -450,14 +583,16 @@ func (m *MockReplicaSet) QueryContext(ctx context.Context, query string, args ..
 	return ret0, ret1
 }
 
-// QueryContext indicates an expected call of QueryContext
+// QueryContext indicates an expected call of QueryContext.
 func (mr *MockReplicaSetMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{ctx, query}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockReplicaSet)(nil).QueryContext), varargs...)
 }
 
-// QueryRowContext mocks base method
+// QueryRowContext mocks base method.
 func (m *MockReplicaSet) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{ctx, query}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -467,61 +602,53 @@ func (m *MockReplicaSet) QueryRowContext(ctx context.Context, query string, args
 	return ret0
 }
 
-// QueryRowContext indicates an expected call of QueryRowContext
+// QueryRowContext indicates an expected call of QueryRowContext.
 func (mr *MockReplicaSetMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{ctx, query}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockReplicaSet)(nil).QueryRowContext), varargs...)
 }
 
-// Primary mocks base method
-func (m *MockReplicaSet) Primary() isql.DBCore {
-	ret := m.ctrl.Call(m, "Primary")
-	ret0, _ := ret[0].(isql.DBCore)
-	return ret0
-}
-
-// Primary indicates an expected call of Primary
-func (mr *MockReplicaSetMockRecorder) Primary() *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Primary", reflect.TypeOf((*MockReplicaSet)(nil).Primary))
-}
-
-// Slaves mocks base method
+// Slaves mocks base method.
 func (m *MockReplicaSet) Slaves() []isql.DBCore {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Slaves")
 	ret0, _ := ret[0].([]isql.DBCore)
 	return ret0
 }
 
-// Slaves indicates an expected call of Slaves
+// Slaves indicates an expected call of Slaves.
 func (mr *MockReplicaSetMockRecorder) Slaves() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Slaves", reflect.TypeOf((*MockReplicaSet)(nil).Slaves))
 }
 
-// MockRow is a mock of Row interface
+// MockRow is a mock of Row interface.
 type MockRow struct {
 	ctrl     *gomock.Controller
 	recorder *MockRowMockRecorder
 }
 
-// MockRowMockRecorder is the mock recorder for MockRow
+// MockRowMockRecorder is the mock recorder for MockRow.
 type MockRowMockRecorder struct {
 	mock *MockRow
 }
 
-// NewMockRow creates a new mock instance
+// NewMockRow creates a new mock instance.
 func NewMockRow(ctrl *gomock.Controller) *MockRow {
 	mock := &MockRow{ctrl: ctrl}
 	mock.recorder = &MockRowMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockRow) EXPECT() *MockRowMockRecorder {
 	return m.recorder
 }
 
-// Scan mocks base method
+// Scan mocks base method.
 func (m *MockRow) Scan(dest ...interface{}) error {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{}
 	for _, a := range dest {
 		varargs = append(varargs, a)
@@ -531,110 +658,124 @@ func (m *MockRow) Scan(dest ...interface{}) error {
 	return ret0
 }
 
-// Scan indicates an expected call of Scan
+// Scan indicates an expected call of Scan.
 func (mr *MockRowMockRecorder) Scan(dest ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockRow)(nil).Scan), dest...)
 }
 
-// MockRows is a mock of Rows interface
+// MockRows is a mock of Rows interface.
 type MockRows struct {
 	ctrl     *gomock.Controller
 	recorder *MockRowsMockRecorder
 }
 
-// MockRowsMockRecorder is the mock recorder for MockRows
+// MockRowsMockRecorder is the mock recorder for MockRows.
 type MockRowsMockRecorder struct {
 	mock *MockRows
 }
 
-// NewMockRows creates a new mock instance
+// NewMockRows creates a new mock instance.
 func NewMockRows(ctrl *gomock.Controller) *MockRows {
 	mock := &MockRows{ctrl: ctrl}
 	mock.recorder = &MockRowsMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockRows) EXPECT() *MockRowsMockRecorder {
 	return m.recorder
 }
 
-// Close mocks base method
+// Close mocks base method.
 func (m *MockRows) Close() error {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Close")
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Close indicates an expected call of Close
+// Close indicates an expected call of Close.
 func (mr *MockRowsMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockRows)(nil).Close))
 }
 
-// ColumnTypes mocks base method
+// ColumnTypes mocks base method.
 func (m *MockRows) ColumnTypes() ([]isql.ColumnType, error) {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ColumnTypes")
 	ret0, _ := ret[0].([]isql.ColumnType)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ColumnTypes indicates an expected call of ColumnTypes
+// ColumnTypes indicates an expected call of ColumnTypes.
 func (mr *MockRowsMockRecorder) ColumnTypes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ColumnTypes", reflect.TypeOf((*MockRows)(nil).ColumnTypes))
 }
 
-// Columns mocks base method
+// Columns mocks base method.
 func (m *MockRows) Columns() ([]string, error) {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Columns")
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Columns indicates an expected call of Columns
+// Columns indicates an expected call of Columns.
 func (mr *MockRowsMockRecorder) Columns() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Columns", reflect.TypeOf((*MockRows)(nil).Columns))
 }
 
-// Err mocks base method
+// Err mocks base method.
 func (m *MockRows) Err() error {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Err")
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Err indicates an expected call of Err
+// Err indicates an expected call of Err.
 func (mr *MockRowsMockRecorder) Err() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Err", reflect.TypeOf((*MockRows)(nil).Err))
 }
 
-// Next mocks base method
+// Next mocks base method.
 func (m *MockRows) Next() bool {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Next")
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// Next indicates an expected call of Next
+// Next indicates an expected call of Next.
 func (mr *MockRowsMockRecorder) Next() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Next", reflect.TypeOf((*MockRows)(nil).Next))
 }
 
-// NextResultSet mocks base method
+// NextResultSet mocks base method.
 func (m *MockRows) NextResultSet() bool {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "NextResultSet")
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// NextResultSet indicates an expected call of NextResultSet
+// NextResultSet indicates an expected call of NextResultSet.
 func (mr *MockRowsMockRecorder) NextResultSet() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextResultSet", reflect.TypeOf((*MockRows)(nil).NextResultSet))
 }
 
-// Scan mocks base method
+// Scan mocks base method.
 func (m *MockRows) Scan(dest ...interface{}) error {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{}
 	for _, a := range dest {
 		varargs = append(varargs, a)
@@ -644,48 +785,52 @@ func (m *MockRows) Scan(dest ...interface{}) error {
 	return ret0
 }
 
-// Scan indicates an expected call of Scan
+// Scan indicates an expected call of Scan.
 func (mr *MockRowsMockRecorder) Scan(dest ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockRows)(nil).Scan), dest...)
 }
 
-// MockStmt is a mock of Stmt interface
+// MockStmt is a mock of Stmt interface.
 type MockStmt struct {
 	ctrl     *gomock.Controller
 	recorder *MockStmtMockRecorder
 }
 
-// MockStmtMockRecorder is the mock recorder for MockStmt
+// MockStmtMockRecorder is the mock recorder for MockStmt.
 type MockStmtMockRecorder struct {
 	mock *MockStmt
 }
 
-// NewMockStmt creates a new mock instance
+// NewMockStmt creates a new mock instance.
 func NewMockStmt(ctrl *gomock.Controller) *MockStmt {
 	mock := &MockStmt{ctrl: ctrl}
 	mock.recorder = &MockStmtMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockStmt) EXPECT() *MockStmtMockRecorder {
 	return m.recorder
 }
 
-// Close mocks base method
+// Close mocks base method.
 func (m *MockStmt) Close() error {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Close")
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Close indicates an expected call of Close
+// Close indicates an expected call of Close.
 func (mr *MockStmtMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStmt)(nil).Close))
 }
 
-// Exec mocks base method
+// Exec mocks base method.
 func (m *MockStmt) Exec(args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -696,13 +841,15 @@ func (m *MockStmt) Exec(args ...interface{}) (sql.Result, error) {
 	return ret0, ret1
 }
 
-// Exec indicates an expected call of Exec
+// Exec indicates an expected call of Exec.
 func (mr *MockStmtMockRecorder) Exec(args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockStmt)(nil).Exec), args...)
 }
 
-// ExecContext mocks base method
+// ExecContext mocks base method.
 func (m *MockStmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{ctx}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -713,14 +860,16 @@ func (m *MockStmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Re
 	return ret0, ret1
 }
 
-// ExecContext indicates an expected call of ExecContext
+// ExecContext indicates an expected call of ExecContext.
 func (mr *MockStmtMockRecorder) ExecContext(ctx interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{ctx}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockStmt)(nil).ExecContext), varargs...)
 }
 
-// Query mocks base method
+// Query mocks base method.
 func (m *MockStmt) Query(args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -731,13 +880,15 @@ func (m *MockStmt) Query(args ...interface{}) (isql.Rows, error) {
 	return ret0, ret1
 }
 
-// Query indicates an expected call of Query
+// Query indicates an expected call of Query.
 func (mr *MockStmtMockRecorder) Query(args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockStmt)(nil).Query), args...)
 }
 
-// QueryContext mocks base method
+// QueryContext mocks base method.
 func (m *MockStmt) QueryContext(ctx context.Context, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{ctx}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -748,14 +899,16 @@ func (m *MockStmt) QueryContext(ctx context.Context, args ...interface{}) (isql.
 	return ret0, ret1
 }
 
-// QueryContext indicates an expected call of QueryContext
+// QueryContext indicates an expected call of QueryContext.
 func (mr *MockStmtMockRecorder) QueryContext(ctx interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{ctx}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockStmt)(nil).QueryContext), varargs...)
 }
 
-// QueryRow mocks base method
+// QueryRow mocks base method.
 func (m *MockStmt) QueryRow(args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -765,13 +918,15 @@ func (m *MockStmt) QueryRow(args ...interface{}) isql.Row {
 	return ret0
 }
 
-// QueryRow indicates an expected call of QueryRow
+// QueryRow indicates an expected call of QueryRow.
 func (mr *MockStmtMockRecorder) QueryRow(args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRow", reflect.TypeOf((*MockStmt)(nil).QueryRow), args...)
 }
 
-// QueryRowContext mocks base method
+// QueryRowContext mocks base method.
 func (m *MockStmt) QueryRowContext(ctx context.Context, args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{ctx}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -781,102 +936,53 @@ func (m *MockStmt) QueryRowContext(ctx context.Context, args ...interface{}) isq
 	return ret0
 }
 
-// QueryRowContext indicates an expected call of QueryRowContext
+// QueryRowContext indicates an expected call of QueryRowContext.
 func (mr *MockStmtMockRecorder) QueryRowContext(ctx interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{ctx}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockStmt)(nil).QueryRowContext), varargs...)
 }
 
-// MockTx is a mock of Tx interface
+// MockTx is a mock of Tx interface.
 type MockTx struct {
 	ctrl     *gomock.Controller
 	recorder *MockTxMockRecorder
 }
 
-// MockTxMockRecorder is the mock recorder for MockTx
+// MockTxMockRecorder is the mock recorder for MockTx.
 type MockTxMockRecorder struct {
 	mock *MockTx
 }
 
-// NewMockTx creates a new mock instance
+// NewMockTx creates a new mock instance.
 func NewMockTx(ctrl *gomock.Controller) *MockTx {
 	mock := &MockTx{ctrl: ctrl}
 	mock.recorder = &MockTxMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockTx) EXPECT() *MockTxMockRecorder {
 	return m.recorder
 }
 
-// ExecContext mocks base method
-func (m *MockTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	varargs := []interface{}{ctx, query}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "ExecContext", varargs...)
-	ret0, _ := ret[0].(sql.Result)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// ExecContext indicates an expected call of ExecContext
-func (mr *MockTxMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
-	varargs := append([]interface{}{ctx, query}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockTx)(nil).ExecContext), varargs...)
-}
-
-// QueryContext mocks base method
-func (m *MockTx) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
-	varargs := []interface{}{ctx, query}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "QueryContext", varargs...)
-	ret0, _ := ret[0].(isql.Rows)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// QueryContext indicates an expected call of QueryContext
-func (mr *MockTxMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
-	varargs := append([]interface{}{ctx, query}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockTx)(nil).QueryContext), varargs...)
-}
-
-// QueryRowContext mocks base method
-func (m *MockTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
-	varargs := []interface{}{ctx, query}
-	for _, a := range args {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
-	ret0, _ := ret[0].(isql.Row)
-	return ret0
-}
-
-// QueryRowContext indicates an expected call of QueryRowContext
-func (mr *MockTxMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
-	varargs := append([]interface{}{ctx, query}, args...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockTx)(nil).QueryRowContext), varargs...)
-}
-
-// Commit mocks base method
+// Commit mocks base method.
 func (m *MockTx) Commit() error {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Commit")
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Commit indicates an expected call of Commit
+// Commit indicates an expected call of Commit.
 func (mr *MockTxMockRecorder) Commit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockTx)(nil).Commit))
 }
 
-// Exec mocks base method
+// Exec mocks base method.
 func (m *MockTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{query}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -887,40 +993,66 @@ func (m *MockTx) Exec(query string, args ...interface{}) (sql.Result, error) {
 	return ret0, ret1
 }
 
-// Exec indicates an expected call of Exec
+// Exec indicates an expected call of Exec.
 func (mr *MockTxMockRecorder) Exec(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{query}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockTx)(nil).Exec), varargs...)
 }
 
-// Prepare mocks base method
+// ExecContext mocks base method.
+func (m *MockTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext.
+func (mr *MockTxMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockTx)(nil).ExecContext), varargs...)
+}
+
+// Prepare mocks base method.
 func (m *MockTx) Prepare(query string) (isql.Stmt, error) {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Prepare", query)
 	ret0, _ := ret[0].(isql.Stmt)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Prepare indicates an expected call of Prepare
+// Prepare indicates an expected call of Prepare.
 func (mr *MockTxMockRecorder) Prepare(query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prepare", reflect.TypeOf((*MockTx)(nil).Prepare), query)
 }
 
-// PrepareContext mocks base method
+// PrepareContext mocks base method.
 func (m *MockTx) PrepareContext(ctx context.Context, query string) (isql.Stmt, error) {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "PrepareContext", ctx, query)
 	ret0, _ := ret[0].(isql.Stmt)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// PrepareContext indicates an expected call of PrepareContext
+// PrepareContext indicates an expected call of PrepareContext.
 func (mr *MockTxMockRecorder) PrepareContext(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareContext", reflect.TypeOf((*MockTx)(nil).PrepareContext), ctx, query)
 }
 
-// Query mocks base method
+// Query mocks base method.
 func (m *MockTx) Query(query string, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{query}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -931,14 +1063,36 @@ func (m *MockTx) Query(query string, args ...interface{}) (isql.Rows, error) {
 	return ret0, ret1
 }
 
-// Query indicates an expected call of Query
+// Query indicates an expected call of Query.
 func (mr *MockTxMockRecorder) Query(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{query}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockTx)(nil).Query), varargs...)
 }
 
-// QueryRow mocks base method
+// QueryContext mocks base method.
+func (m *MockTx) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(isql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext.
+func (mr *MockTxMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockTx)(nil).QueryContext), varargs...)
+}
+
+// QueryRow mocks base method.
 func (m *MockTx) QueryRow(query string, args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{query}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -948,85 +1102,254 @@ func (m *MockTx) QueryRow(query string, args ...interface{}) isql.Row {
 	return ret0
 }
 
-// QueryRow indicates an expected call of QueryRow
+// QueryRow indicates an expected call of QueryRow.
 func (mr *MockTxMockRecorder) QueryRow(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{query}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRow", reflect.TypeOf((*MockTx)(nil).QueryRow), varargs...)
 }
 
-// Rollback mocks base method
+// QueryRowContext mocks base method.
+func (m *MockTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
+	ret0, _ := ret[0].(isql.Row)
+	return ret0
+}
+
+// QueryRowContext indicates an expected call of QueryRowContext.
+func (mr *MockTxMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockTx)(nil).QueryRowContext), varargs...)
+}
+
+// Rollback mocks base method.
 func (m *MockTx) Rollback() error {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Rollback")
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Rollback indicates an expected call of Rollback
+// Rollback indicates an expected call of Rollback.
 func (mr *MockTxMockRecorder) Rollback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockTx)(nil).Rollback))
 }
 
-// Stmt mocks base method
+// Stmt mocks base method.
 func (m *MockTx) Stmt(stmt *sql.Stmt) isql.Stmt {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Stmt", stmt)
 	ret0, _ := ret[0].(isql.Stmt)
 	return ret0
 }
 
-// Stmt indicates an expected call of Stmt
+// Stmt indicates an expected call of Stmt.
 func (mr *MockTxMockRecorder) Stmt(stmt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stmt", reflect.TypeOf((*MockTx)(nil).Stmt), stmt)
 }
 
-// StmtContext mocks base method
+// StmtContext mocks base method.
 func (m *MockTx) StmtContext(ctx context.Context, stmt *sql.Stmt) isql.Stmt {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "StmtContext", ctx, stmt)
 	ret0, _ := ret[0].(isql.Stmt)
 	return ret0
 }
 
-// StmtContext indicates an expected call of StmtContext
+// StmtContext indicates an expected call of StmtContext.
 func (mr *MockTxMockRecorder) StmtContext(ctx, stmt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StmtContext", reflect.TypeOf((*MockTx)(nil).StmtContext), ctx, stmt)
 }
 
-// MockColumnType is a mock of ColumnType interface
+// MockPinnedConn is a mock of PinnedConn interface.
+type MockPinnedConn struct {
+	ctrl     *gomock.Controller
+	recorder *MockPinnedConnMockRecorder
+}
+
+// MockPinnedConnMockRecorder is the mock recorder for MockPinnedConn.
+type MockPinnedConnMockRecorder struct {
+	mock *MockPinnedConn
+}
+
+// NewMockPinnedConn creates a new mock instance.
+func NewMockPinnedConn(ctrl *gomock.Controller) *MockPinnedConn {
+	mock := &MockPinnedConn{ctrl: ctrl}
+	mock.recorder = &MockPinnedConnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPinnedConn) EXPECT() *MockPinnedConnMockRecorder {
+	return m.recorder
+}
+
+// BeginTx mocks base method.
+func (m *MockPinnedConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (isql.Tx, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginTx", ctx, opts)
+	ret0, _ := ret[0].(isql.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginTx indicates an expected call of BeginTx.
+func (mr *MockPinnedConnMockRecorder) BeginTx(ctx, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTx", reflect.TypeOf((*MockPinnedConn)(nil).BeginTx), ctx, opts)
+}
+
+// Close mocks base method.
+func (m *MockPinnedConn) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockPinnedConnMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockPinnedConn)(nil).Close))
+}
+
+// ExecContext mocks base method.
+func (m *MockPinnedConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext.
+func (mr *MockPinnedConnMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockPinnedConn)(nil).ExecContext), varargs...)
+}
+
+// PingContext mocks base method.
+func (m *MockPinnedConn) PingContext(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PingContext", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PingContext indicates an expected call of PingContext.
+func (mr *MockPinnedConnMockRecorder) PingContext(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PingContext", reflect.TypeOf((*MockPinnedConn)(nil).PingContext), ctx)
+}
+
+// PrepareContext mocks base method.
+func (m *MockPinnedConn) PrepareContext(ctx context.Context, query string) (isql.Stmt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrepareContext", ctx, query)
+	ret0, _ := ret[0].(isql.Stmt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PrepareContext indicates an expected call of PrepareContext.
+func (mr *MockPinnedConnMockRecorder) PrepareContext(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareContext", reflect.TypeOf((*MockPinnedConn)(nil).PrepareContext), ctx, query)
+}
+
+// QueryContext mocks base method.
+func (m *MockPinnedConn) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(isql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext.
+func (mr *MockPinnedConnMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockPinnedConn)(nil).QueryContext), varargs...)
+}
+
+// QueryRowContext mocks base method.
+func (m *MockPinnedConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
+	ret0, _ := ret[0].(isql.Row)
+	return ret0
+}
+
+// QueryRowContext indicates an expected call of QueryRowContext.
+func (mr *MockPinnedConnMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockPinnedConn)(nil).QueryRowContext), varargs...)
+}
+
+// MockColumnType is a mock of ColumnType interface.
 type MockColumnType struct {
 	ctrl     *gomock.Controller
 	recorder *MockColumnTypeMockRecorder
 }
 
-// MockColumnTypeMockRecorder is the mock recorder for MockColumnType
+// MockColumnTypeMockRecorder is the mock recorder for MockColumnType.
 type MockColumnTypeMockRecorder struct {
 	mock *MockColumnType
 }
 
-// NewMockColumnType creates a new mock instance
+// NewMockColumnType creates a new mock instance.
 func NewMockColumnType(ctrl *gomock.Controller) *MockColumnType {
 	mock := &MockColumnType{ctrl: ctrl}
 	mock.recorder = &MockColumnTypeMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockColumnType) EXPECT() *MockColumnTypeMockRecorder {
 	return m.recorder
 }
 
-// DatabaseTypeName mocks base method
+// DatabaseTypeName mocks base method.
 func (m *MockColumnType) DatabaseTypeName() string {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DatabaseTypeName")
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// DatabaseTypeName indicates an expected call of DatabaseTypeName
+// DatabaseTypeName indicates an expected call of DatabaseTypeName.
 func (mr *MockColumnTypeMockRecorder) DatabaseTypeName() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DatabaseTypeName", reflect.TypeOf((*MockColumnType)(nil).DatabaseTypeName))
 }
 
-// DecimalSize mocks base method
+// DecimalSize mocks base method.
 func (m *MockColumnType) DecimalSize() (int64, int64, bool) {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DecimalSize")
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(int64)
@@ -1034,57 +1357,66 @@ func (m *MockColumnType) DecimalSize() (int64, int64, bool) {
 	return ret0, ret1, ret2
 }
 
-// DecimalSize indicates an expected call of DecimalSize
+// DecimalSize indicates an expected call of DecimalSize.
 func (mr *MockColumnTypeMockRecorder) DecimalSize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecimalSize", reflect.TypeOf((*MockColumnType)(nil).DecimalSize))
 }
 
-// Length mocks base method
+// Length mocks base method.
 func (m *MockColumnType) Length() (int64, bool) {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Length")
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(bool)
 	return ret0, ret1
 }
 
-// Length indicates an expected call of Length
+// Length indicates an expected call of Length.
 func (mr *MockColumnTypeMockRecorder) Length() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Length", reflect.TypeOf((*MockColumnType)(nil).Length))
 }
 
-// Name mocks base method
+// Name mocks base method.
 func (m *MockColumnType) Name() string {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Name")
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// Name indicates an expected call of Name
+// Name indicates an expected call of Name.
 func (mr *MockColumnTypeMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockColumnType)(nil).Name))
 }
 
-// Nullable mocks base method
+// Nullable mocks base method.
 func (m *MockColumnType) Nullable() (bool, bool) {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Nullable")
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(bool)
 	return ret0, ret1
 }
 
-// Nullable indicates an expected call of Nullable
+// Nullable indicates an expected call of Nullable.
 func (mr *MockColumnTypeMockRecorder) Nullable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Nullable", reflect.TypeOf((*MockColumnType)(nil).Nullable))
 }
 
-// ScanType mocks base method
+// ScanType mocks base method.
 func (m *MockColumnType) ScanType() reflect.Type {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ScanType")
 	ret0, _ := ret[0].(reflect.Type)
 	return ret0
 }
 
-// ScanType indicates an expected call of ScanType
+// ScanType indicates an expected call of ScanType.
 func (mr *MockColumnTypeMockRecorder) ScanType() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanType", reflect.TypeOf((*MockColumnType)(nil).ScanType))
 }
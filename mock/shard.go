@@ -0,0 +1,183 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: shard.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	sql "database/sql"
+	reflect "reflect"
+
+	isql "github.com/0xor1/isql"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockShardStrategy is a mock of ShardStrategy interface.
+type MockShardStrategy struct {
+	ctrl     *gomock.Controller
+	recorder *MockShardStrategyMockRecorder
+}
+
+// MockShardStrategyMockRecorder is the mock recorder for MockShardStrategy.
+type MockShardStrategyMockRecorder struct {
+	mock *MockShardStrategy
+}
+
+// NewMockShardStrategy creates a new mock instance.
+func NewMockShardStrategy(ctrl *gomock.Controller) *MockShardStrategy {
+	mock := &MockShardStrategy{ctrl: ctrl}
+	mock.recorder = &MockShardStrategyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockShardStrategy) EXPECT() *MockShardStrategyMockRecorder {
+	return m.recorder
+}
+
+// Shard mocks base method.
+func (m *MockShardStrategy) Shard(key interface{}, shards int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Shard", key, shards)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Shard indicates an expected call of Shard.
+func (mr *MockShardStrategyMockRecorder) Shard(key, shards interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shard", reflect.TypeOf((*MockShardStrategy)(nil).Shard), key, shards)
+}
+
+// MockShardSet is a mock of ShardSet interface.
+type MockShardSet struct {
+	ctrl     *gomock.Controller
+	recorder *MockShardSetMockRecorder
+}
+
+// MockShardSetMockRecorder is the mock recorder for MockShardSet.
+type MockShardSetMockRecorder struct {
+	mock *MockShardSet
+}
+
+// NewMockShardSet creates a new mock instance.
+func NewMockShardSet(ctrl *gomock.Controller) *MockShardSet {
+	mock := &MockShardSet{ctrl: ctrl}
+	mock.recorder = &MockShardSetMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockShardSet) EXPECT() *MockShardSetMockRecorder {
+	return m.recorder
+}
+
+// ExecContext mocks base method.
+func (m *MockShardSet) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext.
+func (mr *MockShardSetMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockShardSet)(nil).ExecContext), varargs...)
+}
+
+// QueryContext mocks base method.
+func (m *MockShardSet) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(isql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext.
+func (mr *MockShardSetMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockShardSet)(nil).QueryContext), varargs...)
+}
+
+// QueryRowContext mocks base method.
+func (m *MockShardSet) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
+	ret0, _ := ret[0].(isql.Row)
+	return ret0
+}
+
+// QueryRowContext indicates an expected call of QueryRowContext.
+func (mr *MockShardSetMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockShardSet)(nil).QueryRowContext), varargs...)
+}
+
+// ScatterQueryContext mocks base method.
+func (m *MockShardSet) ScatterQueryContext(ctx context.Context, opts isql.ScatterOptions, query string, args ...interface{}) (isql.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, opts, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ScatterQueryContext", varargs...)
+	ret0, _ := ret[0].(isql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScatterQueryContext indicates an expected call of ScatterQueryContext.
+func (mr *MockShardSetMockRecorder) ScatterQueryContext(ctx, opts, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, opts, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScatterQueryContext", reflect.TypeOf((*MockShardSet)(nil).ScatterQueryContext), varargs...)
+}
+
+// Shard mocks base method.
+func (m *MockShardSet) Shard(key interface{}) (isql.ReplicaSet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Shard", key)
+	ret0, _ := ret[0].(isql.ReplicaSet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Shard indicates an expected call of Shard.
+func (mr *MockShardSetMockRecorder) Shard(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shard", reflect.TypeOf((*MockShardSet)(nil).Shard), key)
+}
+
+// Shards mocks base method.
+func (m *MockShardSet) Shards() []isql.ReplicaSet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Shards")
+	ret0, _ := ret[0].([]isql.ReplicaSet)
+	return ret0
+}
+
+// Shards indicates an expected call of Shards.
+func (mr *MockShardSetMockRecorder) Shards() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shards", reflect.TypeOf((*MockShardSet)(nil).Shards))
+}
@@ -0,0 +1,394 @@
+package isql
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("isql: invalid cursor")
+
+type PageOrder struct {
+	// Column is a column of the base query's result, the final column(s) must make the order unique, e.g. the
+	// primary key, and none may be NULL.
+	Column string
+	Desc   bool
+	// NullsLast sorts NULLs after every other value whatever the direction, as NULLS LAST does, otherwise they
+	// sort first. It is only used to merge the rows of ScatterQueryContext, Postgres sorts NULLs last ascending
+	// and first descending by default, MySQL, SQLite and SQL Server the reverse.
+	NullsLast bool
+}
+
+type PageRequest struct {
+	Dialect Dialect
+	// Query is the base query, it must not have an ORDER BY or LIMIT and its result must include every order
+	// column. It is wrapped as a derived table, SELECT * FROM (<Query>) ..., so the seek predicate applies to its
+	// result columns.
+	Query string
+	// Args are the base query's args, placeholders for the seek predicate are numbered after them.
+	Args  []interface{}
+	Order []PageOrder
+	// Limit is the page size, it must be positive.
+	Limit int
+	// Cursor is a Page's Next or Prev token, empty for the first page.
+	Cursor string
+	// Key if set signs cursors with HMAC-SHA256 so they can't be forged, otherwise they are only encoded.
+	Key []byte
+}
+
+type Page struct {
+	Set ResultSet
+	// Next is the cursor of the page after this one, empty if this is the last page.
+	Next string
+	// Prev is the cursor of the page before this one, empty if this is the first page.
+	Prev string
+}
+
+// Rows returns the page's rows which scan with the same conversions as "database/sql".
+func (p *Page) Rows() (Rows, error) {
+	return NewDataRows(p.Set)
+}
+
+// Paginate runs a page of req.Query on db using keyset pagination, rather than skipping rows with OFFSET it
+// seeks past the order column values of the row the cursor was taken from, e.g. WHERE (a, b) > ($1, $2), so
+// every page costs the same however deep it is. Row value comparisons are used where the dialect supports them
+// and the order directions agree, otherwise the predicate is expanded to a > ? OR (a = ? AND b > ?).
+func Paginate(ctx context.Context, db DBCore, req PageRequest) (*Page, error) {
+	if req.Limit <= 0 {
+		return nil, fmt.Errorf("isql: page limit must be positive")
+	}
+	if len(req.Order) == 0 {
+		return nil, fmt.Errorf("isql: page order is required")
+	}
+	c := cursor{
+		Forward: true,
+	}
+	if req.Cursor != "" {
+		var err error
+		if c, err = decodeCursor(req.Cursor, req.Key, req.Order); err != nil {
+			return nil, err
+		}
+	}
+	query, args := pageQuery(req, c)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	set, err := readResultSet(rows)
+	if err != nil {
+		return nil, err
+	}
+	if set.Err != nil {
+		return nil, set.Err
+	}
+	indexes := make([]int, len(req.Order))
+	for i, o := range req.Order {
+		indexes[i] = -1
+		for j, column := range set.Columns {
+			if strings.EqualFold(column, o.Column) {
+				indexes[i] = j
+			}
+		}
+		if indexes[i] < 0 {
+			return nil, fmt.Errorf("isql: page order column %s is not in the query result", o.Column)
+		}
+	}
+	more := len(set.Rows) > req.Limit
+	if more {
+		set.Rows = set.Rows[:req.Limit]
+	}
+	if !c.Forward {
+		// backward pages are read in reverse order
+		for i, j := 0, len(set.Rows)-1; i < j; i, j = i+1, j-1 {
+			set.Rows[i], set.Rows[j] = set.Rows[j], set.Rows[i]
+		}
+	}
+	page := &Page{
+		Set: set,
+	}
+	if len(set.Rows) == 0 {
+		return page, nil
+	}
+	// going forward there is a previous page if we came from one and a next page if there were more rows,
+	// going backward the reverse
+	hasNext, hasPrev := more, req.Cursor != ""
+	if !c.Forward {
+		hasNext, hasPrev = true, more
+	}
+	if hasNext {
+		if page.Next, err = encodeCursor(cursor{Forward: true, Values: keyValues(set, len(set.Rows)-1, indexes)}, req.Key, req.Order); err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		if page.Prev, err = encodeCursor(cursor{Forward: false, Values: keyValues(set, 0, indexes)}, req.Key, req.Order); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// keyValues returns the values of the columns at indexes of row i of set, converted with keyValue.
+func keyValues(set ResultSet, i int, indexes []int) []interface{} {
+	values := make([]interface{}, len(indexes))
+	for j, idx := range indexes {
+		ct := ColumnTypeInfo{}
+		if idx < len(set.ColumnTypes) {
+			ct = set.ColumnTypes[idx]
+		}
+		values[j] = keyValue(set.Rows[i][idx], ct)
+	}
+	return values
+}
+
+var (
+	nullInt64Type   = reflect.TypeOf(sql.NullInt64{})
+	nullFloat64Type = reflect.TypeOf(sql.NullFloat64{})
+	nullStringType  = reflect.TypeOf(sql.NullString{})
+)
+
+// keyValue converts v, a driver value of a column of type ct, to the value it is encoded in a cursor and compared
+// as. Drivers may return numbers and text as []byte, e.g. MySQL without the binary protocol, which would be
+// compared as bytes so 10 sorts before 9, they are parsed by their scan type or, failing that, database type.
+func keyValue(v interface{}, ct ColumnTypeInfo) interface{} {
+	b, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+	scanType := ct.ScanType
+	for scanType != nil && scanType.Kind() == reflect.Ptr {
+		scanType = scanType.Elem()
+	}
+	kind := reflect.Invalid
+	if scanType != nil {
+		kind = scanType.Kind()
+	}
+	dbType := strings.ToUpper(ct.DatabaseType)
+	switch {
+	case scanType == nullInt64Type || kind >= reflect.Int && kind <= reflect.Uint64 || IsIntegerType(dbType):
+		if i, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(string(b), 10, 64); err == nil {
+			return u
+		}
+	case scanType == nullFloat64Type || kind == reflect.Float32 || kind == reflect.Float64 ||
+		strings.Contains(dbType, "FLOAT") || strings.Contains(dbType, "DOUBLE") || dbType == "REAL":
+		if f, err := strconv.ParseFloat(string(b), 64); err == nil {
+			return f
+		}
+	case scanType == nullStringType || kind == reflect.String || strings.Contains(dbType, "CHAR") || strings.Contains(dbType, "TEXT"):
+		return string(b)
+	}
+	return v
+}
+
+// pageQuery wraps req.Query with the seek predicate for c, the order, reversed when going backward, and a limit
+// of one more row than the page to tell whether there are more.
+func pageQuery(req PageRequest, c cursor) (string, []interface{}) {
+	d := req.Dialect
+	args := append([]interface{}{}, req.Args...)
+	b := &strings.Builder{}
+	b.WriteString("SELECT * FROM (")
+	b.WriteString(req.Query)
+	b.WriteString(") isql_page")
+	// desc reports whether column i is read in descending order
+	desc := func(i int) bool {
+		return req.Order[i].Desc == c.Forward
+	}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return d.Placeholder(len(args))
+	}
+	if len(c.Values) > 0 {
+		b.WriteString(" WHERE ")
+		sameDirection := true
+		for i := range req.Order {
+			sameDirection = sameDirection && req.Order[i].Desc == req.Order[0].Desc
+		}
+		if sameDirection && d.Name() != "sqlserver" {
+			columns := make([]string, len(req.Order))
+			placeholders := make([]string, len(req.Order))
+			for i, o := range req.Order {
+				columns[i] = o.Column
+				placeholders[i] = arg(c.Values[i])
+			}
+			op := " > "
+			if desc(0) {
+				op = " < "
+			}
+			b.WriteString("(" + strings.Join(columns, ", ") + ")" + op + "(" + strings.Join(placeholders, ", ") + ")")
+		} else {
+			terms := make([]string, len(req.Order))
+			for i := range req.Order {
+				parts := make([]string, 0, i+1)
+				for j := 0; j < i; j++ {
+					parts = append(parts, req.Order[j].Column+" = "+arg(c.Values[j]))
+				}
+				op := " > "
+				if desc(i) {
+					op = " < "
+				}
+				parts = append(parts, req.Order[i].Column+op+arg(c.Values[i]))
+				terms[i] = "(" + strings.Join(parts, " AND ") + ")"
+			}
+			b.WriteString("(" + strings.Join(terms, " OR ") + ")")
+		}
+	}
+	b.WriteString(" ORDER BY ")
+	for i, o := range req.Order {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(o.Column)
+		if desc(i) {
+			b.WriteString(" DESC")
+		}
+	}
+	limit := strconv.Itoa(req.Limit + 1)
+	if d.Name() == "sqlserver" {
+		b.WriteString(" OFFSET 0 ROWS FETCH NEXT " + limit + " ROWS ONLY")
+	} else {
+		b.WriteString(" LIMIT " + limit)
+	}
+	return b.String(), args
+}
+
+type cursor struct {
+	Forward bool
+	Values  []interface{}
+}
+
+// cursorPayload is the encoded form of a cursor, values are tagged with their type so they decode to the same
+// driver value they were read as.
+type cursorPayload struct {
+	Order   string      `json:"o"`
+	Forward bool        `json:"f"`
+	Values  [][2]string `json:"v"`
+}
+
+func orderSignature(order []PageOrder) string {
+	parts := make([]string, len(order))
+	for i, o := range order {
+		parts[i] = o.Column
+		if o.Desc {
+			parts[i] += " DESC"
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func encodeCursor(c cursor, key []byte, order []PageOrder) (string, error) {
+	p := cursorPayload{
+		Order:   orderSignature(order),
+		Forward: c.Forward,
+		Values:  make([][2]string, len(c.Values)),
+	}
+	for i, v := range c.Values {
+		switch v := v.(type) {
+		case nil:
+			p.Values[i] = [2]string{"n", ""}
+		case int64:
+			p.Values[i] = [2]string{"i", strconv.FormatInt(v, 10)}
+		case uint64:
+			p.Values[i] = [2]string{"u", strconv.FormatUint(v, 10)}
+		case float64:
+			p.Values[i] = [2]string{"f", strconv.FormatFloat(v, 'g', -1, 64)}
+		case bool:
+			p.Values[i] = [2]string{"b", strconv.FormatBool(v)}
+		case string:
+			p.Values[i] = [2]string{"s", v}
+		case []byte:
+			p.Values[i] = [2]string{"x", base64.StdEncoding.EncodeToString(v)}
+		case time.Time:
+			p.Values[i] = [2]string{"t", v.Format(time.RFC3339Nano)}
+		default:
+			return "", fmt.Errorf("isql: can't encode page cursor value of type %T", v)
+		}
+	}
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(payload)
+	if key != nil {
+		token += "." + base64.RawURLEncoding.EncodeToString(sign(key, payload))
+	}
+	return token, nil
+}
+
+func decodeCursor(token string, key []byte, order []PageOrder) (cursor, error) {
+	encoded, signature := token, ""
+	if key != nil {
+		dot := strings.IndexByte(token, '.')
+		if dot < 0 {
+			return cursor{}, ErrInvalidCursor
+		}
+		encoded, signature = token[:dot], token[dot+1:]
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	if key != nil {
+		mac, err := base64.RawURLEncoding.DecodeString(signature)
+		if err != nil || !hmac.Equal(mac, sign(key, payload)) {
+			return cursor{}, ErrInvalidCursor
+		}
+	}
+	p := cursorPayload{}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	if p.Order != orderSignature(order) || len(p.Values) != len(order) {
+		return cursor{}, ErrInvalidCursor
+	}
+	c := cursor{
+		Forward: p.Forward,
+		Values:  make([]interface{}, len(p.Values)),
+	}
+	for i, tv := range p.Values {
+		var v interface{}
+		var err error
+		switch tv[0] {
+		case "n":
+		case "i":
+			v, err = strconv.ParseInt(tv[1], 10, 64)
+		case "u":
+			v, err = strconv.ParseUint(tv[1], 10, 64)
+		case "f":
+			v, err = strconv.ParseFloat(tv[1], 64)
+		case "b":
+			v, err = strconv.ParseBool(tv[1])
+		case "s":
+			v = tv[1]
+		case "x":
+			v, err = base64.StdEncoding.DecodeString(tv[1])
+		case "t":
+			v, err = time.Parse(time.RFC3339Nano, tv[1])
+		default:
+			err = ErrInvalidCursor
+		}
+		if err != nil {
+			return cursor{}, ErrInvalidCursor
+		}
+		c.Values[i] = v
+	}
+	return c, nil
+}
+
+func sign(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,205 @@
+package isql_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+// idTable is a table with an id column which it returns as []byte text, as MySQL does without the binary
+// protocol, it runs page queries by reading their direction, seek arg and limit.
+type idTable struct {
+	ids  []int64
+	args [][]interface{}
+}
+
+func (t *idTable) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return isql.NewResult(0, 0), nil
+}
+
+func (t *idTable) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	t.args = append(t.args, args)
+	desc := strings.Contains(query, "id DESC")
+	ids := append([]int64{}, t.ids...)
+	sort.Slice(ids, func(i, j int) bool { return (ids[i] < ids[j]) != desc })
+	limit, err := strconv.Atoi(query[strings.LastIndex(query, " ")+1:])
+	if err != nil {
+		return nil, err
+	}
+	set := isql.ResultSet{
+		Columns:     []string{"id"},
+		ColumnTypes: []isql.ColumnTypeInfo{{DatabaseType: "BIGINT", ScanType: reflect.TypeOf(sql.RawBytes{})}},
+	}
+	for _, id := range ids {
+		if len(args) > 0 {
+			seek, ok := args[0].(int64)
+			if !ok {
+				return nil, fmt.Errorf("seek arg %T, expected int64", args[0])
+			}
+			if desc && id >= seek || !desc && id <= seek {
+				continue
+			}
+		}
+		if len(set.Rows) < limit {
+			set.Rows = append(set.Rows, []interface{}{[]byte(strconv.FormatInt(id, 10))})
+		}
+	}
+	return isql.NewDataRows(set)
+}
+
+func (t *idTable) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	return isql.NewDataRow(isql.ResultSet{})
+}
+
+func pageIDs(p *isql.Page) string {
+	ids := make([]string, len(p.Set.Rows))
+	for i, row := range p.Set.Rows {
+		ids[i] = string(row[0].([]byte))
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestPaginateRoundTrip(t *testing.T) {
+	table := &idTable{ids: []int64{8, 9, 10, 11, 100}}
+	tests := []struct {
+		limit int
+		order isql.PageOrder
+		pages []string
+	}{
+		{1, isql.PageOrder{Column: "id"}, []string{"8", "9", "10", "11", "100"}},
+		{2, isql.PageOrder{Column: "id"}, []string{"8,9", "10,11", "100"}},
+		{2, isql.PageOrder{Column: "id", Desc: true}, []string{"100,11", "10,9", "8"}},
+		{5, isql.PageOrder{Column: "id"}, []string{"8,9,10,11,100"}},
+	}
+	for _, tt := range tests {
+		name := fmt.Sprintf("limit %d desc %v", tt.limit, tt.order.Desc)
+		req := isql.PageRequest{
+			Dialect: isql.MySQL,
+			Query:   "SELECT id FROM t",
+			Order:   []isql.PageOrder{tt.order},
+			Limit:   tt.limit,
+			Key:     []byte("key"),
+		}
+		var pages []*isql.Page
+		for {
+			p, err := isql.Paginate(context.Background(), table, req)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			pages = append(pages, p)
+			if p.Next == "" || len(pages) > len(tt.pages) {
+				break
+			}
+			req.Cursor = p.Next
+		}
+		var ids []string
+		for _, p := range pages {
+			ids = append(ids, pageIDs(p))
+		}
+		if !reflect.DeepEqual(ids, tt.pages) {
+			t.Errorf("%s: forward pages %q, expected %q", name, ids, tt.pages)
+			continue
+		}
+		if pages[0].Prev != "" {
+			t.Errorf("%s: expected no previous page before the first", name)
+		}
+		// walk back from the last page, every page must match the one read going forward
+		for i := len(pages) - 1; i > 0; i-- {
+			req.Cursor = pages[i].Prev
+			p, err := isql.Paginate(context.Background(), table, req)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if pageIDs(p) != tt.pages[i-1] || p.Next == "" || (p.Prev == "") != (i == 1) {
+				t.Errorf("%s: backward page %q next %q prev %q, expected %q", name, pageIDs(p), p.Next, p.Prev, tt.pages[i-1])
+			}
+		}
+	}
+}
+
+// setDB returns set for every query, recording the queries and their args.
+type setDB struct {
+	isql.DBCore
+	set     isql.ResultSet
+	queries []string
+	args    [][]interface{}
+}
+
+func (s *setDB) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	s.queries = append(s.queries, query)
+	s.args = append(s.args, args)
+	return isql.NewDataRows(s.set)
+}
+
+func TestPaginateQuery(t *testing.T) {
+	at := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	set := isql.ResultSet{Columns: []string{"id", "at"}, Rows: [][]interface{}{{int64(3), at}, {int64(2), at}, {int64(1), at}}}
+	tests := []struct {
+		name  string
+		d     isql.Dialect
+		order []isql.PageOrder
+		query string
+		args  []interface{}
+	}{
+		{"row value", isql.Postgres, []isql.PageOrder{{Column: "at", Desc: true}, {Column: "id", Desc: true}},
+			"SELECT * FROM (SELECT id, at FROM t WHERE org = ?) isql_page WHERE (at, id) < ($2, $3) ORDER BY at DESC, id DESC LIMIT 3",
+			[]interface{}{9, at, int64(2)}},
+		{"mixed directions", isql.Postgres, []isql.PageOrder{{Column: "at", Desc: true}, {Column: "id"}},
+			"SELECT * FROM (SELECT id, at FROM t WHERE org = ?) isql_page WHERE ((at < $2) OR (at = $3 AND id > $4)) ORDER BY at DESC, id LIMIT 3",
+			[]interface{}{9, at, at, int64(2)}},
+		{"sqlserver", isql.SQLServer, []isql.PageOrder{{Column: "at"}, {Column: "id"}},
+			"SELECT * FROM (SELECT id, at FROM t WHERE org = ?) isql_page WHERE ((at > @p2) OR (at = @p3 AND id > @p4)) " +
+				"ORDER BY at, id OFFSET 0 ROWS FETCH NEXT 3 ROWS ONLY",
+			[]interface{}{9, at, at, int64(2)}},
+	}
+	for _, tt := range tests {
+		db := &setDB{set: set}
+		req := isql.PageRequest{Dialect: tt.d, Query: "SELECT id, at FROM t WHERE org = ?", Args: []interface{}{9}, Order: tt.order, Limit: 2}
+		p, err := isql.Paginate(context.Background(), db, req)
+		if err != nil || p.Next == "" || p.Prev != "" {
+			t.Fatalf("%s: page %+v with error %v", tt.name, p, err)
+		}
+		req.Cursor = p.Next
+		if _, err := isql.Paginate(context.Background(), db, req); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if db.queries[1] != tt.query || !reflect.DeepEqual(db.args[1], tt.args) {
+			t.Errorf("%s: query %s %v, expected %s %v", tt.name, db.queries[1], db.args[1], tt.query, tt.args)
+		}
+	}
+}
+
+func TestPaginateCursor(t *testing.T) {
+	db := &setDB{set: isql.ResultSet{Columns: []string{"id"}, ColumnTypes: []isql.ColumnTypeInfo{{DatabaseType: "UNSIGNED BIGINT"}},
+		Rows: [][]interface{}{{[]byte("9223372036854775808")}, {[]byte("9223372036854775809")}}}}
+	req := isql.PageRequest{Dialect: isql.MySQL, Query: "SELECT id FROM t", Order: []isql.PageOrder{{Column: "id"}}, Limit: 1, Key: []byte("key")}
+	p, err := isql.Paginate(context.Background(), db, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Cursor = p.Next
+	if _, err := isql.Paginate(context.Background(), db, req); err != nil || !reflect.DeepEqual(db.args[1], []interface{}{uint64(1 << 63)}) {
+		t.Fatalf("args %v with error %v, expected the unsigned key to round trip", db.args[1], err)
+	}
+	tests := map[string]isql.PageRequest{
+		"forged":   func(r isql.PageRequest) isql.PageRequest { r.Cursor = p.Next[:len(p.Next)-2] + "xx"; return r }(req),
+		"unsigned": func(r isql.PageRequest) isql.PageRequest { r.Key = nil; return r }(req),
+		"other order": func(r isql.PageRequest) isql.PageRequest {
+			r.Order = []isql.PageOrder{{Column: "id", Desc: true}}
+			return r
+		}(req),
+	}
+	for name, r := range tests {
+		if _, err := isql.Paginate(context.Background(), db, r); err != isql.ErrInvalidCursor {
+			t.Errorf("%s: error %v, expected %v", name, err, isql.ErrInvalidCursor)
+		}
+	}
+}
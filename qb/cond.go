@@ -0,0 +1,269 @@
+package qb
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Cond is a boolean SQL expression used in WHERE, HAVING and JOIN ON clauses, a nil Cond is skipped so optional
+// filters can be left unset.
+type Cond interface {
+	writeCond(w *writer)
+}
+
+// Eq matches column against value, a nil value is written as column IS NULL.
+func Eq(column string, value interface{}) Cond {
+	return &compare{column, "=", value}
+}
+
+// NotEq is the negation of Eq, a nil value is written as column IS NOT NULL.
+func NotEq(column string, value interface{}) Cond {
+	return &compare{column, "<>", value}
+}
+
+func Lt(column string, value interface{}) Cond {
+	return &compare{column, "<", value}
+}
+
+func Lte(column string, value interface{}) Cond {
+	return &compare{column, "<=", value}
+}
+
+func Gt(column string, value interface{}) Cond {
+	return &compare{column, ">", value}
+}
+
+func Gte(column string, value interface{}) Cond {
+	return &compare{column, ">=", value}
+}
+
+func Like(column string, pattern interface{}) Cond {
+	return &compare{column, "LIKE", pattern}
+}
+
+func IsNull(column string) Cond {
+	return &raw{sql: column + " IS NULL"}
+}
+
+func IsNotNull(column string) Cond {
+	return &raw{sql: column + " IS NOT NULL"}
+}
+
+// In matches column against values, which may be given individually, as a single slice or as a single
+// *SelectBuilder subquery. An empty list matches nothing.
+func In(column string, values ...interface{}) Cond {
+	return &in{column, values, false}
+}
+
+// NotIn is the negation of In, an empty list matches everything.
+func NotIn(column string, values ...interface{}) Cond {
+	return &in{column, values, true}
+}
+
+// And matches when every non nil cond does, it is skipped if there are none.
+func And(conds ...Cond) Cond {
+	return junction(" AND ", conds)
+}
+
+// Or matches when any non nil cond does, it is skipped if there are none.
+func Or(conds ...Cond) Cond {
+	return junction(" OR ", conds)
+}
+
+func Not(cond Cond) Cond {
+	if cond == nil || isNilCond(cond) {
+		return nil
+	}
+	return &not{cond}
+}
+
+// Expr is a raw SQL expression with a ? for each of args, which are rewritten to the dialect's placeholders,
+// e.g. Expr("u.id = o.user_id") or Expr("lower(email) = lower(?)", email). A ? inside quotes is left alone and ??
+// is written as a single ?, e.g. Expr("data ?? ?", key) for the Postgres jsonb operator, as are ??| and ??&, it is
+// an error with dialects such as MySQL and SQLite whose placeholder is ?.
+func Expr(sql string, args ...interface{}) Cond {
+	return &raw{sql, args}
+}
+
+type compare struct {
+	column string
+	op     string
+	value  interface{}
+}
+
+func (c *compare) writeCond(w *writer) {
+	if c.value == nil {
+		switch c.op {
+		case "=":
+			w.write(c.column, " IS NULL")
+		case "<>":
+			w.write(c.column, " IS NOT NULL")
+		default:
+			// comparing with NULL is never true
+			w.fail(fmt.Errorf("qb: %s %s NULL matches nothing", c.column, c.op))
+		}
+		return
+	}
+	w.write(c.column, " ", c.op, " ")
+	w.arg(c.value)
+}
+
+type in struct {
+	column string
+	values []interface{}
+	negate bool
+}
+
+func (c *in) writeCond(w *writer) {
+	values := c.values
+	if len(values) == 1 {
+		if _, ok := values[0].(statement); ok {
+			c.writeList(w, func() {
+				w.arg(values[0])
+			})
+			return
+		}
+		if v := reflect.ValueOf(values[0]); v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+			values = make([]interface{}, v.Len())
+			for i := range values {
+				values[i] = v.Index(i).Interface()
+			}
+		}
+	}
+	if len(values) == 0 {
+		if c.negate {
+			w.write("1 = 1")
+		} else {
+			w.write("1 = 0")
+		}
+		return
+	}
+	c.writeList(w, func() {
+		w.write("(")
+		for i, v := range values {
+			if i > 0 {
+				w.write(", ")
+			}
+			w.arg(v)
+		}
+		w.write(")")
+	})
+}
+
+func (c *in) writeList(w *writer, list func()) {
+	w.write(c.column)
+	if c.negate {
+		w.write(" NOT IN ")
+	} else {
+		w.write(" IN ")
+	}
+	list()
+}
+
+type junctionCond struct {
+	sep   string
+	conds []Cond
+}
+
+func junction(sep string, conds []Cond) Cond {
+	nonNil := make([]Cond, 0, len(conds))
+	for _, c := range conds {
+		if c != nil && !isNilCond(c) {
+			nonNil = append(nonNil, c)
+		}
+	}
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	}
+	return &junctionCond{sep, nonNil}
+}
+
+func (c *junctionCond) writeCond(w *writer) {
+	w.write("(")
+	for i, cond := range c.conds {
+		if i > 0 {
+			w.write(c.sep)
+		}
+		cond.writeCond(w)
+	}
+	w.write(")")
+}
+
+type not struct {
+	cond Cond
+}
+
+func (c *not) writeCond(w *writer) {
+	w.write("NOT (")
+	c.cond.writeCond(w)
+	w.write(")")
+}
+
+type raw struct {
+	sql  string
+	args []interface{}
+}
+
+func (c *raw) writeCond(w *writer) {
+	n := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(c.sql); i++ {
+		ch := c.sql[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '?' && i+1 < len(c.sql) && c.sql[i+1] == '?':
+			if w.d.Placeholder(1) == "?" {
+				w.fail(fmt.Errorf("qb: %q writes a literal ? which %s takes as a placeholder", c.sql, w.d.Name()))
+			}
+			w.write(c.sql[start : i+1])
+			i++
+			start = i + 1
+		case ch == '?':
+			w.write(c.sql[start:i])
+			start = i + 1
+			if n < len(c.args) {
+				w.arg(c.args[n])
+			}
+			n++
+		}
+	}
+	w.write(c.sql[start:])
+	if n != len(c.args) {
+		w.fail(errArgCount(c.sql, n, len(c.args)))
+	}
+}
+
+// isNilCond reports whether c is a typed nil, e.g. a nil *raw stored in a Cond.
+func isNilCond(c Cond) bool {
+	v := reflect.ValueOf(c)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// where writes " <keyword> " and conds joined with AND, it writes nothing when every cond is nil.
+func where(w *writer, keyword string, conds []Cond) {
+	c := And(conds...)
+	if c == nil {
+		return
+	}
+	w.write(" ", keyword, " ")
+	if j, ok := c.(*junctionCond); ok && j.sep == " AND " {
+		// top level conds need no parentheses
+		for i, cond := range j.conds {
+			if i > 0 {
+				w.write(j.sep)
+			}
+			cond.writeCond(w)
+		}
+		return
+	}
+	c.writeCond(w)
+}
@@ -0,0 +1,237 @@
+package qb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/0xor1/isql"
+)
+
+type InsertBuilder struct {
+	d         isql.Dialect
+	table     string
+	columns   []string
+	rows      [][]interface{}
+	returning []string
+	err       error
+}
+
+func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+// Values adds a row of values, one for each column, call it repeatedly to insert several rows. A value may be
+// an Expr, e.g. qb.Expr("now()"), which is written in place.
+func (b *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	if len(values) != len(b.columns) && b.err == nil {
+		b.err = fmt.Errorf("qb: insert into %s has %d columns but row %d has %d values", b.table, len(b.columns), len(b.rows)+1, len(values))
+	}
+	b.rows = append(b.rows, values)
+	return b
+}
+
+// Returning sets the columns of the inserted rows to return, use Query or QueryRow to read them. It is written as
+// RETURNING, or OUTPUT INSERTED on SQL Server, and is not supported on MySQL.
+func (b *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	b.returning = append(b.returning, columns...)
+	return b
+}
+
+func (b *InsertBuilder) ToSQL() (string, []interface{}, error) {
+	return toSQL(b.d, b)
+}
+
+func (b *InsertBuilder) Exec(ctx context.Context, db isql.DBCore) (sql.Result, error) {
+	return exec(ctx, db, b.d, b)
+}
+
+func (b *InsertBuilder) Query(ctx context.Context, db isql.DBCore) (isql.Rows, error) {
+	return query(ctx, db, b.d, b)
+}
+
+func (b *InsertBuilder) QueryRow(ctx context.Context, db isql.DBCore) isql.Row {
+	return queryRow(ctx, db, b.d, b)
+}
+
+func (b *InsertBuilder) writeTo(w *writer) {
+	switch {
+	case b.err != nil:
+		w.fail(b.err)
+		return
+	case b.table == "":
+		w.fail(ErrNoTable)
+		return
+	case len(b.columns) == 0 || len(b.rows) == 0:
+		w.fail(ErrNoColumns)
+		return
+	}
+	w.write("INSERT INTO ", b.table, " (")
+	w.list(b.columns)
+	w.write(")")
+	writeOutput(w, "INSERTED", b.returning)
+	w.write(" VALUES ")
+	for i, row := range b.rows {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write("(")
+		for j, v := range row {
+			if j > 0 {
+				w.write(", ")
+			}
+			w.arg(v)
+		}
+		w.write(")")
+	}
+	writeReturning(w, b.returning)
+}
+
+type UpdateBuilder struct {
+	d         isql.Dialect
+	table     string
+	sets      []set
+	where     []Cond
+	returning []string
+}
+
+type set struct {
+	column string
+	value  interface{}
+}
+
+// Set assigns value to column, value may be an Expr, e.g. Set("count", qb.Expr("count + 1")).
+func (b *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	b.sets = append(b.sets, set{
+		column: column,
+		value:  value,
+	})
+	return b
+}
+
+// Where adds conds which must all match, nil conds are skipped, it may be called repeatedly. Without any non nil
+// conds every row is updated.
+func (b *UpdateBuilder) Where(conds ...Cond) *UpdateBuilder {
+	b.where = append(b.where, conds...)
+	return b
+}
+
+// Returning sets the columns of the updated rows to return, see InsertBuilder.Returning.
+func (b *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	b.returning = append(b.returning, columns...)
+	return b
+}
+
+func (b *UpdateBuilder) ToSQL() (string, []interface{}, error) {
+	return toSQL(b.d, b)
+}
+
+func (b *UpdateBuilder) Exec(ctx context.Context, db isql.DBCore) (sql.Result, error) {
+	return exec(ctx, db, b.d, b)
+}
+
+func (b *UpdateBuilder) Query(ctx context.Context, db isql.DBCore) (isql.Rows, error) {
+	return query(ctx, db, b.d, b)
+}
+
+func (b *UpdateBuilder) QueryRow(ctx context.Context, db isql.DBCore) isql.Row {
+	return queryRow(ctx, db, b.d, b)
+}
+
+func (b *UpdateBuilder) writeTo(w *writer) {
+	switch {
+	case b.table == "":
+		w.fail(ErrNoTable)
+		return
+	case len(b.sets) == 0:
+		w.fail(ErrNoColumns)
+		return
+	}
+	w.write("UPDATE ", b.table, " SET ")
+	for i, s := range b.sets {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write(s.column, " = ")
+		w.arg(s.value)
+	}
+	writeOutput(w, "INSERTED", b.returning)
+	where(w, "WHERE", b.where)
+	writeReturning(w, b.returning)
+}
+
+type DeleteBuilder struct {
+	d         isql.Dialect
+	table     string
+	where     []Cond
+	returning []string
+}
+
+// Where adds conds which must all match, nil conds are skipped, it may be called repeatedly. Without any non nil
+// conds every row is deleted.
+func (b *DeleteBuilder) Where(conds ...Cond) *DeleteBuilder {
+	b.where = append(b.where, conds...)
+	return b
+}
+
+// Returning sets the columns of the deleted rows to return, see InsertBuilder.Returning.
+func (b *DeleteBuilder) Returning(columns ...string) *DeleteBuilder {
+	b.returning = append(b.returning, columns...)
+	return b
+}
+
+func (b *DeleteBuilder) ToSQL() (string, []interface{}, error) {
+	return toSQL(b.d, b)
+}
+
+func (b *DeleteBuilder) Exec(ctx context.Context, db isql.DBCore) (sql.Result, error) {
+	return exec(ctx, db, b.d, b)
+}
+
+func (b *DeleteBuilder) Query(ctx context.Context, db isql.DBCore) (isql.Rows, error) {
+	return query(ctx, db, b.d, b)
+}
+
+func (b *DeleteBuilder) QueryRow(ctx context.Context, db isql.DBCore) isql.Row {
+	return queryRow(ctx, db, b.d, b)
+}
+
+func (b *DeleteBuilder) writeTo(w *writer) {
+	if b.table == "" {
+		w.fail(ErrNoTable)
+		return
+	}
+	w.write("DELETE FROM ", b.table)
+	writeOutput(w, "DELETED", b.returning)
+	where(w, "WHERE", b.where)
+	writeReturning(w, b.returning)
+}
+
+// writeOutput writes SQL Server's OUTPUT clause, which comes before VALUES and WHERE.
+func writeOutput(w *writer, pseudoTable string, columns []string) {
+	if len(columns) == 0 || w.d.Name() != "sqlserver" {
+		return
+	}
+	prefixed := make([]string, len(columns))
+	for i, c := range columns {
+		prefixed[i] = pseudoTable + "." + c
+	}
+	w.write(" OUTPUT ", strings.Join(prefixed, ", "))
+}
+
+// writeReturning writes the RETURNING clause every other dialect but MySQL supports.
+func writeReturning(w *writer, columns []string) {
+	if len(columns) == 0 {
+		return
+	}
+	switch w.d.Name() {
+	case "sqlserver":
+	case "mysql":
+		w.fail(ErrReturningUnsupported)
+	default:
+		w.write(" RETURNING ")
+		w.list(columns)
+	}
+}
@@ -0,0 +1,168 @@
+// Package qb builds SELECT, INSERT, UPDATE and DELETE statements for an isql.Dialect, values are always bound
+// as args so dynamic filters need no string concatenation, e.g.
+//
+//	rows, err := qb.New(isql.Postgres).Select("id", "name").From("users").
+//		Where(qb.Eq("org_id", orgID), nameFilter).OrderBy("name").Limit(20).Query(ctx, db)
+//
+// where a nil Cond such as an unset nameFilter is skipped. Table and column names are written as given, they
+// are not quoted or escaped so must never come from user input. Builders are mutable, each method modifies and
+// returns its receiver.
+package qb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0xor1/isql"
+)
+
+var (
+	ErrNoTable              = errors.New("qb: no table")
+	ErrNoColumns            = errors.New("qb: no columns")
+	ErrReturningUnsupported = errors.New("qb: RETURNING is not supported by the dialect")
+)
+
+// New returns a Builder of statements for d.
+func New(d isql.Dialect) *Builder {
+	return &Builder{
+		d: d,
+	}
+}
+
+type Builder struct {
+	d isql.Dialect
+}
+
+func (b *Builder) Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{
+		d:       b.d,
+		columns: columns,
+	}
+}
+
+func (b *Builder) Insert(table string) *InsertBuilder {
+	return &InsertBuilder{
+		d:     b.d,
+		table: table,
+	}
+}
+
+func (b *Builder) Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{
+		d:     b.d,
+		table: table,
+	}
+}
+
+func (b *Builder) Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{
+		d:     b.d,
+		table: table,
+	}
+}
+
+// writer accumulates a statement and its args, writing a placeholder for each arg in the dialect's style.
+type writer struct {
+	d    isql.Dialect
+	b    strings.Builder
+	args []interface{}
+	err  error
+}
+
+func (w *writer) write(s ...string) {
+	for _, part := range s {
+		w.b.WriteString(part)
+	}
+}
+
+// arg binds v, an Expr is written in place and a statement such as a *SelectBuilder is written as a
+// parenthesised subquery, both sharing the statement's args.
+func (w *writer) arg(v interface{}) {
+	if expr, ok := v.(*raw); ok && expr != nil {
+		expr.writeCond(w)
+		return
+	}
+	if sub, ok := v.(statement); ok {
+		w.write("(")
+		sub.writeTo(w)
+		w.write(")")
+		return
+	}
+	w.args = append(w.args, v)
+	w.write(w.d.Placeholder(len(w.args)))
+}
+
+func (w *writer) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *writer) list(items []string) {
+	w.write(strings.Join(items, ", "))
+}
+
+func (w *writer) int(n int64) {
+	w.write(strconv.FormatInt(n, 10))
+}
+
+// statement is implemented by every builder.
+type statement interface {
+	writeTo(w *writer)
+}
+
+func toSQL(d isql.Dialect, s statement) (string, []interface{}, error) {
+	w := &writer{
+		d: d,
+	}
+	s.writeTo(w)
+	if w.err == nil && len(w.args) > d.MaxParams() {
+		w.err = fmt.Errorf("qb: %d args exceeds the %s limit of %d", len(w.args), d.Name(), d.MaxParams())
+	}
+	if w.err != nil {
+		return "", nil, w.err
+	}
+	return w.b.String(), w.args, nil
+}
+
+func query(ctx context.Context, db isql.DBCore, d isql.Dialect, s statement) (isql.Rows, error) {
+	q, args, err := toSQL(d, s)
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryContext(ctx, q, args...)
+}
+
+func queryRow(ctx context.Context, db isql.DBCore, d isql.Dialect, s statement) isql.Row {
+	q, args, err := toSQL(d, s)
+	if err != nil {
+		return &errRow{
+			err: err,
+		}
+	}
+	return db.QueryRowContext(ctx, q, args...)
+}
+
+func exec(ctx context.Context, db isql.DBCore, d isql.Dialect, s statement) (sql.Result, error) {
+	q, args, err := toSQL(d, s)
+	if err != nil {
+		return nil, err
+	}
+	return db.ExecContext(ctx, q, args...)
+}
+
+func errArgCount(expr string, placeholders, args int) error {
+	return fmt.Errorf("qb: %q has %d placeholders but %d args", expr, placeholders, args)
+}
+
+type errRow struct {
+	err error
+}
+
+func (r *errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
@@ -0,0 +1,138 @@
+package qb_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/isqltest"
+	"github.com/0xor1/isql/mock"
+	"github.com/0xor1/isql/qb"
+	"github.com/golang/mock/gomock"
+)
+
+type statement interface {
+	ToSQL() (string, []interface{}, error)
+}
+
+func TestToSQL(t *testing.T) {
+	pg, ms := qb.New(isql.Postgres), qb.New(isql.SQLServer)
+	var nameFilter qb.Cond
+	tests := []struct {
+		name  string
+		s     statement
+		query string
+		args  []interface{}
+	}{
+		{"select", pg.Select("u.id", "u.name").From("users u").Join("orgs o", qb.Expr("o.id = u.org_id")).
+			Where(qb.Eq("o.id", 1), nameFilter, qb.Or(qb.Like("u.name", "a%"), qb.IsNull("u.name")), qb.In("u.id", []int{2, 3}), qb.NotIn("u.id")).
+			OrderBy("u.name").Limit(10).Offset(5),
+			"SELECT u.id, u.name FROM users u JOIN orgs o ON o.id = u.org_id WHERE o.id = $1 AND (u.name LIKE $2 OR u.name IS NULL) AND " +
+				"u.id IN ($3, $4) AND 1 = 1 ORDER BY u.name LIMIT 10 OFFSET 5", []interface{}{1, "a%", 2, 3}},
+		{"subquery", pg.Select("id").From("users").Where(qb.Eq("a", 1), qb.In("id", pg.Select("user_id").From("bans").Where(qb.Gt("at", 7)))),
+			"SELECT id FROM users WHERE a = $1 AND id IN (SELECT user_id FROM bans WHERE at > $2)", []interface{}{1, 7}},
+		{"nil values", pg.Select("id").From("users").Where(qb.Eq("deleted_at", nil), qb.NotEq("email", nil), qb.Not(qb.Eq("org_id", nil))),
+			"SELECT id FROM users WHERE deleted_at IS NULL AND email IS NOT NULL AND NOT (org_id IS NULL)", nil},
+		{"quoted placeholder", pg.Select("id").From("t").Where(qb.Expr("x = '?' AND y = ?", 2)), "SELECT id FROM t WHERE x = '?' AND y = $1", []interface{}{2}},
+		{"escaped placeholder", pg.Select("id").From("t").Where(qb.Expr("data ?? ? AND data ??| ? AND data ??& ?", "a", "b", "c")),
+			"SELECT id FROM t WHERE data ? $1 AND data ?| $2 AND data ?& $3", []interface{}{"a", "b", "c"}},
+		{"sqlserver limit", ms.Select("id").From("t").Limit(3), "SELECT id FROM t ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 3 ROWS ONLY", nil},
+		{"insert", ms.Insert("t").Columns("a", "b").Values(1, qb.Expr("GETDATE()")).Values(2, nil).Returning("id"),
+			"INSERT INTO t (a, b) OUTPUT INSERTED.id VALUES (@p1, GETDATE()), (@p2, @p3)", []interface{}{1, 2, nil}},
+		{"update", ms.Update("t").Set("n", qb.Expr("n + ?", 1)).Set("m", nil).Where(qb.Eq("id", 2)).Returning("n"),
+			"UPDATE t SET n = n + @p1, m = @p2 OUTPUT INSERTED.n WHERE id = @p3", []interface{}{1, nil, 2}},
+		{"delete", qb.New(isql.SQLite).Delete("t").Where(qb.Not(qb.Eq("a", 1))).Returning("id"), "DELETE FROM t WHERE NOT (a = ?) RETURNING id", []interface{}{1}},
+		{"mysql offset", qb.New(isql.MySQL).Select("id").From("t").Offset(2), "SELECT id FROM t LIMIT 18446744073709551615 OFFSET 2", nil},
+	}
+	for _, tt := range tests {
+		query, args, err := tt.s.ToSQL()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if query != tt.query || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s: %s %v, expected %s %v", tt.name, query, args, tt.query, tt.args)
+		}
+	}
+}
+
+func TestToSQLErrors(t *testing.T) {
+	pg := qb.New(isql.Postgres)
+	tests := []struct {
+		name string
+		s    statement
+		err  string
+	}{
+		{"returning", qb.New(isql.MySQL).Delete("t").Returning("id"), qb.ErrReturningUnsupported.Error()},
+		{"values", pg.Insert("t").Columns("a").Values(1, 2), ""},
+		{"arg count", pg.Select("a").From("t").Where(qb.Expr("a = ?")), `qb: "a = ?" has 1 placeholders but 0 args`},
+		{"escaped arg count", pg.Select("a").From("t").Where(qb.Expr("a ?? b", 1)), `qb: "a ?? b" has 0 placeholders but 1 args`},
+		{"null comparison", pg.Select("a").From("t").Where(qb.Gt("a", nil)), "qb: a > NULL matches nothing"},
+		{"escaped placeholder", qb.New(isql.MySQL).Select("a").From("t").Where(qb.Expr("a ?? b")), `qb: "a ?? b" writes a literal ? which mysql takes as a placeholder`},
+	}
+	for _, tt := range tests {
+		if _, _, err := tt.s.ToSQL(); err == nil || tt.err != "" && err.Error() != tt.err {
+			t.Errorf("%s: error %v, expected %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestExec(t *testing.T) {
+	f := isqltest.NewFake("qb_exec")
+	defer f.Close()
+	f.On(isqltest.Exact("DELETE FROM t WHERE id = $1")).WillReturnResult(0, 1)
+	db, err := isql.NewOpener().Open(isqltest.DriverName, "qb_exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	pg := qb.New(isql.Postgres)
+	ctx := context.Background()
+	res, err := pg.Delete("t").Where(qb.Eq("id", 3)).Exec(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Fatalf("%d rows affected", n)
+	}
+	if err := pg.Select().QueryRow(ctx, db).Scan(new(int)); err != qb.ErrNoColumns {
+		t.Fatalf("error %v, expected %v", err, qb.ErrNoColumns)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := mock.NewMockDBCore(ctrl)
+	sqlite := qb.New(isql.SQLite)
+	ctx := context.Background()
+	db.EXPECT().QueryContext(ctx, "SELECT id FROM users WHERE org_id = ? AND deleted_at IS NULL ORDER BY id", mock.Args(7)).
+		Return(mock.NewMockRowsFrom(ctrl, mock.NewRows("id").AddRow(1).AddRow(2)), nil)
+	db.EXPECT().QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE org_id = ?", mock.Args(7)).
+		Return(mock.NewMockRowFrom(ctrl, mock.NewRows("n").AddRow(2)))
+
+	rows, err := sqlite.Select("id").From("users").Where(qb.Eq("org_id", 7), qb.Eq("deleted_at", nil)).OrderBy("id").Query(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Fatalf("ids %v", ids)
+	}
+	var n int
+	if err := sqlite.Select("COUNT(*)").From("users").Where(qb.Eq("org_id", 7)).QueryRow(ctx, db).Scan(&n); err != nil || n != 2 {
+		t.Fatalf("count %d with error %v", n, err)
+	}
+	// a statement which can't be built never reaches the database
+	if _, err := sqlite.Select("id").From("t").Where(qb.Expr("a ?? b")).Query(ctx, db); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,180 @@
+package qb
+
+import (
+	"context"
+
+	"github.com/0xor1/isql"
+)
+
+type SelectBuilder struct {
+	d        isql.Dialect
+	distinct bool
+	columns  []string
+	from     string
+	joins    []join
+	where    []Cond
+	groupBy  []string
+	having   []Cond
+	orderBy  []string
+	limit    int64
+	offset   int64
+}
+
+type join struct {
+	kind  string
+	table string
+	on    Cond
+}
+
+func (s *SelectBuilder) Distinct() *SelectBuilder {
+	s.distinct = true
+	return s
+}
+
+// Columns adds columns to those passed to Select.
+func (s *SelectBuilder) Columns(columns ...string) *SelectBuilder {
+	s.columns = append(s.columns, columns...)
+	return s
+}
+
+// From sets the table, which may include an alias, e.g. From("users u").
+func (s *SelectBuilder) From(table string) *SelectBuilder {
+	s.from = table
+	return s
+}
+
+// Join adds an INNER JOIN, e.g. Join("orders o", qb.Expr("o.user_id = u.id")).
+func (s *SelectBuilder) Join(table string, on Cond) *SelectBuilder {
+	return s.addJoin("JOIN", table, on)
+}
+
+func (s *SelectBuilder) LeftJoin(table string, on Cond) *SelectBuilder {
+	return s.addJoin("LEFT JOIN", table, on)
+}
+
+func (s *SelectBuilder) RightJoin(table string, on Cond) *SelectBuilder {
+	return s.addJoin("RIGHT JOIN", table, on)
+}
+
+func (s *SelectBuilder) addJoin(kind, table string, on Cond) *SelectBuilder {
+	s.joins = append(s.joins, join{
+		kind:  kind,
+		table: table,
+		on:    on,
+	})
+	return s
+}
+
+// Where adds conds which must all match, nil conds are skipped, it may be called repeatedly.
+func (s *SelectBuilder) Where(conds ...Cond) *SelectBuilder {
+	s.where = append(s.where, conds...)
+	return s
+}
+
+func (s *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	s.groupBy = append(s.groupBy, columns...)
+	return s
+}
+
+// Having adds conds which must all match, nil conds are skipped, it may be called repeatedly.
+func (s *SelectBuilder) Having(conds ...Cond) *SelectBuilder {
+	s.having = append(s.having, conds...)
+	return s
+}
+
+// OrderBy adds order terms, e.g. OrderBy("created_at DESC", "id").
+func (s *SelectBuilder) OrderBy(terms ...string) *SelectBuilder {
+	s.orderBy = append(s.orderBy, terms...)
+	return s
+}
+
+// Limit sets the maximum number of rows returned, 0 means no limit.
+func (s *SelectBuilder) Limit(n int64) *SelectBuilder {
+	s.limit = n
+	return s
+}
+
+func (s *SelectBuilder) Offset(n int64) *SelectBuilder {
+	s.offset = n
+	return s
+}
+
+func (s *SelectBuilder) ToSQL() (string, []interface{}, error) {
+	return toSQL(s.d, s)
+}
+
+func (s *SelectBuilder) Query(ctx context.Context, db isql.DBCore) (isql.Rows, error) {
+	return query(ctx, db, s.d, s)
+}
+
+func (s *SelectBuilder) QueryRow(ctx context.Context, db isql.DBCore) isql.Row {
+	return queryRow(ctx, db, s.d, s)
+}
+
+func (s *SelectBuilder) writeTo(w *writer) {
+	if len(s.columns) == 0 {
+		w.fail(ErrNoColumns)
+		return
+	}
+	w.write("SELECT ")
+	if s.distinct {
+		w.write("DISTINCT ")
+	}
+	w.list(s.columns)
+	if s.from != "" {
+		w.write(" FROM ", s.from)
+	}
+	for _, j := range s.joins {
+		w.write(" ", j.kind, " ", j.table)
+		if j.on != nil && !isNilCond(j.on) {
+			w.write(" ON ")
+			j.on.writeCond(w)
+		}
+	}
+	where(w, "WHERE", s.where)
+	if len(s.groupBy) > 0 {
+		w.write(" GROUP BY ")
+		w.list(s.groupBy)
+	}
+	where(w, "HAVING", s.having)
+	orderBy := s.orderBy
+	if len(orderBy) == 0 && w.d.Name() == "sqlserver" && (s.limit > 0 || s.offset > 0) {
+		// OFFSET FETCH requires an ORDER BY
+		orderBy = []string{"(SELECT NULL)"}
+	}
+	if len(orderBy) > 0 {
+		w.write(" ORDER BY ")
+		w.list(orderBy)
+	}
+	s.writeLimit(w)
+}
+
+func (s *SelectBuilder) writeLimit(w *writer) {
+	if s.limit <= 0 && s.offset <= 0 {
+		return
+	}
+	if w.d.Name() == "sqlserver" {
+		w.write(" OFFSET ")
+		w.int(s.offset)
+		w.write(" ROWS")
+		if s.limit > 0 {
+			w.write(" FETCH NEXT ")
+			w.int(s.limit)
+			w.write(" ROWS ONLY")
+		}
+		return
+	}
+	if s.limit > 0 {
+		w.write(" LIMIT ")
+		w.int(s.limit)
+	} else if w.d.Name() == "mysql" {
+		// MySQL and SQLite have no OFFSET without LIMIT
+		w.write(" LIMIT 18446744073709551615")
+	} else if w.d.Name() == "sqlite" {
+		w.write(" LIMIT -1")
+	}
+	if s.offset > 0 {
+		w.write(" OFFSET ")
+		w.int(s.offset)
+	}
+}
@@ -0,0 +1,229 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/0xor1/isql"
+)
+
+// infoSchemaReader reads Postgres, MySQL and SQL Server catalogs, columns, views and foreign keys come from
+// information_schema and indexes, which it does not cover, from each database's own catalog.
+type infoSchemaReader struct {
+	db isql.DBCore
+	d  isql.Dialect
+}
+
+func (r *infoSchemaReader) read(ctx context.Context, schemaName string) (*Schema, error) {
+	if schemaName == "" {
+		var current sql.NullString
+		if err := r.db.QueryRowContext(ctx, r.currentSchemaQuery()).Scan(&current); err != nil {
+			return nil, err
+		}
+		schemaName = current.String
+	}
+	s := &Schema{
+		Name: schemaName,
+	}
+	tables := map[string]*Table{}
+	views := map[string]*View{}
+	if err := r.readTables(ctx, s, tables, views); err != nil {
+		return nil, err
+	}
+	if err := r.readColumns(ctx, s, tables, views); err != nil {
+		return nil, err
+	}
+	if err := r.readIndexes(ctx, s, tables); err != nil {
+		return nil, err
+	}
+	if err := r.readForeignKeys(ctx, s, tables); err != nil {
+		return nil, err
+	}
+	s.Tables = make([]Table, 0, len(tables))
+	for _, t := range tables {
+		s.Tables = append(s.Tables, *t)
+	}
+	s.Views = make([]View, 0, len(views))
+	for _, v := range views {
+		s.Views = append(s.Views, *v)
+	}
+	return s, nil
+}
+
+func (r *infoSchemaReader) currentSchemaQuery() string {
+	switch r.d.Name() {
+	case "mysql":
+		return "SELECT DATABASE()"
+	case "sqlserver":
+		return "SELECT SCHEMA_NAME()"
+	default:
+		return "SELECT current_schema()"
+	}
+}
+
+func (r *infoSchemaReader) readTables(ctx context.Context, s *Schema, tables map[string]*Table, views map[string]*View) error {
+	rows, err := r.db.QueryContext(ctx, "SELECT t.table_name, t.table_type, v.view_definition FROM information_schema.tables t "+
+		"LEFT JOIN information_schema.views v ON v.table_schema = t.table_schema AND v.table_name = t.table_name "+
+		"WHERE t.table_schema = "+r.d.Placeholder(1), s.Name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, tableType string
+		var definition sql.NullString
+		if err := rows.Scan(&name, &tableType, &definition); err != nil {
+			return err
+		}
+		if tableType == "VIEW" {
+			views[name] = &View{
+				Name:       name,
+				Definition: definition.String,
+			}
+		} else {
+			tables[name] = &Table{
+				Name: name,
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (r *infoSchemaReader) readColumns(ctx context.Context, s *Schema, tables map[string]*Table, views map[string]*View) error {
+	// Postgres data_type is the SQL standard name, e.g. character varying, udt_name matches what drivers report
+	typeColumn := "data_type"
+	if r.d.Name() == "postgres" {
+		typeColumn = "udt_name"
+	}
+	rows, err := r.db.QueryContext(ctx, "SELECT table_name, column_name, ordinal_position, "+typeColumn+", is_nullable, column_default, "+
+		"character_maximum_length, numeric_precision, numeric_scale FROM information_schema.columns "+
+		"WHERE table_schema = "+r.d.Placeholder(1)+" ORDER BY table_name, ordinal_position", s.Name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tableName, isNullable string
+		var c Column
+		var databaseType string
+		var columnDefault sql.NullString
+		var length, precision, scale sql.NullInt64
+		if err := rows.Scan(&tableName, &c.Name, &c.Position, &databaseType, &isNullable, &columnDefault, &length, &precision, &scale); err != nil {
+			return err
+		}
+		c.Type = columnTypeInfo(databaseType, nullInt64(length), nullInt64(precision), nullInt64(scale), isNullable == "YES")
+		c.Default, c.HasDefault = columnDefault.String, columnDefault.Valid
+		if t, ok := tables[tableName]; ok {
+			t.Columns = append(t.Columns, c)
+		} else if v, ok := views[tableName]; ok {
+			v.Columns = append(v.Columns, c)
+		}
+	}
+	return rows.Err()
+}
+
+func (r *infoSchemaReader) readIndexes(ctx context.Context, s *Schema, tables map[string]*Table) error {
+	var query string
+	switch r.d.Name() {
+	case "mysql":
+		query = "SELECT table_name, index_name, non_unique = 0, index_name = 'PRIMARY', column_name " +
+			"FROM information_schema.statistics WHERE table_schema = ? ORDER BY table_name, index_name, seq_in_index"
+	case "sqlserver":
+		query = "SELECT t.name, i.name, i.is_unique, i.is_primary_key, c.name FROM sys.indexes i " +
+			"JOIN sys.tables t ON t.object_id = i.object_id JOIN sys.schemas s ON s.schema_id = t.schema_id " +
+			"JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id " +
+			"JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id " +
+			"WHERE s.name = @p1 AND i.name IS NOT NULL AND ic.is_included_column = 0 ORDER BY t.name, i.name, ic.key_ordinal"
+	default:
+		query = "SELECT t.relname, i.relname, ix.indisunique, ix.indisprimary, a.attname FROM pg_index ix " +
+			"JOIN pg_class t ON t.oid = ix.indrelid JOIN pg_class i ON i.oid = ix.indexrelid " +
+			"JOIN pg_namespace n ON n.oid = t.relnamespace " +
+			"JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true " +
+			"JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum " +
+			"WHERE n.nspname = $1 ORDER BY t.relname, i.relname, k.ord"
+	}
+	rows, err := r.db.QueryContext(ctx, query, s.Name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tableName, indexName, columnName string
+		var unique, primary bool
+		if err := rows.Scan(&tableName, &indexName, &unique, &primary, &columnName); err != nil {
+			return err
+		}
+		t, ok := tables[tableName]
+		if !ok {
+			continue
+		}
+		if n := len(t.Indexes); n > 0 && t.Indexes[n-1].Name == indexName {
+			t.Indexes[n-1].Columns = append(t.Indexes[n-1].Columns, columnName)
+			continue
+		}
+		t.Indexes = append(t.Indexes, Index{
+			Name:    indexName,
+			Columns: []string{columnName},
+			Unique:  unique,
+			Primary: primary,
+		})
+	}
+	return rows.Err()
+}
+
+func (r *infoSchemaReader) readForeignKeys(ctx context.Context, s *Schema, tables map[string]*Table) error {
+	var query string
+	if r.d.Name() == "mysql" {
+		query = "SELECT kcu.table_name, kcu.constraint_name, kcu.column_name, rc.update_rule, rc.delete_rule, " +
+			"kcu.referenced_table_schema, kcu.referenced_table_name, kcu.referenced_column_name " +
+			"FROM information_schema.key_column_usage kcu JOIN information_schema.referential_constraints rc " +
+			"ON rc.constraint_schema = kcu.constraint_schema AND rc.constraint_name = kcu.constraint_name AND rc.table_name = kcu.table_name " +
+			"WHERE kcu.table_schema = ? AND kcu.referenced_table_name IS NOT NULL " +
+			"ORDER BY kcu.table_name, kcu.constraint_name, kcu.ordinal_position"
+	} else {
+		query = "SELECT kcu.table_name, kcu.constraint_name, kcu.column_name, rc.update_rule, rc.delete_rule, " +
+			"ref.table_schema, ref.table_name, ref.column_name FROM information_schema.referential_constraints rc " +
+			"JOIN information_schema.key_column_usage kcu ON kcu.constraint_schema = rc.constraint_schema AND kcu.constraint_name = rc.constraint_name " +
+			"JOIN information_schema.key_column_usage ref ON ref.constraint_schema = rc.unique_constraint_schema " +
+			"AND ref.constraint_name = rc.unique_constraint_name AND ref.ordinal_position = kcu.position_in_unique_constraint " +
+			"WHERE kcu.table_schema = " + r.d.Placeholder(1) + " ORDER BY kcu.table_name, kcu.constraint_name, kcu.ordinal_position"
+	}
+	rows, err := r.db.QueryContext(ctx, query, s.Name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tableName, name, column, onUpdate, onDelete, refSchema, refTable, refColumn string
+		if err := rows.Scan(&tableName, &name, &column, &onUpdate, &onDelete, &refSchema, &refTable, &refColumn); err != nil {
+			return err
+		}
+		t, ok := tables[tableName]
+		if !ok {
+			continue
+		}
+		if n := len(t.ForeignKeys); n > 0 && t.ForeignKeys[n-1].Name == name {
+			fk := &t.ForeignKeys[n-1]
+			fk.Columns = append(fk.Columns, column)
+			fk.RefColumns = append(fk.RefColumns, refColumn)
+			continue
+		}
+		t.ForeignKeys = append(t.ForeignKeys, ForeignKey{
+			Name:       name,
+			Columns:    []string{column},
+			RefSchema:  refSchema,
+			RefTable:   refTable,
+			RefColumns: []string{refColumn},
+			OnUpdate:   onUpdate,
+			OnDelete:   onDelete,
+		})
+	}
+	return rows.Err()
+}
+
+func nullInt64(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
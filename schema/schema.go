@@ -0,0 +1,209 @@
+// Package schema reads typed descriptions of tables, columns, indexes, foreign keys and views from a database's
+// catalog, information_schema on Postgres, MySQL and SQL Server and sqlite_master and PRAGMAs on SQLite.
+package schema
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/0xor1/isql"
+)
+
+type Schema struct {
+	Name   string
+	Tables []Table
+	Views  []View
+}
+
+// Table returns the table called name, or nil if there is none.
+func (s *Schema) Table(name string) *Table {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i]
+		}
+	}
+	return nil
+}
+
+type Table struct {
+	Name        string
+	Columns     []Column
+	PrimaryKey  []string
+	Indexes     []Index
+	ForeignKeys []ForeignKey
+}
+
+// Column returns the column called name, or nil if there is none.
+func (t *Table) Column(name string) *Column {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+type Column struct {
+	Name string
+	// Position is the 1 based ordinal position of the column in its table.
+	Position int
+	// Type uses the same model as isql.ColumnType so introspected columns can be compared with query results,
+	// DatabaseType is upper case without any length or precision, e.g. VARCHAR or INT4 on Postgres. ScanType is
+	// not known from the catalog and is left nil.
+	Type       isql.ColumnTypeInfo
+	Default    string
+	HasDefault bool
+}
+
+// ColumnType returns the column as an isql.ColumnType.
+func (c Column) ColumnType() isql.ColumnType {
+	return &columnType{
+		c: c,
+	}
+}
+
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+	Primary bool
+}
+
+type ForeignKey struct {
+	// Name is empty on SQLite where foreign keys are not named.
+	Name       string
+	Columns    []string
+	RefSchema  string
+	RefTable   string
+	RefColumns []string
+	OnUpdate   string
+	OnDelete   string
+}
+
+type View struct {
+	Name       string
+	Definition string
+	Columns    []Column
+}
+
+// Read describes every table and view in schemaName, an empty schemaName reads the current schema, e.g. the
+// current database on MySQL or main on SQLite.
+func Read(ctx context.Context, db isql.DBCore, d isql.Dialect, schemaName string) (*Schema, error) {
+	var r reader
+	switch d.Name() {
+	case "postgres", "mysql", "sqlserver":
+		r = &infoSchemaReader{
+			db: db,
+			d:  d,
+		}
+	case "sqlite":
+		r = &sqliteReader{
+			db: db,
+			d:  d,
+		}
+	default:
+		return nil, fmt.Errorf("schema: unsupported dialect %s", d.Name())
+	}
+	s, err := r.read(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(s.Tables, func(i, j int) bool {
+		return s.Tables[i].Name < s.Tables[j].Name
+	})
+	sort.Slice(s.Views, func(i, j int) bool {
+		return s.Views[i].Name < s.Views[j].Name
+	})
+	for i := range s.Tables {
+		t := &s.Tables[i]
+		for _, idx := range t.Indexes {
+			if idx.Primary {
+				t.PrimaryKey = idx.Columns
+			}
+		}
+	}
+	return s, nil
+}
+
+// ReadTable describes the table called name in schemaName, it returns an error if there is no such table.
+func ReadTable(ctx context.Context, db isql.DBCore, d isql.Dialect, schemaName, name string) (*Table, error) {
+	s, err := Read(ctx, db, d, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	t := s.Table(name)
+	if t == nil {
+		return nil, fmt.Errorf("schema: no table %s in schema %s", name, s.Name)
+	}
+	return t, nil
+}
+
+type reader interface {
+	read(ctx context.Context, schemaName string) (*Schema, error)
+}
+
+// columnTypeInfo builds the type of a column from its catalog description, length is only reported for
+// character and binary types and decimal size only for DECIMAL and NUMERIC, as drivers do.
+func columnTypeInfo(databaseType string, length, precision, scale *int64, nullable bool) isql.ColumnTypeInfo {
+	info := isql.ColumnTypeInfo{
+		DatabaseType: strings.ToUpper(databaseType),
+		Nullable:     nullable,
+		HasNullable:  true,
+	}
+	if length != nil && isLengthType(info.DatabaseType) {
+		info.Length, info.HasLength = *length, true
+	}
+	if precision != nil && isDecimalType(info.DatabaseType) {
+		info.Precision, info.HasDecimalSize = *precision, true
+		if scale != nil {
+			info.Scale = *scale
+		}
+	}
+	return info
+}
+
+func isLengthType(databaseType string) bool {
+	return strings.Contains(databaseType, "CHAR") ||
+		strings.Contains(databaseType, "BINARY") ||
+		strings.Contains(databaseType, "TEXT") ||
+		strings.Contains(databaseType, "BLOB") ||
+		databaseType == "BYTEA"
+}
+
+func isDecimalType(databaseType string) bool {
+	return databaseType == "DECIMAL" || databaseType == "NUMERIC"
+}
+
+type columnType struct {
+	c Column
+}
+
+func (ct *columnType) DatabaseTypeName() string {
+	return ct.c.Type.DatabaseType
+}
+
+func (ct *columnType) DecimalSize() (precision, scale int64, ok bool) {
+	return ct.c.Type.Precision, ct.c.Type.Scale, ct.c.Type.HasDecimalSize
+}
+
+func (ct *columnType) Length() (length int64, ok bool) {
+	return ct.c.Type.Length, ct.c.Type.HasLength
+}
+
+func (ct *columnType) Name() string {
+	return ct.c.Name
+}
+
+func (ct *columnType) Nullable() (nullable, ok bool) {
+	return ct.c.Type.Nullable, ct.c.Type.HasNullable
+}
+
+func (ct *columnType) ScanType() reflect.Type {
+	if ct.c.Type.ScanType == nil {
+		return reflect.TypeOf((*interface{})(nil)).Elem()
+	}
+	return ct.c.Type.ScanType
+}
@@ -0,0 +1,180 @@
+package schema_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/isqltest"
+	"github.com/0xor1/isql/schema"
+)
+
+func open(t *testing.T, name string) (isqltest.Fake, isql.DB) {
+	t.Helper()
+	f := isqltest.NewFake(name)
+	t.Cleanup(f.Close)
+	db, err := isql.NewOpener().Open(isqltest.DriverName, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return f, db
+}
+
+func TestReadSQLite(t *testing.T) {
+	f, db := open(t, "schema_sqlite")
+	f.On(isqltest.Regex(`FROM "main".sqlite_master`)).WillReturnRows([]string{"name", "type", "sql"},
+		[]interface{}{"users", "table", "CREATE TABLE users"},
+		[]interface{}{"posts", "table", "CREATE TABLE posts"},
+		[]interface{}{"names", "view", "CREATE VIEW names AS SELECT name FROM users"})
+	tableInfo := []string{"cid", "name", "type", "notnull", "dflt_value", "pk"}
+	f.On(isqltest.Exact(`PRAGMA "main".table_info("users")`)).WillReturnRows(tableInfo,
+		[]interface{}{int64(0), "id", "INTEGER", int64(1), nil, int64(1)},
+		[]interface{}{int64(1), "name", "varchar(255)", int64(0), "'x'", int64(0)},
+		[]interface{}{int64(2), "amount", "DECIMAL(10, 2)", int64(0), nil, int64(0)})
+	f.On(isqltest.Exact(`PRAGMA "main".table_info("posts")`)).WillReturnRows(tableInfo,
+		[]interface{}{int64(0), "user_id", "INTEGER", int64(1), nil, int64(1)},
+		[]interface{}{int64(1), "seq", "INTEGER", int64(1), nil, int64(2)})
+	f.On(isqltest.Exact(`PRAGMA "main".table_info("names")`)).WillReturnRows(tableInfo,
+		[]interface{}{int64(0), "name", "", int64(0), nil, int64(0)})
+	f.On(isqltest.Exact(`PRAGMA "main".index_list("users")`)).WillReturnRows([]string{"seq", "name", "unique", "origin", "partial"},
+		[]interface{}{int64(0), "users_name", int64(1), "c", int64(0)})
+	f.On(isqltest.Exact(`PRAGMA "main".index_list("posts")`)).WillReturnRows([]string{"seq", "name", "unique", "origin", "partial"},
+		[]interface{}{int64(0), "sqlite_autoindex_posts_1", int64(1), "pk", int64(0)})
+	f.On(isqltest.Exact(`PRAGMA "main".index_info("users_name")`)).WillReturnRows([]string{"seqno", "cid", "name"},
+		[]interface{}{int64(0), int64(1), "name"})
+	f.On(isqltest.Exact(`PRAGMA "main".index_info("sqlite_autoindex_posts_1")`)).WillReturnRows([]string{"seqno", "cid", "name"},
+		[]interface{}{int64(0), int64(0), "user_id"}, []interface{}{int64(1), int64(1), "seq"})
+	fkList := []string{"id", "seq", "table", "from", "to", "on_update", "on_delete", "match"}
+	f.On(isqltest.Exact(`PRAGMA "main".foreign_key_list("users")`)).WillReturnRows(fkList)
+	f.On(isqltest.Exact(`PRAGMA "main".foreign_key_list("posts")`)).WillReturnRows(fkList,
+		[]interface{}{int64(0), int64(0), "users", "user_id", "id", "NO ACTION", "CASCADE", "NONE"})
+
+	s, err := schema.Read(context.Background(), db, isql.SQLite, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &schema.Schema{
+		Name: "main",
+		Tables: []schema.Table{
+			{
+				Name: "posts",
+				Columns: []schema.Column{
+					{Name: "user_id", Position: 1, Type: isql.ColumnTypeInfo{DatabaseType: "INTEGER", HasNullable: true}},
+					{Name: "seq", Position: 2, Type: isql.ColumnTypeInfo{DatabaseType: "INTEGER", HasNullable: true}},
+				},
+				PrimaryKey: []string{"user_id", "seq"},
+				Indexes:    []schema.Index{{Name: "sqlite_autoindex_posts_1", Columns: []string{"user_id", "seq"}, Unique: true, Primary: true}},
+				ForeignKeys: []schema.ForeignKey{
+					{Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}, OnUpdate: "NO ACTION", OnDelete: "CASCADE"},
+				},
+			},
+			{
+				Name: "users",
+				Columns: []schema.Column{
+					{Name: "id", Position: 1, Type: isql.ColumnTypeInfo{DatabaseType: "INTEGER", HasNullable: true}},
+					{Name: "name", Position: 2, Type: isql.ColumnTypeInfo{DatabaseType: "VARCHAR", Length: 255, HasLength: true, Nullable: true, HasNullable: true},
+						Default: "'x'", HasDefault: true},
+					{Name: "amount", Position: 3, Type: isql.ColumnTypeInfo{DatabaseType: "DECIMAL", Precision: 10, Scale: 2, HasDecimalSize: true, Nullable: true, HasNullable: true}},
+				},
+				// the rowid alias has no index of its own
+				PrimaryKey: []string{"id"},
+				Indexes: []schema.Index{
+					{Name: "users_name", Columns: []string{"name"}, Unique: true},
+					{Columns: []string{"id"}, Unique: true, Primary: true},
+				},
+			},
+		},
+		Views: []schema.View{
+			{
+				Name:       "names",
+				Definition: "CREATE VIEW names AS SELECT name FROM users",
+				Columns:    []schema.Column{{Name: "name", Position: 1, Type: isql.ColumnTypeInfo{Nullable: true, HasNullable: true}}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(s, expected) {
+		t.Fatalf("read\n%+v\nexpected\n%+v", s, expected)
+	}
+
+	ct := s.Table("users").Column("amount").ColumnType()
+	if p, sc, ok := ct.DecimalSize(); !ok || p != 10 || sc != 2 || ct.Name() != "amount" || ct.DatabaseTypeName() != "DECIMAL" {
+		t.Fatalf("column type %s %s(%d, %d)", ct.Name(), ct.DatabaseTypeName(), p, sc)
+	}
+	if s.Table("missing") != nil || s.Table("users").Column("missing") != nil {
+		t.Fatal("expected nil for missing tables and columns")
+	}
+}
+
+func TestReadPostgres(t *testing.T) {
+	f, db := open(t, "schema_postgres")
+	f.On(isqltest.Exact("SELECT current_schema()")).WillReturnRows([]string{"current_schema"}, []interface{}{"public"})
+	f.On(isqltest.Regex(`^SELECT t.table_name, t.table_type`)).WillReturnRows([]string{"table_name", "table_type", "view_definition"},
+		[]interface{}{"users", "BASE TABLE", nil},
+		[]interface{}{"orders", "BASE TABLE", nil},
+		[]interface{}{"active", "VIEW", " SELECT id FROM users"})
+	f.On(isqltest.Regex(`FROM information_schema.columns`)).WillReturnRows([]string{"table_name", "column_name", "ordinal_position", "udt_name",
+		"is_nullable", "column_default", "character_maximum_length", "numeric_precision", "numeric_scale"},
+		[]interface{}{"active", "id", int64(1), "int8", "YES", nil, nil, int64(64), int64(0)},
+		[]interface{}{"orders", "id", int64(1), "int8", "NO", "nextval('orders_id_seq'::regclass)", nil, int64(64), int64(0)},
+		[]interface{}{"orders", "user_id", int64(2), "int8", "NO", nil, nil, int64(64), int64(0)},
+		[]interface{}{"orders", "total", int64(3), "numeric", "NO", nil, nil, int64(12), int64(2)},
+		[]interface{}{"users", "id", int64(1), "int8", "NO", nil, nil, int64(64), int64(0)},
+		[]interface{}{"users", "email", int64(2), "varchar", "NO", nil, int64(320), nil, nil})
+	f.On(isqltest.Regex(`FROM pg_index`)).WillReturnRows([]string{"relname", "relname", "indisunique", "indisprimary", "attname"},
+		[]interface{}{"orders", "orders_pkey", true, true, "id"},
+		[]interface{}{"users", "users_email_key", true, false, "email"},
+		[]interface{}{"users", "users_pkey", true, true, "id"})
+	f.On(isqltest.Regex(`FROM information_schema.referential_constraints`)).WillReturnRows([]string{"table_name", "constraint_name", "column_name",
+		"update_rule", "delete_rule", "table_schema", "table_name", "column_name"},
+		[]interface{}{"orders", "orders_user_id_fkey", "user_id", "NO ACTION", "CASCADE", "public", "users", "id"})
+
+	s, err := schema.Read(context.Background(), db, isql.Postgres, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "public" || len(s.Tables) != 2 || s.Tables[0].Name != "orders" || len(s.Views) != 1 || len(s.Views[0].Columns) != 1 {
+		t.Fatalf("read %+v", s)
+	}
+	orders, users := s.Table("orders"), s.Table("users")
+	if id := orders.Column("id"); !id.HasDefault || id.Type.DatabaseType != "INT8" || id.Type.HasDecimalSize || id.Type.Nullable {
+		t.Fatalf("orders.id %+v", id)
+	}
+	if total := orders.Column("total").Type; !total.HasDecimalSize || total.Precision != 12 || total.Scale != 2 {
+		t.Fatalf("orders.total %+v", total)
+	}
+	if email := users.Column("email").Type; !email.HasLength || email.Length != 320 {
+		t.Fatalf("users.email %+v", email)
+	}
+	if !reflect.DeepEqual(orders.PrimaryKey, []string{"id"}) || !reflect.DeepEqual(users.PrimaryKey, []string{"id"}) || len(users.Indexes) != 2 {
+		t.Fatalf("indexes %+v %+v", orders.Indexes, users.Indexes)
+	}
+	expected := []schema.ForeignKey{{Name: "orders_user_id_fkey", Columns: []string{"user_id"}, RefSchema: "public", RefTable: "users",
+		RefColumns: []string{"id"}, OnUpdate: "NO ACTION", OnDelete: "CASCADE"}}
+	if !reflect.DeepEqual(orders.ForeignKeys, expected) {
+		t.Fatalf("foreign keys %+v, expected %+v", orders.ForeignKeys, expected)
+	}
+}
+
+type unknownDialect struct {
+	isql.Dialect
+}
+
+func (unknownDialect) Name() string {
+	return "unknown"
+}
+
+func TestReadErrors(t *testing.T) {
+	f, db := open(t, "schema_errors")
+	f.On(isqltest.Regex(`sqlite_master`)).WillReturnRows([]string{"name", "type", "sql"})
+	ctx := context.Background()
+	if _, err := schema.ReadTable(ctx, db, isql.SQLite, "", "users"); err == nil || err.Error() != "schema: no table users in schema main" {
+		t.Fatalf("error %v, expected the missing table to be reported", err)
+	}
+	if _, err := schema.Read(ctx, db, unknownDialect{isql.Postgres}, ""); err == nil || err.Error() != "schema: unsupported dialect unknown" {
+		t.Fatalf("error %v, expected the dialect to be unsupported", err)
+	}
+}
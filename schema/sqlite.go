@@ -0,0 +1,255 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/0xor1/isql"
+)
+
+// sqliteReader reads sqlite_master and the table_info, index_list, index_info and foreign_key_list PRAGMAs.
+type sqliteReader struct {
+	db isql.DBCore
+	d  isql.Dialect
+}
+
+func (r *sqliteReader) read(ctx context.Context, schemaName string) (*Schema, error) {
+	if schemaName == "" {
+		schemaName = "main"
+	}
+	s := &Schema{
+		Name: schemaName,
+	}
+	prefix := r.d.QuoteIdent(schemaName) + "."
+	rows, err := r.db.QueryContext(ctx, "SELECT name, type, sql FROM "+prefix+"sqlite_master "+
+		"WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	type object struct {
+		name, objectType string
+		definition       sql.NullString
+	}
+	var objects []object
+	for rows.Next() {
+		o := object{}
+		if err := rows.Scan(&o.name, &o.objectType, &o.definition); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		objects = append(objects, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, o := range objects {
+		columns, err := r.columns(ctx, prefix, o.name)
+		if err != nil {
+			return nil, err
+		}
+		if o.objectType == "view" {
+			s.Views = append(s.Views, View{
+				Name:       o.name,
+				Definition: o.definition.String,
+				Columns:    columns,
+			})
+			continue
+		}
+		t := Table{
+			Name:    o.name,
+			Columns: columns,
+		}
+		if t.Indexes, err = r.indexes(ctx, prefix, o.name); err != nil {
+			return nil, err
+		}
+		if t.ForeignKeys, err = r.foreignKeys(ctx, prefix, o.name); err != nil {
+			return nil, err
+		}
+		s.Tables = append(s.Tables, t)
+	}
+	return s, nil
+}
+
+func (r *sqliteReader) columns(ctx context.Context, prefix, table string) ([]Column, error) {
+	rows, err := r.pragma(ctx, prefix+"table_info("+r.d.QuoteIdent(table)+")")
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]Column, 0, len(rows))
+	for _, row := range rows {
+		c := Column{
+			Name:     asString(row["name"]),
+			Position: int(asInt64(row["cid"])) + 1,
+		}
+		databaseType, length, precision, scale := parseDeclaredType(asString(row["type"]))
+		c.Type = columnTypeInfo(databaseType, length, precision, scale, asInt64(row["notnull"]) == 0)
+		if row["dflt_value"] != nil {
+			c.Default, c.HasDefault = asString(row["dflt_value"]), true
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+func (r *sqliteReader) indexes(ctx context.Context, prefix, table string) ([]Index, error) {
+	list, err := r.pragma(ctx, prefix+"index_list("+r.d.QuoteIdent(table)+")")
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([]Index, 0, len(list))
+	for _, row := range list {
+		idx := Index{
+			Name:    asString(row["name"]),
+			Unique:  asInt64(row["unique"]) == 1,
+			Primary: asString(row["origin"]) == "pk",
+		}
+		info, err := r.pragma(ctx, prefix+"index_info("+r.d.QuoteIdent(idx.Name)+")")
+		if err != nil {
+			return nil, err
+		}
+		for _, col := range info {
+			idx.Columns = append(idx.Columns, asString(col["name"]))
+		}
+		indexes = append(indexes, idx)
+	}
+	// a rowid alias INTEGER PRIMARY KEY has no index, describe it as one so Table.PrimaryKey is set
+	hasPrimary := false
+	for _, idx := range indexes {
+		hasPrimary = hasPrimary || idx.Primary
+	}
+	if !hasPrimary {
+		info, err := r.pragma(ctx, prefix+"table_info("+r.d.QuoteIdent(table)+")")
+		if err != nil {
+			return nil, err
+		}
+		pk := map[int64]string{}
+		for _, row := range info {
+			if n := asInt64(row["pk"]); n > 0 {
+				pk[n] = asString(row["name"])
+			}
+		}
+		if len(pk) > 0 {
+			idx := Index{
+				Unique:  true,
+				Primary: true,
+			}
+			for i := int64(1); i <= int64(len(pk)); i++ {
+				idx.Columns = append(idx.Columns, pk[i])
+			}
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes, nil
+}
+
+func (r *sqliteReader) foreignKeys(ctx context.Context, prefix, table string) ([]ForeignKey, error) {
+	list, err := r.pragma(ctx, prefix+"foreign_key_list("+r.d.QuoteIdent(table)+")")
+	if err != nil {
+		return nil, err
+	}
+	var fks []ForeignKey
+	lastID := int64(-1)
+	for _, row := range list {
+		id := asInt64(row["id"])
+		if id != lastID {
+			lastID = id
+			fks = append(fks, ForeignKey{
+				RefTable: asString(row["table"]),
+				OnUpdate: asString(row["on_update"]),
+				OnDelete: asString(row["on_delete"]),
+			})
+		}
+		fk := &fks[len(fks)-1]
+		fk.Columns = append(fk.Columns, asString(row["from"]))
+		fk.RefColumns = append(fk.RefColumns, asString(row["to"]))
+	}
+	return fks, nil
+}
+
+// pragma reads every row of a PRAGMA by column name, as the columns returned vary between SQLite versions.
+func (r *sqliteReader) pragma(ctx context.Context, pragma string) ([]map[string]interface{}, error) {
+	rows, err := r.db.QueryContext(ctx, "PRAGMA "+pragma)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var res []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			row[column] = values[i]
+		}
+		res = append(res, row)
+	}
+	return res, rows.Err()
+}
+
+// parseDeclaredType splits a declared type such as VARCHAR(255) or DECIMAL(10, 2) into its name and parameters.
+func parseDeclaredType(declared string) (databaseType string, length, precision, scale *int64) {
+	declared = strings.TrimSpace(declared)
+	open := strings.IndexByte(declared, '(')
+	if open < 0 || !strings.HasSuffix(declared, ")") {
+		return declared, nil, nil, nil
+	}
+	databaseType = strings.TrimSpace(declared[:open])
+	params := strings.Split(declared[open+1:len(declared)-1], ",")
+	if n, err := strconv.ParseInt(strings.TrimSpace(params[0]), 10, 64); err == nil {
+		length, precision = &n, &n
+	}
+	if len(params) > 1 {
+		if n, err := strconv.ParseInt(strings.TrimSpace(params[1]), 10, 64); err == nil {
+			scale = &n
+		}
+	}
+	return databaseType, length, precision, scale
+}
+
+func asString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	default:
+		return strconv.FormatInt(asInt64(v), 10)
+	}
+}
+
+func asInt64(v interface{}) int64 {
+	switch v := v.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case []byte:
+		n, _ := strconv.ParseInt(string(v), 10, 64)
+		return n
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,13 @@
+#!/bin/sh
+# Regenerates the mocks in package mock and fails if they differ from the committed ones, run it in CI so
+# interface changes can't be merged with stale mocks.
+set -e
+cd "$(dirname "$0")/.."
+go generate .
+stale=$(git status --porcelain -- mock)
+if [ -n "$stale" ]; then
+	echo "mocks are stale, run go generate and commit the changes:" >&2
+	echo "$stale" >&2
+	git --no-pager diff -- mock >&2
+	exit 1
+fi
@@ -0,0 +1,479 @@
+package isql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoShardKey matches every *NoShardKeyError with errors.Is.
+var ErrNoShardKey = errors.New("isql: no shard key")
+
+// NoShardKeyError is returned when a query must be routed to one shard but its context carries no shard key.
+type NoShardKeyError struct {
+	Query string
+}
+
+func (e *NoShardKeyError) Error() string {
+	return "isql: query needs a shard key but the context has none, set one with WithShardKey or use ScatterQueryContext"
+}
+
+func (e *NoShardKeyError) Is(target error) bool {
+	return target == ErrNoShardKey
+}
+
+type shardKeyKey struct{}
+
+// WithShardKey returns a copy of ctx carrying key, which a ShardSet uses to pick the shard a query runs on.
+func WithShardKey(ctx context.Context, key interface{}) context.Context {
+	return context.WithValue(ctx, shardKeyKey{}, key)
+}
+
+// ShardKeyFromContext returns the shard key set on ctx with WithShardKey, ok is false if there is none.
+func ShardKeyFromContext(ctx context.Context) (key interface{}, ok bool) {
+	key = ctx.Value(shardKeyKey{})
+	return key, key != nil
+}
+
+// ShardStrategy maps a shard key to the index of one of shards.
+type ShardStrategy interface {
+	Shard(key interface{}, shards int) (int, error)
+}
+
+// ShardStrategyFunc adapts a function to a ShardStrategy.
+type ShardStrategyFunc func(key interface{}, shards int) (int, error)
+
+func (f ShardStrategyFunc) Shard(key interface{}, shards int) (int, error) {
+	return f(key, shards)
+}
+
+// NewHashStrategy returns a ShardStrategy which hashes keys, strings, []byte and integers, with FNV-1a and
+// spreads them over the shards with jump consistent hashing, so growing from n to n+1 shards only moves 1/(n+1)
+// of the keys, all of them to the new shard. Integers hash the same whatever their Go type.
+func NewHashStrategy() ShardStrategy {
+	return ShardStrategyFunc(hashShard)
+}
+
+func hashShard(key interface{}, shards int) (int, error) {
+	h := fnv.New64a()
+	switch k := key.(type) {
+	case string:
+		h.Write([]byte(k))
+	case []byte:
+		h.Write(k)
+	default:
+		i, err := shardInt(key)
+		if err != nil {
+			return 0, err
+		}
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(i))
+		h.Write(b[:])
+	}
+	return jumpHash(h.Sum64(), shards), nil
+}
+
+// jumpHash is the jump consistent hash of Lamping and Veach.
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// NewRangeStrategy returns a ShardStrategy for integer keys, bounds must be ascending and there must be one more
+// shard than bounds. Shard 0 holds keys < bounds[0], shard i keys >= bounds[i-1] and < bounds[i] and the last shard
+// keys >= the last bound.
+func NewRangeStrategy(bounds ...int64) ShardStrategy {
+	bounds = append([]int64{}, bounds...)
+	return ShardStrategyFunc(func(key interface{}, shards int) (int, error) {
+		if shards != len(bounds)+1 {
+			return 0, fmt.Errorf("isql: range shard strategy with %d bounds needs %d shards, have %d", len(bounds), len(bounds)+1, shards)
+		}
+		if !sort.SliceIsSorted(bounds, func(i, j int) bool { return bounds[i] < bounds[j] }) {
+			return 0, fmt.Errorf("isql: range shard strategy bounds are not ascending")
+		}
+		i, err := shardInt(key)
+		if err != nil {
+			return 0, err
+		}
+		return sort.Search(len(bounds), func(idx int) bool { return i < bounds[idx] }), nil
+	})
+}
+
+// NewLookupStrategy returns a ShardStrategy which looks keys up in table, integer keys match whatever their Go
+// type, e.g. int(1) matches int64(1). Keys not in table are passed to fallback, or are an error if it is nil.
+func NewLookupStrategy(table map[interface{}]int, fallback ShardStrategy) ShardStrategy {
+	normalized := make(map[interface{}]int, len(table))
+	for k, v := range table {
+		normalized[lookupKey(k)] = v
+	}
+	return ShardStrategyFunc(func(key interface{}, shards int) (int, error) {
+		if i, ok := normalized[lookupKey(key)]; ok {
+			return i, nil
+		}
+		if fallback != nil {
+			return fallback.Shard(key, shards)
+		}
+		return 0, fmt.Errorf("isql: shard key %v is not in the lookup table", key)
+	})
+}
+
+func lookupKey(key interface{}) interface{} {
+	if b, ok := key.([]byte); ok {
+		return string(b)
+	}
+	if i, err := shardInt(key); err == nil {
+		return i
+	}
+	return key
+}
+
+func shardInt(key interface{}) (int64, error) {
+	switch k := key.(type) {
+	case int:
+		return int64(k), nil
+	case int8:
+		return int64(k), nil
+	case int16:
+		return int64(k), nil
+	case int32:
+		return int64(k), nil
+	case int64:
+		return k, nil
+	case uint:
+		return shardUint(uint64(k))
+	case uint8:
+		return int64(k), nil
+	case uint16:
+		return int64(k), nil
+	case uint32:
+		return int64(k), nil
+	case uint64:
+		return shardUint(k)
+	}
+	return 0, fmt.Errorf("isql: unsupported shard key type %T", key)
+}
+
+func shardUint(k uint64) (int64, error) {
+	if k > math.MaxInt64 {
+		return 0, fmt.Errorf("isql: shard key %d overflows int64", k)
+	}
+	return int64(k), nil
+}
+
+// ScatterOptions are how ScatterQueryContext merges the rows of every shard.
+type ScatterOptions struct {
+	// OrderBy if set merges the shards' rows in this order, the query must return them in the same order from
+	// every shard, e.g. with a matching ORDER BY, and NullsLast must match where the database sorts NULLs.
+	// Values are compared as numbers, times or bytes, so text columns must have a binary collation, otherwise
+	// e.g. a case insensitive collation orders rows differently than the merge. Without OrderBy rows are
+	// returned shard by shard.
+	OrderBy []PageOrder
+	// Limit if > 0 caps the rows returned, at most Limit rows are read from each shard, so the query should have
+	// the same LIMIT.
+	Limit int
+}
+
+type ShardSet interface {
+	DBCore
+	Shards() []ReplicaSet
+	// Shard returns the shard key is routed to.
+	Shard(key interface{}) (ReplicaSet, error)
+	// ScatterQueryContext runs query on every shard concurrently and merges their rows, the first error cancels
+	// the queries still running. The merged rows are read into memory before they are returned.
+	ScatterQueryContext(ctx context.Context, opts ScatterOptions, query string, args ...interface{}) (Rows, error)
+}
+
+// NewShardSet returns a ShardSet which routes ExecContext, QueryContext and QueryRowContext to the shard strategy
+// picks for the key set on their context with WithShardKey, they return a *NoShardKeyError if there is none.
+func NewShardSet(strategy ShardStrategy, shards ...ReplicaSet) ShardSet {
+	return &shardSet{
+		strategy: strategy,
+		shards:   shards,
+	}
+}
+
+type shardSet struct {
+	strategy ShardStrategy
+	shards   []ReplicaSet
+}
+
+func (s *shardSet) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	shard, err := s.route(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return shard.ExecContext(ctx, query, args...)
+}
+
+func (s *shardSet) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	shard, err := s.route(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return shard.QueryContext(ctx, query, args...)
+}
+
+func (s *shardSet) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	shard, err := s.route(ctx, query)
+	if err != nil {
+		return &errRow{err: err}
+	}
+	return shard.QueryRowContext(ctx, query, args...)
+}
+
+func (s *shardSet) Shards() []ReplicaSet {
+	return s.shards
+}
+
+func (s *shardSet) Shard(key interface{}) (ReplicaSet, error) {
+	if len(s.shards) == 0 {
+		return nil, errors.New("isql: shard set has no shards")
+	}
+	i, err := s.strategy.Shard(key, len(s.shards))
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 || i >= len(s.shards) {
+		return nil, fmt.Errorf("isql: shard strategy returned shard %d of %d", i, len(s.shards))
+	}
+	return s.shards[i], nil
+}
+
+func (s *shardSet) route(ctx context.Context, query string) (ReplicaSet, error) {
+	key, ok := ShardKeyFromContext(ctx)
+	if !ok {
+		return nil, &NoShardKeyError{Query: query}
+	}
+	return s.Shard(key)
+}
+
+func (s *shardSet) ScatterQueryContext(ctx context.Context, opts ScatterOptions, query string, args ...interface{}) (Rows, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sets := make([]ResultSet, len(s.shards))
+	errs := make([]error, len(s.shards))
+	wg := sync.WaitGroup{}
+	for i, shard := range s.shards {
+		wg.Add(1)
+		go func(i int, shard ReplicaSet) {
+			defer wg.Done()
+			sets[i], errs[i] = scatterShard(ctx, shard, opts.Limit, query, args)
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i, shard)
+	}
+	wg.Wait()
+	// the first error may have cancelled the other shards, report it rather than their context errors
+	var err error
+	for i, e := range errs {
+		if e != nil && (err == nil || errors.Is(err, context.Canceled) && !errors.Is(e, context.Canceled)) {
+			err = fmt.Errorf("isql: shard %d: %w", i, e)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	merged, err := mergeShardSets(sets, opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewDataRows(merged)
+}
+
+func scatterShard(ctx context.Context, shard ReplicaSet, limit int, query string, args []interface{}) (ResultSet, error) {
+	rows, err := shard.QueryContext(ctx, query, args...)
+	if err != nil {
+		return ResultSet{}, err
+	}
+	defer rows.Close()
+	set, err := readResultSetLimit(rows, limit)
+	if err != nil {
+		return set, err
+	}
+	return set, set.Err
+}
+
+func mergeShardSets(sets []ResultSet, opts ScatterOptions) (ResultSet, error) {
+	merged := ResultSet{}
+	if len(sets) == 0 {
+		return merged, nil
+	}
+	merged.Columns, merged.ColumnTypes = sets[0].Columns, sets[0].ColumnTypes
+	total := 0
+	for i, set := range sets {
+		if len(set.Columns) != len(merged.Columns) {
+			return merged, fmt.Errorf("isql: shard %d returned %d columns, shard 0 returned %d", i, len(set.Columns), len(merged.Columns))
+		}
+		for j, column := range set.Columns {
+			if !strings.EqualFold(column, merged.Columns[j]) {
+				return merged, fmt.Errorf("isql: shard %d returned column %s where shard 0 returned %s", i, column, merged.Columns[j])
+			}
+		}
+		total += len(set.Rows)
+	}
+	if opts.Limit > 0 && total > opts.Limit {
+		total = opts.Limit
+	}
+	merged.Rows = make([][]interface{}, 0, total)
+	if len(opts.OrderBy) == 0 {
+		for _, set := range sets {
+			merged.Rows = append(merged.Rows, set.Rows...)
+		}
+		merged.Rows = merged.Rows[:total]
+		return merged, nil
+	}
+	indexes := make([]int, len(opts.OrderBy))
+	for i, o := range opts.OrderBy {
+		indexes[i] = -1
+		for j, column := range merged.Columns {
+			if strings.EqualFold(column, o.Column) {
+				indexes[i] = j
+			}
+		}
+		if indexes[i] < 0 {
+			return merged, fmt.Errorf("isql: scatter order column %s is not in the query result", o.Column)
+		}
+	}
+	// rows are compared by the values of their order columns converted by each shard's column types, so e.g.
+	// MySQL's text []byte integers compare as numbers
+	keys := make([][][]interface{}, len(sets))
+	for i, set := range sets {
+		keys[i] = make([][]interface{}, len(set.Rows))
+		for j := range set.Rows {
+			keys[i][j] = keyValues(set, j, indexes)
+		}
+	}
+	less := func(a, b []interface{}) (bool, error) {
+		for i, o := range opts.OrderBy {
+			if (a[i] == nil) != (b[i] == nil) {
+				// NULLs are placed whatever the direction
+				return (a[i] == nil) != o.NullsLast, nil
+			}
+			c, err := compareValues(a[i], b[i])
+			if err != nil {
+				return false, fmt.Errorf("isql: scatter order column %s: %w", o.Column, err)
+			}
+			if c != 0 {
+				return (c < 0) != o.Desc, nil
+			}
+		}
+		return false, nil
+	}
+	// shards are few so the next row is found by scanning the head of each, ties go to the lower shard
+	heads := make([]int, len(sets))
+	for len(merged.Rows) < total {
+		next := -1
+		for i, set := range sets {
+			if heads[i] == len(set.Rows) {
+				continue
+			}
+			if next < 0 {
+				next = i
+				continue
+			}
+			isLess, err := less(keys[i][heads[i]], keys[next][heads[next]])
+			if err != nil {
+				return merged, err
+			}
+			if isLess {
+				next = i
+			}
+		}
+		merged.Rows = append(merged.Rows, sets[next].Rows[heads[next]])
+		heads[next]++
+	}
+	return merged, nil
+}
+
+// compareValues compares driver values, NULL before any other value, numbers by value whether int64, uint64 or
+// float64 and strings and []byte by their bytes.
+func compareValues(a, b interface{}) (int, error) {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0, nil
+		case a == nil:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	}
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return compareOrdered(x < y, x > y), nil
+		case uint64:
+			return compareOrdered(x < 0 || uint64(x) < y, x >= 0 && uint64(x) > y), nil
+		case float64:
+			return compareOrdered(float64(x) < y, float64(x) > y), nil
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint64:
+			return compareOrdered(x < y, x > y), nil
+		case int64:
+			return compareOrdered(y >= 0 && x < uint64(y), y < 0 || x > uint64(y)), nil
+		case float64:
+			return compareOrdered(float64(x) < y, float64(x) > y), nil
+		}
+	case float64:
+		switch y := b.(type) {
+		case float64:
+			return compareOrdered(x < y, x > y), nil
+		case int64:
+			return compareOrdered(x < float64(y), x > float64(y)), nil
+		case uint64:
+			return compareOrdered(x < float64(y), x > float64(y)), nil
+		}
+	case string:
+		switch y := b.(type) {
+		case string:
+			return strings.Compare(x, y), nil
+		case []byte:
+			return bytes.Compare([]byte(x), y), nil
+		}
+	case []byte:
+		switch y := b.(type) {
+		case []byte:
+			return bytes.Compare(x, y), nil
+		case string:
+			return bytes.Compare(x, []byte(y)), nil
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			return compareOrdered(!x && y, x && !y), nil
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return compareOrdered(x.Before(y), x.After(y)), nil
+		}
+	}
+	return 0, fmt.Errorf("can't compare %T with %T", a, b)
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
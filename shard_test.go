@@ -0,0 +1,304 @@
+package isql_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0xor1/isql"
+)
+
+// shardDB is a shard which returns set for every query, or err, and records the queries run on it.
+type shardDB struct {
+	set     isql.ResultSet
+	err     error
+	queries []string
+}
+
+func (s *shardDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	s.queries = append(s.queries, query)
+	return isql.NewResult(0, 1), s.err
+}
+
+func (s *shardDB) QueryContext(ctx context.Context, query string, args ...interface{}) (isql.Rows, error) {
+	s.queries = append(s.queries, query)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return isql.NewDataRows(s.set)
+}
+
+func (s *shardDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) isql.Row {
+	s.queries = append(s.queries, query)
+	return isql.NewDataRow(s.set)
+}
+
+func newShardSet(strategy isql.ShardStrategy, shards ...*shardDB) isql.ShardSet {
+	members := make([]isql.ReplicaSet, len(shards))
+	for i, shard := range shards {
+		members[i] = isql.NewReplicaSetFromMembers(shard)
+	}
+	return isql.NewShardSet(strategy, members...)
+}
+
+func TestShardRouting(t *testing.T) {
+	shards := []*shardDB{{}, {}}
+	ss := newShardSet(isql.NewRangeStrategy(100), shards...)
+	ctx := context.Background()
+	var noKey *isql.NoShardKeyError
+	if _, err := ss.ExecContext(ctx, "UPDATE t"); !errors.Is(err, isql.ErrNoShardKey) || !errors.As(err, &noKey) || noKey.Query != "UPDATE t" {
+		t.Fatalf("error %v, expected a shard key to be required", err)
+	}
+	if _, err := ss.QueryContext(ctx, "SELECT a"); !errors.Is(err, isql.ErrNoShardKey) {
+		t.Fatalf("error %v, expected a shard key to be required", err)
+	}
+	if err := ss.QueryRowContext(ctx, "SELECT a").Scan(); !errors.Is(err, isql.ErrNoShardKey) {
+		t.Fatalf("error %v, expected a shard key to be required", err)
+	}
+	if _, ok := isql.ShardKeyFromContext(ctx); ok {
+		t.Fatal("expected no shard key")
+	}
+	tests := []struct {
+		key   interface{}
+		shard int
+		err   string
+	}{
+		{150, 1, ""},
+		{int8(5), 0, ""},
+		{uint64(100), 1, ""},
+		{"a", 0, "isql: unsupported shard key type string"},
+		{uint64(1 << 63), 0, "isql: shard key 9223372036854775808 overflows int64"},
+	}
+	for _, tt := range tests {
+		for _, shard := range shards {
+			shard.queries = nil
+		}
+		query := fmt.Sprintf("UPDATE t WHERE k = %v", tt.key)
+		_, err := ss.ExecContext(isql.WithShardKey(ctx, tt.key), query)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%v: error %v, expected %q", tt.key, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(shards[tt.shard].queries, []string{query}) || len(shards[1-tt.shard].queries) != 0 {
+			t.Errorf("%v: error %v, expected the query to run on shard %d only", tt.key, err, tt.shard)
+		}
+	}
+
+	outOfRange := newShardSet(isql.ShardStrategyFunc(func(key interface{}, shards int) (int, error) { return shards, nil }), shards...)
+	if _, err := outOfRange.Shard(1); err == nil || err.Error() != "isql: shard strategy returned shard 2 of 2" {
+		t.Fatalf("error %v, expected the shard index to be checked", err)
+	}
+	if _, err := newShardSet(isql.NewHashStrategy()).Shard(1); err == nil {
+		t.Fatal("expected an error from a shard set without shards")
+	}
+}
+
+func TestHashStrategy(t *testing.T) {
+	h := isql.NewHashStrategy()
+	want, _ := h.Shard(int64(42), 10)
+	for _, key := range []interface{}{int32(42), uint64(42), uint8(42), 42} {
+		if i, err := h.Shard(key, 10); err != nil || i != want {
+			t.Errorf("%T: shard %d with error %v, expected integers to hash the same whatever their type", key, i, err)
+		}
+	}
+	if a, _ := h.Shard("acme", 10); a != func() int { i, _ := h.Shard([]byte("acme"), 10); return i }() {
+		t.Error("expected strings and []byte to hash the same")
+	}
+	if _, err := h.Shard(1.5, 10); err == nil {
+		t.Error("expected floats to be unsupported")
+	}
+	counts := make([]int, 11)
+	moved := 0
+	for i := 0; i < 11000; i++ {
+		before, _ := h.Shard(i, 10)
+		after, _ := h.Shard(i, 11)
+		counts[after]++
+		if before != after {
+			if after != 10 {
+				t.Fatalf("key %d moved from shard %d to %d, expected keys only to move to the new shard", i, before, after)
+			}
+			moved++
+		}
+	}
+	if moved != counts[10] || moved < 800 || moved > 1200 {
+		t.Fatalf("%d keys moved, expected about 1000", moved)
+	}
+	for i, n := range counts {
+		if n < 800 || n > 1200 {
+			t.Errorf("shard %d has %d keys, expected about 1000", i, n)
+		}
+	}
+}
+
+func TestRangeStrategy(t *testing.T) {
+	tests := []struct {
+		bounds []int64
+		shards int
+		key    interface{}
+		shard  int
+		err    string
+	}{
+		{[]int64{100, 200}, 3, -1, 0, ""},
+		{[]int64{100, 200}, 3, 99, 0, ""},
+		{[]int64{100, 200}, 3, 100, 1, ""},
+		{[]int64{100, 200}, 3, uint32(199), 1, ""},
+		{[]int64{100, 200}, 3, int64(200), 2, ""},
+		{[]int64{100, 200}, 3, int64(1 << 40), 2, ""},
+		{nil, 1, 5, 0, ""},
+		{[]int64{100, 200}, 2, 5, 0, "isql: range shard strategy with 2 bounds needs 3 shards, have 2"},
+		{[]int64{200, 100}, 3, 5, 0, "isql: range shard strategy bounds are not ascending"},
+		{[]int64{100}, 2, "5", 0, "isql: unsupported shard key type string"},
+	}
+	for _, tt := range tests {
+		shard, err := isql.NewRangeStrategy(tt.bounds...).Shard(tt.key, tt.shards)
+		if (err != nil || tt.err != "") && (err == nil || err.Error() != tt.err) || err == nil && shard != tt.shard {
+			t.Errorf("%v in %v: shard %d with error %v, expected %d with %q", tt.key, tt.bounds, shard, err, tt.shard, tt.err)
+		}
+	}
+}
+
+func TestLookupStrategy(t *testing.T) {
+	l := isql.NewLookupStrategy(map[interface{}]int{int64(7): 2, "acme": 1}, nil)
+	tests := []struct {
+		key   interface{}
+		shard int
+		err   bool
+	}{
+		{7, 2, false},
+		{uint8(7), 2, false},
+		{"acme", 1, false},
+		{[]byte("acme"), 1, false},
+		{8, 0, true},
+	}
+	for _, tt := range tests {
+		if shard, err := l.Shard(tt.key, 3); (err != nil) != tt.err || shard != tt.shard {
+			t.Errorf("%v: shard %d with error %v", tt.key, shard, err)
+		}
+	}
+	fallback := isql.NewLookupStrategy(nil, isql.NewRangeStrategy(10))
+	if shard, err := fallback.Shard(15, 2); err != nil || shard != 1 {
+		t.Errorf("shard %d with error %v, expected the fallback to be used", shard, err)
+	}
+}
+
+func TestScatterQuery(t *testing.T) {
+	bigint := []isql.ColumnTypeInfo{{DatabaseType: "VARCHAR"}, {DatabaseType: "BIGINT"}}
+	set := func(types []isql.ColumnTypeInfo, rows ...[]interface{}) isql.ResultSet {
+		return isql.ResultSet{Columns: []string{"id", "n"}, ColumnTypes: types, Rows: rows}
+	}
+	tests := []struct {
+		name string
+		opts isql.ScatterOptions
+		sets []isql.ResultSet
+		ids  string
+	}{
+		{"unordered", isql.ScatterOptions{Limit: 3},
+			[]isql.ResultSet{set(nil, []interface{}{"a", int64(2)}, []interface{}{"b", int64(1)}), set(nil, []interface{}{"c", int64(0)}, []interface{}{"d", int64(3)})},
+			"a,b,c"},
+		{"desc with limit", isql.ScatterOptions{OrderBy: []isql.PageOrder{{Column: "n", Desc: true}}, Limit: 4},
+			[]isql.ResultSet{
+				set(nil, []interface{}{"a", int64(9)}, []interface{}{"b", 5.5}, []interface{}{"c", int64(1)}),
+				set(nil, []interface{}{"d", int64(7)}, []interface{}{"e", int64(2)}),
+			},
+			"a,d,b,e"},
+		{"text integers", isql.ScatterOptions{OrderBy: []isql.PageOrder{{Column: "N"}}},
+			[]isql.ResultSet{
+				set(bigint, []interface{}{[]byte("a"), []byte("9")}, []interface{}{[]byte("b"), []byte("100")}),
+				set(bigint, []interface{}{[]byte("c"), []byte("10")}, []interface{}{[]byte("d"), []byte("11")}),
+			},
+			"a,c,d,b"},
+		{"text and binary integers", isql.ScatterOptions{OrderBy: []isql.PageOrder{{Column: "n"}}},
+			[]isql.ResultSet{
+				set(bigint, []interface{}{"a", []byte("9")}, []interface{}{"b", []byte("100")}),
+				set(nil, []interface{}{"c", int64(10)}, []interface{}{"d", uint64(11)}),
+			},
+			"a,c,d,b"},
+		{"nulls first", isql.ScatterOptions{OrderBy: []isql.PageOrder{{Column: "n"}}},
+			[]isql.ResultSet{set(nil, []interface{}{"a", nil}, []interface{}{"b", int64(2)}), set(nil, []interface{}{"c", nil}, []interface{}{"d", int64(1)})},
+			"a,c,d,b"},
+		{"nulls last", isql.ScatterOptions{OrderBy: []isql.PageOrder{{Column: "n", NullsLast: true}}},
+			[]isql.ResultSet{set(nil, []interface{}{"b", int64(2)}, []interface{}{"a", nil}), set(nil, []interface{}{"d", int64(1)}, []interface{}{"c", nil})},
+			"d,b,a,c"},
+		{"desc nulls last", isql.ScatterOptions{OrderBy: []isql.PageOrder{{Column: "n", Desc: true, NullsLast: true}}},
+			[]isql.ResultSet{set(nil, []interface{}{"b", int64(2)}, []interface{}{"a", nil}), set(nil, []interface{}{"d", int64(1)}, []interface{}{"c", nil})},
+			"b,d,a,c"},
+		{"tie break", isql.ScatterOptions{OrderBy: []isql.PageOrder{{Column: "n"}, {Column: "id", Desc: true}}, Limit: 3},
+			[]isql.ResultSet{set(nil, []interface{}{"a", int64(1)}, []interface{}{"d", int64(2)}), set(nil, []interface{}{"c", int64(1)}, []interface{}{"b", int64(1)})},
+			"c,b,a"},
+	}
+	for _, tt := range tests {
+		shards := make([]*shardDB, len(tt.sets))
+		for i := range tt.sets {
+			shards[i] = &shardDB{set: tt.sets[i]}
+		}
+		rows, err := newShardSet(isql.NewHashStrategy(), shards...).ScatterQueryContext(context.Background(), tt.opts, "SELECT id, n FROM t")
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		var ids []string
+		for rows.Next() {
+			var id string
+			var n interface{}
+			if err := rows.Scan(&id, &n); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if strings.Join(ids, ",") != tt.ids {
+			t.Errorf("%s: ids %q, expected %q", tt.name, ids, tt.ids)
+		}
+	}
+}
+
+func TestScatterQueryLimit(t *testing.T) {
+	// each shard fails on its third row, which is only read if more than Limit rows are read from it
+	set := isql.ResultSet{Columns: []string{"id"}, Rows: [][]interface{}{{"a"}, {"b"}, {"c"}}, Err: errors.New("read too far"), ErrAt: 2}
+	for _, opts := range []isql.ScatterOptions{{Limit: 2}, {OrderBy: []isql.PageOrder{{Column: "id"}}, Limit: 2}} {
+		rows, err := newShardSet(isql.NewHashStrategy(), &shardDB{set: set}, &shardDB{set: set}).ScatterQueryContext(context.Background(), opts, "SELECT id FROM t")
+		if err != nil {
+			t.Fatalf("ordered %t: %v", opts.OrderBy != nil, err)
+		}
+		n := 0
+		for rows.Next() {
+			n++
+		}
+		rows.Close()
+		if n != 2 {
+			t.Fatalf("ordered %t: %d rows, expected 2", opts.OrderBy != nil, n)
+		}
+	}
+}
+
+func TestScatterQueryErrors(t *testing.T) {
+	boom := errors.New("boom")
+	rows := isql.ResultSet{Columns: []string{"id"}, Rows: [][]interface{}{{"a"}}}
+	tests := []struct {
+		name   string
+		opts   isql.ScatterOptions
+		shards []*shardDB
+		err    string
+	}{
+		{"shard error", isql.ScatterOptions{}, []*shardDB{{set: rows}, {err: boom}}, "isql: shard 1: boom"},
+		{"columns", isql.ScatterOptions{}, []*shardDB{{set: rows}, {set: isql.ResultSet{Columns: []string{"name"}}}},
+			"isql: shard 1 returned column name where shard 0 returned id"},
+		{"order column", isql.ScatterOptions{OrderBy: []isql.PageOrder{{Column: "n"}}}, []*shardDB{{set: rows}},
+			"isql: scatter order column n is not in the query result"},
+		{"incomparable", isql.ScatterOptions{OrderBy: []isql.PageOrder{{Column: "id"}}},
+			[]*shardDB{{set: rows}, {set: isql.ResultSet{Columns: []string{"id"}, Rows: [][]interface{}{{int64(1)}}}}},
+			"isql: scatter order column id: can't compare int64 with string"},
+	}
+	for _, tt := range tests {
+		_, err := newShardSet(isql.NewHashStrategy(), tt.shards...).ScatterQueryContext(context.Background(), tt.opts, "SELECT id FROM t")
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s: error %v, expected %q", tt.name, err, tt.err)
+		}
+	}
+}
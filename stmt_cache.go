@@ -0,0 +1,155 @@
+package isql
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// WithStmtCache enables an LRU cache of prepared statements keyed by query text which is used
+// transparently by the DBs Exec/Query methods and by any Tx it begins.
+func WithStmtCache(maxSize int) Option {
+	return func(o *options) {
+		o.stmtCacheSize = maxSize
+	}
+}
+
+type StmtCacheStats struct {
+	Size      int
+	MaxSize   int
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+func newStmtCache(db *sql.DB, maxSize int) *stmtCache {
+	return &stmtCache{
+		db:      db,
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element, maxSize),
+	}
+}
+
+type stmtCache struct {
+	mtx       sync.Mutex
+	db        *sql.DB
+	maxSize   int
+	lru       *list.List
+	entries   map[string]*list.Element
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+type stmtCacheEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// acquire returns the cached statement for query, preparing it on a miss, every call must be paired
+// with a call to release so evicted statements are not closed while in use.
+func (c *stmtCache) acquire(ctx context.Context, query string) (*stmtCacheEntry, error) {
+	c.mtx.Lock()
+	if el, ok := c.entries[query]; ok {
+		c.hits++
+		e := c.use(el)
+		c.mtx.Unlock()
+		return e, nil
+	}
+	c.misses++
+	c.mtx.Unlock()
+
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mtx.Lock()
+	if el, ok := c.entries[query]; ok {
+		// prepared concurrently by another caller
+		e := c.use(el)
+		c.mtx.Unlock()
+		stmt.Close()
+		return e, nil
+	}
+	e := &stmtCacheEntry{
+		query: query,
+		stmt:  stmt,
+		refs:  1,
+	}
+	c.entries[query] = c.lru.PushFront(e)
+	var toClose []*sql.Stmt
+	for c.lru.Len() > c.maxSize {
+		if s := c.evict(c.lru.Back()); s != nil {
+			toClose = append(toClose, s)
+		}
+	}
+	c.mtx.Unlock()
+	for _, s := range toClose {
+		s.Close()
+	}
+	return e, nil
+}
+
+func (c *stmtCache) release(e *stmtCacheEntry) {
+	c.mtx.Lock()
+	e.refs--
+	closeStmt := e.evicted && e.refs == 0
+	c.mtx.Unlock()
+	if closeStmt {
+		e.stmt.Close()
+	}
+}
+
+func (c *stmtCache) use(el *list.Element) *stmtCacheEntry {
+	c.lru.MoveToFront(el)
+	e := el.Value.(*stmtCacheEntry)
+	e.refs++
+	return e
+}
+
+// evict must be called with mtx held, it returns the statement to close if it is no longer in use.
+func (c *stmtCache) evict(el *list.Element) *sql.Stmt {
+	e := c.lru.Remove(el).(*stmtCacheEntry)
+	delete(c.entries, e.query)
+	e.evicted = true
+	c.evictions++
+	if e.refs == 0 {
+		return e.stmt
+	}
+	return nil
+}
+
+func (c *stmtCache) stats() StmtCacheStats {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return StmtCacheStats{
+		Size:      c.lru.Len(),
+		MaxSize:   c.maxSize,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
+
+func (c *stmtCache) close() {
+	c.mtx.Lock()
+	var toClose []*sql.Stmt
+	for _, el := range c.entries {
+		e := el.Value.(*stmtCacheEntry)
+		e.evicted = true
+		if e.refs == 0 {
+			toClose = append(toClose, e.stmt)
+		}
+	}
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.mtx.Unlock()
+	for _, s := range toClose {
+		s.Close()
+	}
+}
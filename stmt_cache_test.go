@@ -0,0 +1,90 @@
+package isql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0xor1/isql"
+	"github.com/0xor1/isql/isqltest"
+)
+
+func TestStmtCache(t *testing.T) {
+	f := isqltest.NewFake("stmt_cache")
+	defer f.Close()
+	f.On(isqltest.Regex(`^SELECT`)).WillReturnRows([]string{"n"}, []interface{}{1}, []interface{}{2})
+	f.On(isqltest.Regex(`^UPDATE`)).WillReturnResult(0, 1)
+	db, err := isql.NewOpener(isql.WithStmtCache(2)).Open(isqltest.DriverName, "stmt_cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	// a rows still open when its statement is evicted must stay readable
+	open, err := db.QueryContext(ctx, "SELECT a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		query string
+		tx    bool
+		stats isql.StmtCacheStats
+	}{
+		{"SELECT a", false, isql.StmtCacheStats{Size: 1, MaxSize: 2, Hits: 1, Misses: 1}},
+		{"SELECT b", false, isql.StmtCacheStats{Size: 2, MaxSize: 2, Hits: 1, Misses: 2}},
+		{"UPDATE a", false, isql.StmtCacheStats{Size: 2, MaxSize: 2, Hits: 1, Misses: 3, Evictions: 1}},
+		{"SELECT b", true, isql.StmtCacheStats{Size: 2, MaxSize: 2, Hits: 2, Misses: 3, Evictions: 1}},
+		{"SELECT a", true, isql.StmtCacheStats{Size: 2, MaxSize: 2, Hits: 2, Misses: 4, Evictions: 2}},
+	}
+	for i, s := range steps {
+		var core isql.DBCore = db
+		var tx isql.Tx
+		if s.tx {
+			if tx, err = db.BeginTx(ctx, nil); err != nil {
+				t.Fatal(err)
+			}
+			core = tx
+		}
+		if s.query[0] == 'U' {
+			_, err = core.ExecContext(ctx, s.query)
+		} else {
+			var n int
+			err = core.QueryRowContext(ctx, s.query).Scan(&n)
+		}
+		if err != nil {
+			t.Fatalf("step %d: %s", i, err)
+		}
+		if tx != nil {
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if stats := db.StmtCacheStats(); stats != s.stats {
+			t.Fatalf("step %d: stats %+v, expected %+v", i, stats, s.stats)
+		}
+	}
+	n := 0
+	for open.Next() {
+		n++
+	}
+	if err := open.Close(); err != nil || n != 2 {
+		t.Fatalf("read %d rows from evicted statement: %v", n, err)
+	}
+}
+
+func TestStmtCacheDisabled(t *testing.T) {
+	f := isqltest.NewFake("stmt_cache_disabled")
+	defer f.Close()
+	f.On(isqltest.Regex(``)).WillReturnResult(0, 1)
+	db, err := isql.NewOpener().Open(isqltest.DriverName, "stmt_cache_disabled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(context.Background(), "UPDATE a"); err != nil {
+		t.Fatal(err)
+	}
+	if stats := db.StmtCacheStats(); stats != (isql.StmtCacheStats{}) {
+		t.Fatalf("stats %+v, expected none", stats)
+	}
+}
@@ -0,0 +1,85 @@
+package isql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Timeouts are applied to queries whose context has no deadline, zero values apply no timeout.
+type Timeouts struct {
+	// Read applies to Query and QueryRow calls, the timeout covers reading the results and is released when
+	// the Rows are closed or the Row is scanned.
+	Read time.Duration
+	// Write applies to Exec calls.
+	Write time.Duration
+	// Tx is the maximum duration of a transaction from Begin to Commit or Rollback, a transaction still open when
+	// it expires is rolled back.
+	Tx time.Duration
+}
+
+// WithTimeouts sets default timeouts for the DBs Exec/Query/QueryRow methods, including those without a
+// context, and for any Tx it begins. Statements from Prepare are not covered.
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(o *options) {
+		o.timeouts = timeouts
+	}
+}
+
+type queryTimeoutKey struct{}
+
+// WithQueryTimeout overrides the default timeout for queries run with the returned context, zero disables it.
+// Like the defaults it only applies if the context has no deadline.
+func WithQueryTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, d)
+}
+
+func (t *Timeouts) read(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withDefaultTimeout(ctx, t.Read)
+}
+
+func (t *Timeouts) write(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withDefaultTimeout(ctx, t.Write)
+}
+
+func (t *Timeouts) tx(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withDefaultTimeout(ctx, t.Tx)
+}
+
+func withDefaultTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, noCancel
+	}
+	if override, ok := ctx.Value(queryTimeoutKey{}).(time.Duration); ok {
+		d = override
+	}
+	if d <= 0 {
+		return ctx, noCancel
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+func noCancel() {}
+
+// newTimedRows returns rows which call cancel when closed, or cancels immediately if there are none.
+func newTimedRows(rows *sql.Rows, cancel context.CancelFunc) Rows {
+	if rows == nil {
+		cancel()
+		return nil
+	}
+	r := NewRows(rows).(*rowsWrapper)
+	r.cancel = cancel
+	return r
+}
+
+// newTimedRow returns a row which calls cancel once scanned.
+func newTimedRow(row *sql.Row, cancel context.CancelFunc) Row {
+	if row == nil {
+		cancel()
+		return nil
+	}
+	return &rowWrapper{
+		row:    row,
+		cancel: cancel,
+	}
+}
@@ -0,0 +1,189 @@
+package isql_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xor1/isql"
+)
+
+// ctxConnector opens connections which record the context of every query run on them.
+type ctxConnector struct {
+	ctxs *[]context.Context
+}
+
+func (c ctxConnector) Connect(context.Context) (driver.Conn, error) {
+	return ctxConn(c), nil
+}
+
+func (c ctxConnector) Driver() driver.Driver {
+	return nil
+}
+
+type ctxConn struct {
+	ctxs *[]context.Context
+}
+
+func (c ctxConn) Prepare(string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c ctxConn) Close() error {
+	return nil
+}
+
+func (c ctxConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+func (c ctxConn) Commit() error {
+	return nil
+}
+
+func (c ctxConn) Rollback() error {
+	return nil
+}
+
+func (c ctxConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	*c.ctxs = append(*c.ctxs, ctx)
+	return driver.RowsAffected(1), nil
+}
+
+// QueryContext returns a result set per statement of query, each of one row.
+func (c ctxConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	*c.ctxs = append(*c.ctxs, ctx)
+	return &oneRow{sets: strings.Count(query, ";")}, nil
+}
+
+type oneRow struct {
+	done bool
+	// sets is the number of result sets after this one
+	sets int
+}
+
+func (r *oneRow) HasNextResultSet() bool {
+	return r.sets > 0
+}
+
+func (r *oneRow) NextResultSet() error {
+	if r.sets == 0 {
+		return io.EOF
+	}
+	r.sets--
+	r.done = false
+	return nil
+}
+
+func (r *oneRow) Columns() []string {
+	return []string{"a"}
+}
+
+func (r *oneRow) Close() error {
+	return nil
+}
+
+func (r *oneRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func TestTimeouts(t *testing.T) {
+	var ctxs []context.Context
+	db, err := isql.NewOpener(isql.WithTimeouts(isql.Timeouts{Read: 5 * time.Second, Write: 10 * time.Second, Tx: time.Minute})).
+		OpenConnector(ctxConnector{&ctxs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	deadline, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var a int
+	calls := []struct {
+		name    string
+		call    func()
+		timeout time.Duration
+	}{
+		{"query row", func() { db.QueryRow("SELECT a").Scan(&a) }, 5 * time.Second},
+		{"query", func() {
+			rows, _ := db.Query("SELECT a")
+			rows.Close()
+		}, 5 * time.Second},
+		{"exec", func() { db.Exec("UPDATE t") }, 10 * time.Second},
+		{"disabled", func() { db.QueryRowContext(isql.WithQueryTimeout(context.Background(), 0), "SELECT a").Scan(&a) }, 0},
+		{"override", func() {
+			db.QueryRowContext(isql.WithQueryTimeout(context.Background(), 2*time.Second), "SELECT a").Scan(&a)
+		}, 2 * time.Second},
+		{"deadline", func() { db.ExecContext(deadline, "UPDATE t") }, 30 * time.Second},
+		{"tx", func() {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx.Exec("UPDATE t")
+			tx.Commit()
+		}, 10 * time.Second},
+	}
+	for i, c := range calls {
+		c.call()
+		if len(ctxs) != i+1 {
+			t.Fatalf("%s: ran %d queries", c.name, len(ctxs))
+		}
+		timeout := time.Duration(0)
+		if d, ok := ctxs[i].Deadline(); ok {
+			timeout = time.Until(d).Round(time.Second)
+		}
+		if timeout != c.timeout {
+			t.Errorf("%s: timeout %v, expected %v", c.name, timeout, c.timeout)
+		}
+	}
+}
+
+func TestTimeoutReleased(t *testing.T) {
+	var ctxs []context.Context
+	db, err := isql.NewOpener(isql.WithTimeouts(isql.Timeouts{Read: time.Minute})).OpenConnector(ctxConnector{&ctxs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var a int
+	ends := map[string]func(rows isql.Rows){
+		"exhausted": func(rows isql.Rows) {
+			for rows.Next() {
+			}
+		},
+		"closed": func(rows isql.Rows) {
+			rows.Next()
+			rows.Close()
+		},
+	}
+	for name, end := range ends {
+		rows, err := db.Query("SELECT a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := ctxs[len(ctxs)-1]
+		if ctx.Err() != nil {
+			t.Fatalf("%s: timeout released before the rows ended", name)
+		}
+		end(rows)
+		if ctx.Err() != context.Canceled {
+			t.Errorf("%s: error %v, expected the timeout to be released", name, ctx.Err())
+		}
+	}
+	db.QueryRow("SELECT a").Scan(&a)
+	if err := ctxs[len(ctxs)-1].Err(); err != context.Canceled {
+		t.Errorf("error %v, expected the timeout to be released once the row is scanned", err)
+	}
+	if len(ctxs) != 3 {
+		t.Fatalf("ran %d queries", len(ctxs))
+	}
+}